@@ -0,0 +1,482 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// Version returns the version string reported by each memcached instance,
+// keyed by instance address.
+//
+// Errors are accumulated using errors.Join, and the versions of instances that
+// did respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Version(opts ...Option) (map[string]string, error) {
+	options := c.options(opts)
+	versions := make(map[string]string)
+
+	err := c.each(options.ctx, "version", func(address string, conn *iopool.Buffer) error {
+		version, err := readVersion(conn)
+		if err != nil {
+			return err
+		}
+		versions[address] = version
+		return nil
+	})
+
+	return versions, err
+}
+
+// readVersion executes the "version" command on conn, returning the version
+// reported by the memcached instance
+func readVersion(conn *iopool.Buffer) (string, error) {
+	if _, err := fmt.Fprint(conn, "version\r\n"); err != nil {
+		return "", err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return "", err
+	}
+
+	line, err := conn.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+
+	version, ok := strings.CutPrefix(string(line), "VERSION ")
+	if !ok {
+		return "", unexpected(line)
+	}
+	return strings.TrimSpace(version), nil
+}
+
+// Ping checks that every memcached instance responds to a "version" command,
+// executed on a connection from the pool of each instance, such that it is
+// suitable for the readiness probe of a service depending on the instances.
+// Instances are pinged concurrently, up to the limit set by SetFanOut.
+//
+// The errors of instances that did not respond are accumulated using
+// errors.Join. If the Client has no instances, ErrNoInstances is returned.
+//
+// Once ctx is done, pings still waiting on a response are abandoned.
+func (c *Client) Ping(ctx context.Context) error {
+	results := c.PingAll(ctx)
+	if len(results) == 0 {
+		return ErrNoInstances
+	}
+
+	var errs []error
+	for _, address := range slices.Sorted(maps.Keys(results)) {
+		errs = append(errs, results[address])
+	}
+	return errors.Join(errs...)
+}
+
+// PingAll is like Ping, but returns the outcome of pinging each memcached
+// instance, keyed by instance address, which is nil for each instance that
+// responded.
+func (c *Client) PingAll(ctx context.Context) map[string]error {
+	addresses := c.instances()
+	groups := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		groups[address] = struct{}{}
+	}
+
+	var lock sync.Mutex
+	results := make(map[string]error, len(addresses))
+	fanOut(c, groups, func(address string, _ struct{}) {
+		err := c.doInstance(ctx, "ping", address, func(conn *iopool.Buffer) error {
+			_, err := readVersion(conn)
+			return err
+		})
+
+		lock.Lock()
+		results[address] = err
+		lock.Unlock()
+	})
+	return results
+}
+
+// defaultReadyInterval is how often WaitUntilReady pings the memcached
+// instances if no positive interval is given
+const defaultReadyInterval = 250 * time.Millisecond
+
+// WaitUntilReady blocks until every memcached instance responds to a ping as
+// with Ping, pinging the instances again every interval, such that a service
+// may wait on its cache during startup. If interval is not positive, instances
+// are pinged every 250 milliseconds.
+//
+// Once ctx is done, the error of ctx is returned along with the errors of the
+// instances that last failed to respond. If the Client is closed while waiting,
+// ErrClientClosed is returned.
+func (c *Client) WaitUntilReady(ctx context.Context, interval time.Duration) error {
+	return c.WaitUntilQuorum(ctx, interval, 0)
+}
+
+// WaitUntilQuorum is like WaitUntilReady, but returns once at least quorum of
+// the memcached instances respond, or every instance if quorum is not positive
+// or is more than the number of instances.
+func (c *Client) WaitUntilQuorum(ctx context.Context, interval time.Duration, quorum int) error {
+	if interval <= 0 {
+		interval = defaultReadyInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// the errors of the last round of pings not interrupted by ctx
+	var last []error
+
+	for {
+		results := c.PingAll(ctx)
+
+		ready := 0
+		var errs []error
+		if len(results) == 0 {
+			errs = append(errs, ErrNoInstances)
+		}
+		for _, address := range slices.Sorted(maps.Keys(results)) {
+			if err := results[address]; err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			ready++
+		}
+
+		need := len(results)
+		if quorum > 0 {
+			need = min(quorum, need)
+		}
+		if need > 0 && ready >= need {
+			return nil
+		}
+		if ctx.Err() == nil {
+			last = errs
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(append([]error{ctx.Err()}, last...)...)
+		case <-c.stop:
+			return ErrClientClosed
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stats returns runtime statistics reported by each memcached instance, keyed
+// by instance address.
+//
+// Errors are accumulated using errors.Join, and the statistics of instances
+// that did respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Stats(opts ...Option) (map[string]*Statistics, error) {
+	return queryInstances(c, "stats", "stats\r\n", stats, opts)
+}
+
+// ClusterStatistics are the statistics of every memcached instance combined.
+type ClusterStatistics struct {
+	// Instances is the number of instances whose statistics are combined.
+	Instances int
+
+	// Items is the number of items stored.
+	Items int
+
+	// Bytes is the number of bytes used to store items.
+	Bytes int
+
+	// Limit is the number of bytes instances are allowed to use for storage.
+	Limit int
+
+	// Connections is the number of open connections.
+	Connections int
+
+	// Gets is the number of get commands, each of which is a hit or a miss.
+	Gets int
+
+	// Hits is the number of keys requested and found.
+	Hits int
+
+	// Misses is the number of keys requested and not found.
+	Misses int
+
+	// Evictions is the number of items evicted to free memory.
+	Evictions int
+}
+
+// HitRatio returns the fraction of requested keys that were found, or 0 if no
+// keys were requested.
+func (s *ClusterStatistics) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// AggregateStats returns the statistics of every memcached instance combined,
+// e.g. for dashboards showing a single figure for the whole cluster.
+//
+// Errors are accumulated using errors.Join, and the statistics of instances
+// that did respond are still combined.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) AggregateStats(opts ...Option) (*ClusterStatistics, error) {
+	statistics, err := c.Stats(opts...)
+
+	result := new(ClusterStatistics)
+	for _, s := range statistics {
+		result.Instances++
+		result.Items += s.Items.Current
+		result.Bytes += s.Items.Bytes
+		result.Limit += s.Memory.Limit
+		result.Connections += s.Connections.Current
+		result.Gets += s.Commands.Get
+		result.Hits += s.Commands.Hit.Get
+		result.Misses += s.Commands.Miss.Get
+		result.Evictions += s.Items.Evictions
+	}
+
+	return result, err
+}
+
+// StatsItems returns item statistics of each slab class reported by each
+// memcached instance, keyed by instance address, such as the number and age of
+// items, and the number of items evicted or expired without being fetched.
+//
+// Errors are accumulated using errors.Join, and the statistics of instances
+// that did respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) StatsItems(opts ...Option) (map[string][]*ItemStatistics, error) {
+	return queryInstances(c, "stats_items", "stats items\r\n", items, opts)
+}
+
+// StatsSlabs returns slab statistics reported by each memcached instance, keyed
+// by instance address.
+//
+// Errors are accumulated using errors.Join, and the statistics of instances
+// that did respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) StatsSlabs(opts ...Option) (map[string]*SlabStatistics, error) {
+	return queryInstances(c, "stats_slabs", "stats slabs\r\n", slabs, opts)
+}
+
+// StatsConns returns the state of each connection to each memcached instance,
+// keyed by instance address, e.g. to identify which clients hold connections.
+//
+// Errors are accumulated using errors.Join, and the statistics of instances
+// that did respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) StatsConns(opts ...Option) (map[string][]*ConnStatistics, error) {
+	return queryInstances(c, "stats_conns", "stats conns\r\n", conns, opts)
+}
+
+// queryInstances writes the command cmd to each memcached instance, parsing
+// each response using parse, and returns the results keyed by instance address
+func queryInstances[R any](c *Client, op, cmd string, parse func(io.Reader) (R, error), opts []Option) (map[string]R, error) {
+	options := c.options(opts)
+	results := make(map[string]R)
+
+	err := c.each(options.ctx, op, func(address string, conn *iopool.Buffer) error {
+		if _, err := fmt.Fprint(conn, cmd); err != nil {
+			return err
+		}
+
+		// flush the connection, forcing bytes over the wire
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+
+		result, err := parse(conn.Reader)
+		if err != nil {
+			return err
+		}
+		results[address] = result
+		return nil
+	})
+
+	return results, err
+}
+
+// Each calls f with a connection to each memcached instance, along with the
+// address of the instance, e.g. to execute administrative commands such as
+// "flush_all" or "verbosity" which are not routed by key. The connection is
+// returned to its pool once f returns, so f must read the complete response to
+// each command it writes, and must not retain conn. A connection on which f
+// fails other than with an expected response such as ErrCacheMiss is closed
+// rather than reused.
+//
+// Instances are visited one after another. Errors are accumulated using
+// errors.Join, each identifying its instance as a ServerError, and f is still
+// called for the instances following one that failed.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Each(f func(address string, conn *iopool.Buffer) error, opts ...Option) error {
+	options := c.options(opts)
+	return c.each(options.ctx, "each", f)
+}
+
+// each calls f with a connection to each memcached instance as command op,
+// accumulating the error of each instance
+func (c *Client) each(ctx context.Context, op string, f func(string, *iopool.Buffer) error) error {
+	var errs []error
+	for _, address := range c.instances() {
+		err := c.doInstance(ctx, op, address, func(conn *iopool.Buffer) error {
+			return f(address, conn)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddServer adds the memcached instance of address to the set of instances the
+// Client shards keys across. Adding an instance that is already part of the set
+// has no effect.
+func (c *Client) AddServer(address string) {
+	c.core.lock.Lock()
+	if slices.Contains(c.addrs, address) {
+		c.core.lock.Unlock()
+		return
+	}
+	c.addrs = append(slices.Clip(c.addrs), address)
+	c.core.lock.Unlock()
+
+	c.rebalance()
+}
+
+// RemoveServer removes the memcached instance of address from the set of
+// instances the Client shards keys across. Idle connections to the instance are
+// closed immediately, and connections in use are closed once their request
+// completes. Removing an instance that is not part of the set has no effect.
+func (c *Client) RemoveServer(address string) {
+	c.core.lock.Lock()
+	if !slices.Contains(c.addrs, address) {
+		c.core.lock.Unlock()
+		return
+	}
+	c.addrs = slices.DeleteFunc(slices.Clone(c.addrs), func(s string) bool {
+		return s == address
+	})
+	c.core.lock.Unlock()
+
+	c.rebalance()
+}
+
+// PoolStats describes the connection pool of a memcached instance, such as the
+// number of open, idle, and in use connections, and how often connections were
+// waited for, established, and closed.
+type PoolStats = iopool.Stats
+
+// PoolStats returns the PoolStats of the connection pool of each memcached
+// instance, keyed by instance address. Counters accumulate from when the pool
+// of each instance was created.
+func (c *Client) PoolStats() map[string]PoolStats {
+	return c.pools.Stats()
+}
+
+// ErrSlabsReassign is returned when memcached refuses to reassign a slab page,
+// wrapped along with the reason given by memcached, e.g. "BUSY" while another
+// page is being moved, or "NOSPARE" when the source class has no spare pages.
+// The refusal is an expected response, and is not wrapped in a ServerError.
+var ErrSlabsReassign = errors.New("memc: slab page was not reassigned")
+
+// SlabsReassign moves a page of memory from the source slab class to the dest
+// slab class on the memcached instance of address. A source of -1 picks any
+// slab class with spare pages.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func (c *Client) SlabsReassign(address string, source, dest int, opts ...Option) error {
+	options := c.options(opts)
+
+	return c.doInstance(options.ctx, "slabs_reassign", address, func(conn *iopool.Buffer) error {
+		line, err := command(conn, fmt.Sprintf("slabs reassign %d %d\r\n", source, dest))
+		switch {
+		case err != nil:
+			return err
+		case string(line) == "OK\r\n":
+			return nil
+		default:
+			return fmt.Errorf("%w: %s", ErrSlabsReassign, strings.TrimSpace(string(line)))
+		}
+	})
+}
+
+// AutomoveMode determines how memcached moves slab pages between slab classes
+// in the background.
+type AutomoveMode int
+
+const (
+	// AutomoveOff disables moving slab pages in the background.
+	AutomoveOff AutomoveMode = 0
+
+	// AutomoveStandard moves slab pages from slab classes with free memory to
+	// those with the most evictions, as evictions accumulate.
+	AutomoveStandard AutomoveMode = 1
+
+	// AutomoveAggressive moves a slab page to a slab class on every eviction.
+	AutomoveAggressive AutomoveMode = 2
+)
+
+// SlabsAutomove sets the AutomoveMode of each memcached instance.
+//
+// Errors are accumulated using errors.Join.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) SlabsAutomove(mode AutomoveMode, opts ...Option) error {
+	options := c.options(opts)
+
+	return c.each(options.ctx, "slabs_automove", func(_ string, conn *iopool.Buffer) error {
+		line, err := command(conn, fmt.Sprintf("slabs automove %d\r\n", mode))
+		switch {
+		case err != nil:
+			return err
+		case string(line) == "OK\r\n":
+			return nil
+		default:
+			return unexpected(line)
+		}
+	})
+}
+
+// command writes cmd to conn and returns the single line of its response, which
+// references the buffer of conn and is valid only until conn is read again
+func command(conn *iopool.Buffer, cmd string) ([]byte, error) {
+	if _, err := fmt.Fprint(conn, cmd); err != nil {
+		return nil, err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+
+	return conn.ReadSlice('\n')
+}
@@ -0,0 +1,63 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+// A Future is the eventual result of an operation started by GetAsync or
+// SetAsync, which is resolved once the response of the memcached instance
+// arrives.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done returns a channel which is closed once f is resolved, for use in a
+// select statement.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until f is resolved, and returns the result of its operation.
+func (f *Future[T]) Wait() (T, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// async returns a Future resolved with the result of op, which is run in the
+// background
+func async[T any](op func() (T, error)) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.value, f.err = op()
+	}()
+	return f
+}
+
+// GetAsync starts reading the value associated with key as Get does, and
+// returns a Future resolved with the value once it is read, so that the caller
+// may overlap cache I/O with other work. With SetMultiplexing, the commands of
+// many operations in flight are pipelined over a single connection.
+//
+// Operations started by GetAsync and SetAsync run concurrently and may reach
+// memcached in any order, so wait on a Future before starting an operation
+// that depends on its outcome.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func GetAsync[T any](c *Client, key string, opts ...Option) *Future[T] {
+	return async(func() (T, error) {
+		return Get[T](c, key, opts...)
+	})
+}
+
+// SetAsync starts storing item as Set does, and returns a Future resolved once
+// the item is stored, whose Wait returns the error of Set, if any.
+//
+// See GetAsync for the ordering of operations started asynchronously.
+func SetAsync[T any](c *Client, key string, item T, opts ...Option) *Future[struct{}] {
+	return async(func() (struct{}, error) {
+		return struct{}{}, Set(c, key, item, opts...)
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestFuture(t *testing.T) {
+	t.Parallel()
+
+	t.Run("value", func(t *testing.T) {
+		release := make(chan struct{})
+		f := async(func() (int, error) {
+			<-release
+			return 42, nil
+		})
+
+		select {
+		case <-f.Done():
+			t.Fatal("resolved before the operation completed")
+		default:
+		}
+
+		close(release)
+		v, err := f.Wait()
+		must.NoError(t, err)
+		must.Eq(t, 42, v)
+
+		// waiting again returns the same result
+		v, err = f.Wait()
+		must.NoError(t, err)
+		must.Eq(t, 42, v)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		oops := errors.New("oops")
+		f := async(func() (string, error) {
+			return "", oops
+		})
+
+		<-f.Done()
+		_, err := f.Wait()
+		must.ErrorIs(t, err, oops)
+	})
+}
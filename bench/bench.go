@@ -0,0 +1,330 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package bench generates load against memcached instances using a memc.Client,
+// driving a configurable mix of gets and sets over a set of keys, and reports
+// the throughput and latency achieved.
+//
+// The package is used by the benchmarks of memc, so that changes to connection
+// pooling or protocol parsing can be evaluated reproducibly, and may be used
+// against a real cluster by configuring the Client accordingly.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"cattlecloud.net/go/memc"
+)
+
+// Defaults applied to the zero fields of a Workload.
+const (
+	DefaultDuration  = 10 * time.Second
+	DefaultKeys      = 1000
+	DefaultValueSize = 100
+	DefaultPrefix    = "bench:"
+)
+
+// Workload describes the load generated by Run.
+type Workload struct {
+	// Workers is the number of goroutines issuing operations concurrently,
+	// defaulting to GOMAXPROCS.
+	Workers int
+
+	// Operations is the total number of operations to issue. If zero, operations
+	// are issued until Duration has elapsed instead.
+	Operations int
+
+	// Duration is how long operations are issued for, if Operations is zero,
+	// defaulting to DefaultDuration.
+	Duration time.Duration
+
+	// Reads is the fraction of operations which are gets, between 0 and 1, with
+	// the remainder being sets.
+	Reads float64
+
+	// Keys is the number of distinct keys operated on, defaulting to
+	// DefaultKeys.
+	Keys int
+
+	// Skew, if greater than 1, is the exponent of the Zipf distribution keys are
+	// chosen from, such that some keys are much hotter than others. Otherwise
+	// keys are chosen uniformly.
+	Skew float64
+
+	// ValueSize is the size in bytes of each value set, defaulting to
+	// DefaultValueSize.
+	ValueSize int
+
+	// Prefix is prepended to each key, defaulting to DefaultPrefix.
+	Prefix string
+
+	// Preload sets every key before operations begin, such that gets hit.
+	Preload bool
+
+	// Seed seeds the choice of operations and keys, such that runs of the same
+	// Workload issue the same operations.
+	Seed uint64
+}
+
+// defaults returns w with each zero field set to its default
+func (w Workload) defaults() Workload {
+	if w.Workers <= 0 {
+		w.Workers = runtime.GOMAXPROCS(0)
+	}
+	if w.Operations <= 0 && w.Duration <= 0 {
+		w.Duration = DefaultDuration
+	}
+	if w.Keys <= 0 {
+		w.Keys = DefaultKeys
+	}
+	if w.ValueSize <= 0 {
+		w.ValueSize = DefaultValueSize
+	}
+	if w.Prefix == "" {
+		w.Prefix = DefaultPrefix
+	}
+	return w
+}
+
+// Latency is the distribution of the latency of operations.
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// Report is the outcome of a Run.
+type Report struct {
+	// Operations is the number of operations issued, of which Gets were gets
+	// and Sets were sets.
+	Operations int64
+	Gets       int64
+	Sets       int64
+
+	// Hits and Misses count the gets which found a value and which did not.
+	Hits   int64
+	Misses int64
+
+	// Errors counts the operations which failed, other than by a cache miss.
+	Errors int64
+
+	// Elapsed is the time taken to issue every operation.
+	Elapsed time.Duration
+
+	// Latency is the distribution of the latency of every operation.
+	Latency Latency
+}
+
+// Throughput returns the number of operations completed per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Operations) / r.Elapsed.Seconds()
+}
+
+func (r *Report) String() string {
+	return fmt.Sprintf(
+		"%d ops in %s (%.0f ops/s), %d gets (%d hits, %d misses), %d sets, %d errors, latency p50 %s p90 %s p99 %s max %s",
+		r.Operations, r.Elapsed.Round(time.Millisecond), r.Throughput(),
+		r.Gets, r.Hits, r.Misses, r.Sets, r.Errors,
+		r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max,
+	)
+}
+
+// Run issues the operations described by w using Client c, returning a Report
+// of their outcome once every operation is complete or ctx is done.
+//
+// Values are read and written as []byte, such that the codec of c adds little
+// overhead, and the failure of an operation is counted rather than stopping the
+// run. An error is returned only if w is not valid or preloading fails.
+func Run(ctx context.Context, c *memc.Client, w Workload) (*Report, error) {
+	w = w.defaults()
+	if w.Reads < 0 || w.Reads > 1 {
+		return nil, errors.New("bench: reads must be between 0 and 1")
+	}
+
+	keys := make([]string, w.Keys)
+	for i := range keys {
+		keys[i] = w.Prefix + strconv.Itoa(i)
+	}
+
+	value := make([]byte, w.ValueSize)
+	fill := rand.New(rand.NewPCG(w.Seed, 0))
+	for i := range value {
+		value[i] = byte(fill.Uint32())
+	}
+
+	if w.Preload {
+		items := make([]*memc.Pair[string, []byte], 0, len(keys))
+		for _, key := range keys {
+			items = append(items, &memc.Pair[string, []byte]{A: key, B: value})
+		}
+		if err := memc.SetMulti(c, items, memc.Context(ctx)); err != nil {
+			return nil, fmt.Errorf("bench: failed to preload keys: %w", err)
+		}
+	}
+
+	if w.Duration > 0 && w.Operations <= 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Duration)
+		defer cancel()
+	}
+
+	workers := make([]*worker, w.Workers)
+	for i := range workers {
+		workers[i] = newWorker(c, &w, uint64(i), keys, value)
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i, wk := range workers {
+		operations := -1
+		if w.Operations > 0 {
+			// spread the operations evenly over the workers
+			operations = w.Operations / w.Workers
+			if i < w.Operations%w.Workers {
+				operations++
+			}
+		}
+		wg.Go(func() {
+			wk.run(ctx, operations)
+		})
+	}
+	wg.Wait()
+
+	return report(workers, time.Since(start)), nil
+}
+
+// A worker issues operations one after another, recording their outcome.
+type worker struct {
+	client *memc.Client
+	reads  float64
+	keys   []string
+	value  []byte
+	rand   *rand.Rand
+	zipf   *rand.Zipf
+
+	gets, sets, hits, misses, errors int64
+	latencies                        []time.Duration
+}
+
+func newWorker(c *memc.Client, w *Workload, id uint64, keys []string, value []byte) *worker {
+	r := rand.New(rand.NewPCG(w.Seed, id+1))
+	wk := &worker{
+		client: c,
+		reads:  w.Reads,
+		keys:   keys,
+		value:  value,
+		rand:   r,
+	}
+	if w.Skew > 1 {
+		wk.zipf = rand.NewZipf(r, w.Skew, 1, uint64(len(keys)-1))
+	}
+	return wk
+}
+
+// key returns the key of the next operation
+func (wk *worker) key() string {
+	if wk.zipf != nil {
+		return wk.keys[wk.zipf.Uint64()]
+	}
+	return wk.keys[wk.rand.IntN(len(wk.keys))]
+}
+
+// run issues operations until the given number have been issued, unless
+// negative, or ctx is done
+func (wk *worker) run(ctx context.Context, operations int) {
+	for i := 0; operations < 0 || i < operations; i++ {
+		if over(ctx) {
+			return
+		}
+
+		key := wk.key()
+		read := wk.rand.Float64() < wk.reads
+
+		start := time.Now()
+		var err error
+		if read {
+			_, err = memc.Get[[]byte](wk.client, key, memc.Context(ctx))
+		} else {
+			err = memc.Set(wk.client, key, wk.value, memc.Context(ctx))
+		}
+		elapsed := time.Since(start)
+
+		// an operation interrupted by the end of the run is not counted
+		if err != nil && over(ctx) {
+			return
+		}
+		wk.latencies = append(wk.latencies, elapsed)
+
+		switch {
+		case read && err == nil:
+			wk.gets++
+			wk.hits++
+		case read && errors.Is(err, memc.ErrCacheMiss):
+			wk.gets++
+			wk.misses++
+		case read:
+			wk.gets++
+			wk.errors++
+		case err == nil:
+			wk.sets++
+		default:
+			wk.sets++
+			wk.errors++
+		}
+	}
+}
+
+// over reports whether the run is over, as ctx is done or its deadline has
+// passed, which a dial or read interrupted by the deadline may observe before
+// ctx is done
+func over(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// report combines the outcomes recorded by each of workers
+func report(workers []*worker, elapsed time.Duration) *Report {
+	r := &Report{Elapsed: elapsed}
+
+	var latencies []time.Duration
+	for _, wk := range workers {
+		r.Gets += wk.gets
+		r.Sets += wk.sets
+		r.Hits += wk.hits
+		r.Misses += wk.misses
+		r.Errors += wk.errors
+		latencies = append(latencies, wk.latencies...)
+	}
+	r.Operations = r.Gets + r.Sets
+
+	if len(latencies) == 0 {
+		return r
+	}
+
+	slices.Sort(latencies)
+	quantile := func(q float64) time.Duration {
+		return latencies[min(int(q*float64(len(latencies))), len(latencies)-1)]
+	}
+	r.Latency = Latency{
+		P50: quantile(0.50),
+		P90: quantile(0.90),
+		P99: quantile(0.99),
+		Max: latencies[len(latencies)-1],
+	}
+	return r
+}
@@ -0,0 +1,137 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest/server"
+	"github.com/shoenig/test/must"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	address := server.LaunchTCP(t)
+	c := memc.New([]string{address})
+	t.Cleanup(func() { _ = c.Close() })
+
+	t.Run("operations", func(t *testing.T) {
+		r, err := Run(t.Context(), c, Workload{
+			Workers:    4,
+			Operations: 203,
+			Reads:      0.5,
+			Keys:       50,
+			Prefix:     "operations:",
+			Preload:    true,
+		})
+		must.NoError(t, err)
+		must.Eq(t, 203, r.Operations)
+		must.Eq(t, r.Operations, r.Gets+r.Sets)
+		must.Eq(t, r.Gets, r.Hits)
+		must.Zero(t, r.Errors)
+		must.Positive(t, r.Latency.Max)
+		must.LessEq(t, r.Latency.Max, r.Latency.P50)
+		must.Positive(t, r.Throughput())
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		r, err := Run(t.Context(), c, Workload{
+			Workers:  2,
+			Duration: 100 * time.Millisecond,
+			Reads:    1,
+			Prefix:   "duration:",
+		})
+		must.NoError(t, err)
+		must.Positive(t, r.Operations)
+		must.Eq(t, r.Operations, r.Misses)
+		must.Zero(t, r.Sets)
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		w := Workload{Workers: 1, Operations: 100, Reads: 0.3, Skew: 1.5, Seed: 7, Prefix: "seeded:"}
+
+		first, err := Run(t.Context(), c, w)
+		must.NoError(t, err)
+
+		second, err := Run(t.Context(), c, w)
+		must.NoError(t, err)
+		must.Eq(t, first.Gets, second.Gets)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Run(t.Context(), c, Workload{Reads: 2})
+		must.Error(t, err)
+	})
+}
+
+func TestReport_String(t *testing.T) {
+	t.Parallel()
+
+	r := &Report{Operations: 10, Gets: 6, Hits: 5, Misses: 1, Sets: 4, Elapsed: time.Second}
+	must.StrHasPrefix(t, "10 ops in 1s (10 ops/s), 6 gets (5 hits, 1 misses), 4 sets, 0 errors", r.String())
+}
+
+// servers returns the addresses of the memcached instances to benchmark
+// against, which are those of MEMC_BENCH_SERVERS if set, or otherwise of an
+// in-process server
+func servers(b *testing.B) []string {
+	if env := os.Getenv("MEMC_BENCH_SERVERS"); env != "" {
+		return strings.Split(env, ",")
+	}
+
+	s, err := server.Listen("tcp", "127.0.0.1:0")
+	must.NoError(b, err)
+	b.Cleanup(func() { _ = s.Close() })
+	return []string{s.Address()}
+}
+
+// BenchmarkRun drives mixes of gets and sets of values of various sizes,
+// reporting the latency of operations alongside their throughput, for example
+//
+//	go test ./bench -bench . -benchtime 100000x
+//
+// Set MEMC_BENCH_SERVERS to a comma separated list of addresses to benchmark a
+// memcached cluster rather than the in-process server.
+func BenchmarkRun(b *testing.B) {
+	addresses := servers(b)
+
+	for _, reads := range []float64{0.9, 0.5} {
+		for _, size := range []int{100, 16 << 10} {
+			name := fmt.Sprintf("reads=%.1f/size=%d", reads, size)
+			b.Run(name, func(b *testing.B) {
+				c := memc.New(addresses, memc.SetIdleConnections(16))
+				b.Cleanup(func() { _ = c.Close() })
+
+				w := Workload{
+					Workers:   8,
+					Reads:     reads,
+					Keys:      10_000,
+					Skew:      1.1,
+					ValueSize: size,
+				}
+
+				// the keys are loaded before the timer starts
+				_, err := Run(context.Background(), c, Workload{Operations: 1, Keys: w.Keys, ValueSize: size, Preload: true})
+				must.NoError(b, err)
+
+				b.ResetTimer()
+				w.Operations = b.N
+				r, err := Run(context.Background(), c, w)
+				must.NoError(b, err)
+
+				b.ReportMetric(r.Throughput(), "ops/s")
+				b.ReportMetric(float64(r.Latency.P50.Nanoseconds()), "p50-ns")
+				b.ReportMetric(float64(r.Latency.P99.Nanoseconds()), "p99-ns")
+				b.ReportMetric(float64(r.Errors), "errors")
+			})
+		}
+	}
+}
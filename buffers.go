@@ -0,0 +1,115 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"sync"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// Payload buffers are pooled by size class, where each class is a power of two
+// between 1 KiB and 1 MiB (the default maximum item size of memcached). Larger
+// payloads are allocated directly and never pooled.
+const (
+	minBufferClass = 10
+	maxBufferClass = 20
+)
+
+var buffers [maxBufferClass - minBufferClass + 1]sync.Pool
+
+// class returns the index of the smallest size class that can contain size
+// bytes, or -1 if size exceeds the largest size class
+func class(size int) int {
+	for i := range buffers {
+		if size <= 1<<(minBufferClass+i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// getBuffer returns a byte slice of length size, possibly re-using the
+// underlying array of a previously released buffer
+func getBuffer(size int) *[]byte {
+	i := class(size)
+	if i < 0 {
+		b := make([]byte, size)
+		return &b
+	}
+
+	if v := buffers[i].Get(); v != nil {
+		b := v.(*[]byte)
+		*b = (*b)[:size]
+		return b
+	}
+
+	b := make([]byte, size, 1<<(minBufferClass+i))
+	return &b
+}
+
+// putBuffer releases b back into its size class pool; b must not be used
+// after being released
+func putBuffer(b *[]byte) {
+	i := class(cap(*b))
+	if i < 0 || cap(*b) != 1<<(minBufferClass+i) {
+		return
+	}
+	buffers[i].Put(b)
+}
+
+// payloads hands out the buffers value payloads are read into, which are either
+// pooled or the scratch buffer of the connection the payloads are read from
+type payloads struct {
+	conn  *iopool.Buffer
+	reuse bool
+}
+
+// payloads returns the source of buffers for payloads read from conn
+func (c *Client) payloads(conn *iopool.Buffer) payloads {
+	return payloads{conn: conn, reuse: c.reuse && !c.multiplex}
+}
+
+// get returns a byte slice of length size to read a payload into
+func (p payloads) get(size int) *[]byte {
+	if p.reuse && size <= 1<<maxBufferClass {
+		return p.conn.Scratch(size)
+	}
+	return getBuffer(size)
+}
+
+// put releases b once the payload read into it has been decoded
+func (p payloads) put(b *[]byte) {
+	if p.reuse {
+		// the scratch buffer of the connection is never pooled, and larger
+		// buffers are never pooled either
+		return
+	}
+	putBuffer(b)
+}
+
+// Scratch buffers for encoding values are pooled as a whole, as the size of an
+// encoding is not known up front. Buffers grown beyond the largest size class
+// are dropped rather than pooled, so that an occasional large value does not
+// pin its memory.
+var scratches = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getScratch leases an empty buffer to encode a value into, which should be
+// released with putScratch once the encoding is no longer referenced
+func getScratch() *bytes.Buffer {
+	return scratches.Get().(*bytes.Buffer)
+}
+
+// putScratch releases b back into the pool; b and any encoding referencing its
+// memory must not be used after being released
+func putScratch(b *bytes.Buffer) {
+	if b.Cap() > 1<<maxBufferClass {
+		return
+	}
+	b.Reset()
+	scratches.Put(b)
+}
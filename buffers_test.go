@@ -0,0 +1,49 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_class(t *testing.T) {
+	t.Parallel()
+
+	must.Eq(t, 0, class(0))
+	must.Eq(t, 0, class(1024))
+	must.Eq(t, 1, class(1025))
+	must.Eq(t, 10, class(1<<20))
+	must.Eq(t, -1, class(1<<20+1))
+}
+
+func Test_getBuffer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pooled", func(t *testing.T) {
+		b := getBuffer(3000)
+		must.SliceLen(t, 3000, *b)
+		must.Eq(t, 4096, cap(*b))
+		putBuffer(b)
+	})
+
+	t.Run("large", func(t *testing.T) {
+		b := getBuffer(1<<20 + 1)
+		must.SliceLen(t, 1<<20+1, *b)
+		putBuffer(b)
+	})
+}
+
+func Test_getScratch(t *testing.T) {
+	t.Parallel()
+
+	b := getScratch()
+	b.WriteString("hello")
+	putScratch(b)
+
+	b = getScratch()
+	must.Zero(t, b.Len())
+	putScratch(b)
+}
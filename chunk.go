@@ -0,0 +1,162 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// chunkedFlag is set in the flags of a value that has been split into chunks,
+// in which case the value stored under its key is a manifest of the chunks
+const chunkedFlag = 1 << 27
+
+// chunked reports whether flags records the value as split into chunks
+func (c *Client) chunked(flags int) bool {
+	return !c.compat && flags&chunkedFlag != 0 && !c.missed(flags)
+}
+
+// SetMaxItemSize enables transparent chunking of values whose encoding is
+// larger than size bytes. memcached rejects items larger than its item size
+// limit (1 MiB by default, see the -I option of memcached), so such values are
+// instead split into chunks of at most size bytes, each stored under its own
+// key, along with a manifest of the chunks stored under the key of the value.
+// Reading the value reassembles the chunks.
+//
+// The size should be somewhat smaller than the item size limit of memcached,
+// leaving room for the key and metadata of each chunk.
+//
+// Chunks share the expiration TTL of their value, but are not deleted along
+// with it, and are left to expire or be evicted. Touch and Append or Prepend
+// are not supported for chunked values.
+//
+// If unset the default is to never chunk values.
+func SetMaxItemSize(size int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.maxItem = size
+	}
+}
+
+// oversized reports whether an encoding of n bytes stored with cmd must be
+// split into chunks
+func (c *Client) oversized(cmd string, n int) bool {
+	switch {
+	case c.compat, cmd == "append", cmd == "prepend":
+		return false
+	default:
+		return c.maxItem > 0 && n > c.maxItem
+	}
+}
+
+// manifest describes the chunks of a value that was split into chunks
+type manifest struct {
+	token  string
+	count  int
+	length int
+}
+
+// key returns the key of chunk i
+func (m *manifest) key(i int) string {
+	return "memc:chunk:" + m.token + ":" + strconv.Itoa(i)
+}
+
+func (m *manifest) encode() []byte {
+	return fmt.Appendf(nil, "%s %d %d", m.token, m.count, m.length)
+}
+
+func parseManifest(b []byte) (*manifest, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("%w: malformed chunk manifest", ErrEncoding)
+	}
+
+	count, cerr := strconv.Atoi(fields[1])
+	length, lerr := strconv.Atoi(fields[2])
+	if err := errors.Join(cerr, lerr); err != nil {
+		return nil, fmt.Errorf("%w: malformed chunk manifest: %w", ErrEncoding, err)
+	}
+
+	// every chunk holds at least one byte, and at most the largest item size
+	// memcached can be configured with
+	if count <= 0 || length < count || length/count > maxDumped {
+		return nil, fmt.Errorf("%w: chunk manifest out of range", ErrEncoding)
+	}
+
+	return &manifest{token: fields[0], count: count, length: length}, nil
+}
+
+// chunk stores encoding as chunks of at most the configured max item size, and
+// returns the manifest of the chunks along with the flags to store it with
+func (c *Client) chunk(ctx context.Context, encoding []byte, flags, expiration int) ([]byte, int, error) {
+	m := &manifest{
+		token:  rand.Text(),
+		count:  (len(encoding) + c.maxItem - 1) / c.maxItem,
+		length: len(encoding),
+	}
+
+	keys := make([]string, 0, m.count)
+	chunks := make([][]byte, 0, m.count)
+	for i := range m.count {
+		// chunks are read using GetMulti, which applies the key prefix and
+		// encoding of the client
+		key, err := c.key(m.key(i))
+		if err != nil {
+			return nil, 0, err
+		}
+		keys = append(keys, key)
+		chunks = append(chunks, encoding[i*c.maxItem:min((i+1)*c.maxItem, len(encoding))])
+	}
+
+	raw := make([]int, m.count)
+	for i := range raw {
+		raw[i] = encodingRaw.flags(0)
+	}
+
+	errs := c.pipelineStore(ctx, "", "set", keys, chunks, raw, slices.Repeat([]int{expiration}, m.count))
+	if err := errors.Join(errs...); err != nil {
+		return nil, 0, err
+	}
+
+	return m.encode(), flags | chunkedFlag, nil
+}
+
+// unchunk reassembles the value of key described by the manifest in b from its
+// chunks and converts it into a value of type T according to the encoding recorded in
+// flags
+func unchunk[T any](ctx context.Context, c *Client, key string, b []byte, flags int) (T, error) {
+	var empty T
+
+	m, err := parseManifest(b)
+	if err != nil {
+		return empty, err
+	}
+
+	keys := make([]string, 0, m.count)
+	for i := range m.count {
+		keys = append(keys, m.key(i))
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(m.length)
+	for _, result := range GetMulti[[]byte](c, keys, Context(ctx)) {
+		if result.B != nil {
+			return empty, result.B
+		}
+		buf.Write(result.A)
+	}
+
+	if buf.Len() != m.length {
+		return empty, fmt.Errorf("%w: chunks do not match manifest", ErrEncoding)
+	}
+
+	return unpack[T](c, key, buf.Bytes(), flags&^chunkedFlag)
+}
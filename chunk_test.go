@@ -0,0 +1,69 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/ignore"
+	"github.com/shoenig/test/must"
+)
+
+func Test_manifest(t *testing.T) {
+	t.Parallel()
+
+	m := &manifest{token: "ABC", count: 3, length: 2500}
+	must.Eq(t, "memc:chunk:ABC:2", m.key(2))
+
+	result, err := parseManifest(m.encode())
+	must.NoError(t, err)
+	must.Eq(t, m, result)
+
+	_, err = parseManifest([]byte("ABC 3"))
+	must.ErrorIs(t, err, ErrEncoding)
+
+	_, err = parseManifest([]byte("ABC three 2500"))
+	must.ErrorIs(t, err, ErrEncoding)
+
+	for _, manifest := range []string{"x -1 5", "x 0 0", "x 3 -1", "x 3 2", "x 1 2147483648"} {
+		_, err = parseManifest([]byte(manifest))
+		must.ErrorIs(t, err, ErrEncoding, must.Sprint(manifest))
+	}
+}
+
+func TestClient_oversized(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.False(t, c.oversized("set", 1<<30))
+
+	c = New(nil, SetMaxItemSize(100))
+	must.False(t, c.oversized("set", 100))
+	must.True(t, c.oversized("set", 101))
+	must.True(t, c.oversized("cas", 101))
+	must.False(t, c.oversized("append", 101))
+}
+
+func TestClient_chunk_keyEncoding(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetKeyEncoding(Base64Keys), SetKeyPrefix("app:"), SetMaxItemSize(100))
+	defer ignore.Close(c)
+
+	large := make([]byte, 500)
+	for i := range large {
+		large[i] = byte(i)
+	}
+
+	err := Set(c, "large", large)
+	must.NoError(t, err)
+
+	v, err := Get[[]byte](c, "large")
+	must.NoError(t, err)
+	must.Eq(t, large, v)
+}
@@ -4,8 +4,17 @@
 package memc
 
 import (
-	"regexp"
+	"context"
+	"crypto/cipher"
+	"errors"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cattlecloud.net/go/memc/iopool"
@@ -16,28 +25,143 @@ import (
 // Use the package functions Set, Get, Delete, etc. by providing this Client to
 // manage data in memcached.
 type Client struct {
-	timeout    time.Duration
-	expiration time.Duration
-	idle       int
-	now        func() time.Time
+	settings
+	*core
 
-	lock  sync.Mutex
-	addrs []string
-	pools *iopool.Collection
+	lock         sync.Mutex
+	flights      flights
+	remoteHits   atomic.Uint64
+	remoteMisses atomic.Uint64
 }
 
-func (c *Client) getConn(key string) (*iopool.Buffer, error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// settings are the configuration of a Client, which are copied into its clones
+// made by With before their own ClientOption(s) are applied
+type settings struct {
+	timeout       time.Duration
+	expiration    time.Duration
+	flags         int
+	idle          int
+	minIdle       int
+	maxOpen       int
+	poolWait      time.Duration
+	idleTime      time.Duration
+	keepAlive     net.KeepAliveConfig
+	multiplex     bool
+	reuse         bool
+	resolve       time.Duration
+	srvName       string
+	srvRefresh    time.Duration
+	reload        time.Duration
+	distribute    Distribution
+	hash          HashFunc
+	vnodes        int
+	fallback      bool
+	failures      int
+	cooldown      time.Duration
+	retry         RetryPolicy
+	lookupSRV     func(context.Context, string) ([]*net.SRV, error)
+	now           func() time.Time
+	metrics       MetricsSink
+	tagOpen       string
+	tagClose      string
+	wrap          iopool.Wrapper
+	opener        func(context.Context, string) (iopool.Connection, error)
+	protocol      Protocol
+	fanout        int
+	compress      int
+	compression   Compression
+	compressors   map[Compression]Compressor
+	maxItem       int
+	aead          cipher.AEAD
+	aeadErr       error
+	compat        bool
+	interceptors  []Interceptor
+	log           *slog.Logger
+	slowThreshold time.Duration
+	slowFunc      func(Info, time.Duration)
+	prefix        string
+	hashLong      bool
+	keyEncoding   KeyEncoding
+	local         *localCache
+}
+
+// core is the state of a Client shared with its clones made by With, such
+// that clones use the same connection pools
+type core struct {
+	// pools is assigned once by New and is safe for concurrent use, such that
+	// requests are never serialized through the lock
+	pools       *iopool.Collection
+	latencies   latencies
+	errorCounts errorCounts
+
+	lock       sync.Mutex // guards addrs and discovered
+	addrs      []string
+	discovered []string
 
-	return c.pools.Get(key)
+	stop     chan struct{} // stops background routines
+	stopOnce sync.Once
 }
 
-func (c *Client) setConn(key string, conn *iopool.Buffer) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+// getConn returns a connection to the memcached instance chosen for key,
+// waiting on a connection to be returned to the pool if the pool is at its
+// limit of open connections
+func (c *Client) getConn(ctx context.Context, key string) (*iopool.Buffer, error) {
+	return c.pools.Get(ctx, key)
+}
 
+func (c *Client) setConn(key string, conn *iopool.Buffer) {
 	c.pools.Return(key, conn)
+	c.reportPool()
+}
+
+func (c *Client) getInstanceConn(ctx context.Context, address string) (*iopool.Buffer, error) {
+	return c.pools.GetInstance(ctx, address)
+}
+
+func (c *Client) setInstanceConn(address string, conn *iopool.Buffer) {
+	c.pools.ReturnInstance(address, conn)
+	c.reportPool()
+}
+
+// instance returns the address of the memcached instance chosen for key
+func (c *Client) instance(key string) string {
+	return c.pools.Instance(c.hashKey(key))
+}
+
+// route returns the address of the memcached instance for key, which is server
+// if a command is pinned to an instance with Server, or else the instance
+// chosen for key
+func (c *Client) route(server, key string) string {
+	if server != "" {
+		return server
+	}
+	return c.instance(key)
+}
+
+// rebalance replaces the set of memcached instances of the pools with the
+// configured and discovered instances, without interrupting requests in flight
+func (c *Client) rebalance() {
+	c.core.lock.Lock()
+	instances := slices.Concat(c.addrs, c.discovered)
+	c.core.lock.Unlock()
+
+	c.pools.SetInstances(instances)
+}
+
+// secondary returns the address of the memcached instance following the one
+// chosen for key in the order of preference for key, or the empty string if
+// there is no other instance
+func (c *Client) secondary(key string) string {
+	sequence := c.pools.Sequence(c.hashKey(key))
+	if len(sequence) < 2 {
+		return ""
+	}
+	return sequence[1]
+}
+
+// instances returns the address of each configured memcached instance
+func (c *Client) instances() []string {
+	return c.pools.Instances()
 }
 
 type ClientOption func(c *Client)
@@ -57,8 +181,121 @@ func SetIdleConnections(count int) ClientOption {
 	}
 }
 
+// SetMinIdleConnections adjusts the minimum number of idle connections to
+// maintain for each memcached instance, which are established in the
+// background when the client is created and again whenever connections are
+// closed, so that latency stays flat under bursty traffic. The minimum is
+// capped by the maximum number of idle connections of SetIdleConnections.
+//
+// If unset the default is 0, such that idle connections are created on demand.
+func SetMinIdleConnections(count int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.minIdle = count
+	}
+}
+
+// SetMultiplexing sets whether a single connection to each memcached instance
+// is shared by all requests, which are pipelined over the connection and their
+// responses matched in order, rather than each request using a connection of
+// its own. This reduces the number of connections and improves the throughput
+// of small operations under concurrency.
+//
+// A request interrupted by its context, or failing in a way that leaves the
+// connection in an unknown state, closes the shared connection and fails the
+// other requests in flight on it. The idle connection settings do not apply to
+// shared connections. Callbacks of streaming operations such as GetEach must
+// not make requests of the Client, which would wait on the response being read.
+//
+// If unset the default is to use a connection per request.
+func SetMultiplexing(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.multiplex = enabled
+	}
+}
+
+// SetPayloadReuse sets whether the payload of each value read is read into a
+// buffer owned by the connection and reused by each read, rather than into a
+// buffer taken from and returned to a pool shared by all connections. Values
+// are decoded from the payload before the connection is released, such that
+// decoded values never reference the buffer, though []byte and string values
+// are still copied out of it. Values read by GetView borrow a pooled buffer of
+// their own regardless.
+//
+// Each connection retains a buffer as large as the largest value it has read,
+// up to 1 MiB; larger values are always read into a buffer of their own. Reuse
+// does not apply to the connections shared by SetMultiplexing.
+//
+// If unset the default is to read payloads into pooled buffers.
+func SetPayloadReuse(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.reuse = enabled
+	}
+}
+
+// SetIdleTimeout adjusts the maximum amount of time a connection may remain
+// idle in the pool before it is closed by a background reaper. This should be
+// less than the idle_timeout of the memcached instance(s), so that connections
+// closed by the instance are not reused.
+//
+// If unset the default is to keep idle connections open indefinitely.
+func SetIdleTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.idleTime = timeout
+	}
+}
+
+// SetMaxOpenConnections adjusts the maximum number of connections, idle or in
+// use, to open to each memcached instance. Once the limit is reached, a verb
+// waits on a connection to be returned to the pool, for up to the timeout set
+// by SetPoolWaitTimeout, after which ErrPoolExhausted is returned.
+//
+// If unset the default is to not limit the number of open connections.
+func SetMaxOpenConnections(count int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.maxOpen = count
+	}
+}
+
+// SetPoolWaitTimeout adjusts the maximum amount of time to wait on a connection
+// to become available once the limit set by SetMaxOpenConnections is reached.
+// The wait is also abandoned once the Context of the verb is done.
+//
+// If unset the default wait timeout is 1 second.
+func SetPoolWaitTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.poolWait = timeout
+	}
+}
+
+// SetFanOut adjusts the maximum number of memcached instances that a multi-key
+// operation such as GetMulti communicates with concurrently.
+//
+// If unset the default fan out limit is 8.
+func SetFanOut(limit int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.fanout = limit
+	}
+}
+
 // SetDialTimeout adjusts the amount of time to wait on establishing a TCP
-// connection to the memached instance(s).
+// connection to the memcached instance(s).
 //
 // If unset the default timeout is 5 seconds.
 func SetDialTimeout(timeout time.Duration) ClientOption {
@@ -75,7 +312,8 @@ func SetDialTimeout(timeout time.Duration) ClientOption {
 // If unset the default expiration TTL is 1 hour.
 //
 // The expiration time must be more than 1 second, or set to 0 to indicate no
-// expiration time (and values stay in the cache indefinitely).
+// expiration time (and values stay in the cache indefinitely). An expiration
+// time of more than 30 days is converted into an absolute time as with TTL.
 func SetDefaultTTL(expiration time.Duration) ClientOption {
 	return func(c *Client) {
 		c.lock.Lock()
@@ -84,6 +322,205 @@ func SetDefaultTTL(expiration time.Duration) ClientOption {
 	}
 }
 
+// SetDefaultFlags adjusts the default flags stored alongside values set into
+// the memcached instance(s), e.g. to record the version of the schema of every
+// value. The Flags option overrides the default for a single verb.
+//
+// As with Flags, only the lower 24 bits of flags are available to applications,
+// unless SetGomemcacheCompat is enabled.
+//
+// If unset the default flags are 0.
+func SetDefaultFlags(flags int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.flags = flags
+	}
+}
+
+// Distribution determines which memcached instance each key is stored on.
+type Distribution = iopool.Distribution
+
+const (
+	// Modulo chooses an instance by hashing the key modulo the number of
+	// instances. Adding or removing an instance moves nearly every key to a
+	// different instance.
+	Modulo = iopool.Modulo
+
+	// Ketama chooses an instance using libketama style consistent hashing.
+	// Adding or removing an instance moves only about 1/N of keys to a
+	// different instance.
+	Ketama = iopool.Ketama
+)
+
+// SetFailover enables automatic failover of memcached instances that cannot be
+// reached. Once establishing a connection to an instance fails threshold times
+// in a row, the instance is considered down for the cooldown period, during
+// which its keys are stored on and read from the next instance for each key,
+// rather than every request failing with a connection error.
+//
+// Failover is most effective with the Ketama distribution, where the keys of a
+// down instance are spread across the remaining instances.
+//
+// If unset or if threshold is 0 the default is to not fail over.
+func SetFailover(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.failures = threshold
+		c.cooldown = cooldown
+	}
+}
+
+// SetReadFallback enables retrying a Get that misses or fails on the next
+// memcached instance for the key, i.e. the instance the key would be stored on
+// if its instance were removed. This avoids a rebooted or unreachable instance
+// translating directly into load on the origin of cached values, provided the
+// values are also stored on the next instance, e.g. by replication or while the
+// instance was down with SetFailover enabled.
+//
+// If unset the default is to not retry a Get on another instance.
+func SetReadFallback(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.fallback = enabled
+	}
+}
+
+// SetDistribution sets the Distribution used to choose which memcached
+// instance each key is stored on. Ketama is recommended whenever the set of
+// instances may change, e.g. through AddServer or SetDiscoverySRV.
+//
+// If unset the default is Modulo.
+func SetDistribution(d Distribution) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.distribute = d
+	}
+}
+
+// SetVirtualNodes adjusts the number of points each memcached instance owns on
+// the continuum of the Ketama distribution. More points spread keys more evenly
+// across instances, at the cost of memory and the time to rebuild the continuum
+// whenever the set of instances changes.
+//
+// If unset the default is 160 points per instance, matching libketama.
+func SetVirtualNodes(count int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.vnodes = count
+	}
+}
+
+// HashFunc is a function that chooses the index of the memcached instance key
+// is stored on, out of n instances. It must return a value in [0, n), and the
+// same value every time it is given the same key and n.
+type HashFunc func(key string, n int) int
+
+// SetHashFunc sets a HashFunc used to choose which memcached instance each key
+// is stored on, taking precedence over the Distribution set by
+// SetDistribution. This enables mapping keys to instances the same way as an
+// existing deployment of another memcached client, e.g. during a migration.
+//
+// Instances are indexed in the order they were given to New.
+//
+// If unset the default is to use the Distribution.
+func SetHashFunc(f HashFunc) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.hash = f
+	}
+}
+
+// SetHashTag enables hash tags, where only the portion of a key enclosed by the
+// left and right delimiters is used to choose which memcached instance the key
+// is stored on. This enables related keys to be co-located on the same instance,
+// e.g. with the delimiters "{" and "}" the keys "{user:42}:session" and
+// "{user:42}:profile" are always stored on the same instance.
+//
+// Keys without a non-empty hash tag are hashed in their entirety.
+//
+// If unset the default is to hash the entire key.
+func SetHashTag(left, right string) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.tagOpen = left
+		c.tagClose = right
+	}
+}
+
+// SetConnWrapper sets a function that is applied to every newly established
+// connection to a memcached instance. The function may return a replacement
+// connection that wraps the original, e.g. to implement bandwidth throttling,
+// byte accounting, or a custom TLS configuration.
+//
+// If unset the default is to use connections as they are established.
+func SetConnWrapper(wrap func(net.Conn) net.Conn) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.wrap = wrap
+	}
+}
+
+// SetConnOpener sets a function that establishes every connection to a
+// memcached instance in place of dialing its address over the network, e.g. to
+// connect to an in-memory fake of memcached such as memctest.Fake. The
+// connection wrapper, dial timeout, and keepalive settings do not apply to
+// connections established by open.
+//
+// If unset the default is to dial each address over TCP or a Unix socket.
+func SetConnOpener(open func(ctx context.Context, address string) (iopool.Connection, error)) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.opener = open
+	}
+}
+
+// SetKeepAlive enables TCP keepalive probes on connections to the memcached
+// instance(s), so that a connection to an instance that has crashed or become
+// unreachable is detected and closed, rather than hanging on its next use.
+//
+// A probe is sent once a connection has been idle for period, and again every
+// period thereafter, and the connection is closed after count probes go
+// unanswered.
+//
+// If unset the default is to use the keepalive settings of net.Dialer.
+func SetKeepAlive(period time.Duration, count int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.keepAlive = net.KeepAliveConfig{
+			Enable:   true,
+			Idle:     period,
+			Interval: period,
+			Count:    count,
+		}
+	}
+}
+
+// SetResolveInterval enables periodically resolving the hostname of each
+// memcached instance given by hostname. Once the address records of a hostname
+// change, the connections to the old address(es) are closed and new
+// connections are established, such that a failover that moves a hostname to a
+// new address does not require a restart.
+//
+// If unset the default is to resolve hostnames only when establishing a new
+// connection.
+func SetResolveInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.resolve = interval
+	}
+}
+
 // ClockFunc is a function that returns the current time.
 //
 // Normally this should just be the time.Now function.
@@ -106,6 +543,9 @@ const (
 	defaultDialTimeout = 5 * time.Second
 	defaultExpiration  = 1 * time.Hour
 	defaultIdleCount   = 1
+	defaultFanOut      = 8
+	defaultPoolWait    = 1 * time.Second
+	defaultReload      = 10 * time.Second
 )
 
 // New creates a new Client capable of sharding across the given set of
@@ -114,39 +554,111 @@ const (
 // Certain behaviors can be configured by specifying one or more ClientOption
 // options.
 func New(instances []string, opts ...ClientOption) *Client {
-	c := new(Client)
+	c := &Client{core: new(core)}
 	c.addrs = instances
 	c.timeout = defaultDialTimeout
 	c.expiration = defaultExpiration
 	c.idle = defaultIdleCount
+	c.fanout = defaultFanOut
+	c.compression = Gzip
+	c.poolWait = defaultPoolWait
+	c.reload = defaultReload
 	c.now = time.Now
+	c.metrics = noopSink{}
+	c.lookupSRV = lookupSRV
+	c.log = slog.New(slog.DiscardHandler)
+	c.stop = make(chan struct{})
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
-	c.pools = iopool.New(c.addrs, c.idle)
+	c.pools = iopool.New(c.addrs, iopool.Config{
+		Idle:              c.idle,
+		MinIdle:           c.minIdle,
+		MaxOpen:           c.maxOpen,
+		Wait:              c.poolWait,
+		IdleTimeout:       c.idleTime,
+		ResolveInterval:   c.resolve,
+		Distribution:      c.distribute,
+		VirtualNodes:      c.vnodes,
+		Hash:              c.hash,
+		FailoverThreshold: c.failures,
+		FailoverCooldown:  c.cooldown,
+		Multiplex:         c.multiplex,
+		Logger:            c.log,
+		Dialer: iopool.Dialer{
+			Timeout:   c.timeout,
+			KeepAlive: c.keepAlive,
+			Wrap:      c.wrap,
+			Open:      c.opener,
+		},
+	})
+
+	if c.srvName != "" {
+		_ = c.discover()
+		if c.srvRefresh > 0 {
+			go c.discovery()
+		}
+	}
+
 	return c
 }
 
-var (
-	keyRe = regexp.MustCompile(`^[^\s]{1,250}$`)
-)
-
+// check returns ErrKeyNotValid unless key is between 1 and 250 bytes, and
+// contains no spaces or control characters, which memcached rejects and which
+// could otherwise be used to inject commands into the protocol
 func check(key string) error {
-	if !keyRe.MatchString(key) {
+	if len(key) == 0 || len(key) > maxKeyLength {
 		return ErrKeyNotValid
 	}
+	for i := range len(key) {
+		if key[i] < 0x21 || key[i] == 0x7f {
+			return ErrKeyNotValid
+		}
+	}
 	return nil
 }
 
 // Close will close all idle connections and prevent existing connections from
 // becoming idle. Future use of the Client will fail.
 func (c *Client) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	return c.pools.Close()
+}
+
+// Shutdown gracefully closes the Client, closing all idle connections like
+// Close and then waiting for requests in flight to complete and return their
+// connections. If ctx is done first, the connections still in use are closed,
+// interrupting their requests, and the error of ctx is returned.
+//
+// Future use of the Client will fail.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	return c.pools.Shutdown(ctx)
+}
+
+// With returns a shallow clone of the Client, configured as c is but with the
+// given ClientOption options applied, for example to set a different default
+// TTL or key prefix for use by one subsystem of an application.
+//
+// The clone shares the connection pools and set of memcached instances of c,
+// such that options configuring connections or instances, like
+// SetIdleConnections or SetDiscoverySRV, have no effect on the clone. Closing
+// either the clone or c closes the connection pools of both.
+func (c *Client) With(opts ...ClientOption) *Client {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	clone := &Client{settings: c.settings, core: c.core}
+	c.lock.Unlock()
 
-	return c.pools.Close()
+	// options of the clone must not modify those shared with c
+	clone.compressors = maps.Clone(clone.compressors)
+	clone.interceptors = slices.Clip(clone.interceptors)
+
+	for _, opt := range opts {
+		opt(clone)
+	}
+	return clone
 }
 
 // seconds returns the number of seconds until expiration, unless the
@@ -169,13 +681,156 @@ func (c *Client) seconds(expiration time.Duration) (int, error) {
 	}
 }
 
-func (c *Client) do(key string, f func(*iopool.Buffer) error) error {
-	conn, err := c.getConn(key)
+// expiry returns the expiration to send to memcached for the expiration time
+// of options, which is either absolute as applied by TTLAt or relative
+func (c *Client) expiry(options *Options) (int, error) {
+	if options.expiresAt.IsZero() {
+		return c.seconds(options.expiration)
+	}
+	if !options.expiresAt.After(c.now()) {
+		return 0, ErrExpiration
+	}
+	return int(options.expiresAt.Unix()), nil
+}
+
+// hashKey returns the portion of key used to choose a memcached instance, which
+// is the hash tag of the key if hash tags are enabled and key contains one
+func (c *Client) hashKey(key string) string {
+	if c.tagOpen == "" || c.tagClose == "" {
+		return key
+	}
+
+	start := strings.Index(key, c.tagOpen)
+	if start < 0 {
+		return key
+	}
+
+	rest := key[start+len(c.tagOpen):]
+	end := strings.Index(rest, c.tagClose)
+	if end <= 0 {
+		return key
+	}
+
+	return rest[:end]
+}
+
+// do executes f on a connection to the memcached instance chosen for key as
+// command op, through the configured interceptors, unless options pins the
+// command to an instance with Server
+func (c *Client) do(options *Options, op, key string, f func(*iopool.Buffer) error) error {
+	if options.server != "" {
+		return c.doInstance(options.ctx, op, options.server, f)
+	}
+
+	ctx := options.ctx
+	if len(c.interceptors) == 0 {
+		return c.execute(ctx, op, key, f)
+	}
+
+	info := Info{Op: op, Key: key, Address: c.pools.Instance(c.hashKey(key))}
+	return c.intercept(ctx, info, func() error {
+		return c.execute(ctx, op, key, f)
+	})
+}
+
+// execute runs f on a connection to the memcached instance chosen for key,
+// reporting the outcome as command op
+func (c *Client) execute(ctx context.Context, op, key string, f func(*iopool.Buffer) error) error {
+	start := c.now()
+	if err := ctx.Err(); err != nil {
+		c.record(op, start, err)
+		return err
+	}
+	hashed := c.hashKey(key)
+	conn, err := c.getConn(ctx, hashed)
+	if err != nil {
+		address := c.pools.Instance(hashed)
+		c.metrics.Count("memc.conn.errors", 1)
+		c.errorCounts.dialed(address, err)
+		err = attribute(address, op, err)
+		c.logFailure(err)
+		c.record(op, start, err)
+		c.slowOperation(Info{Op: op, Key: key, Address: address}, start)
+		return err
+	}
+	err = c.measure(ctx, conn, f)
+	if !expected(err) {
+		conn.SetHealth(err)
+	}
+	address := conn.Address() // conn must not be used once returned
+	err = attribute(address, op, err)
+	c.logFailure(err)
+	c.setConn(hashed, conn)
+	c.record(op, start, err)
+	c.slowOperation(Info{Op: op, Key: key, Address: address}, start)
+	return err
+}
+
+// doInstance is like do, but executes f on a connection to the memcached
+// instance of address rather than the instance chosen by hashing a key
+func (c *Client) doInstance(ctx context.Context, op, address string, f func(*iopool.Buffer) error) error {
+	if len(c.interceptors) == 0 {
+		return c.executeInstance(ctx, op, address, f)
+	}
+
+	info := Info{Op: op, Address: address}
+	return c.intercept(ctx, info, func() error {
+		return c.executeInstance(ctx, op, address, f)
+	})
+}
+
+// executeInstance is like execute, but runs f on a connection to the memcached
+// instance of address
+func (c *Client) executeInstance(ctx context.Context, op, address string, f func(*iopool.Buffer) error) error {
+	start := c.now()
+	if err := ctx.Err(); err != nil {
+		c.record(op, start, err)
+		return err
+	}
+	conn, err := c.getInstanceConn(ctx, address)
 	if err != nil {
+		c.metrics.Count("memc.conn.errors", 1)
+		c.errorCounts.dialed(address, err)
+		err = attribute(address, op, err)
+		c.logFailure(err)
+		c.record(op, start, err)
+		c.slowOperation(Info{Op: op, Address: address}, start)
 		return err
 	}
-	err = f(conn)
-	conn.SetHealth(err)
-	c.setConn(key, conn)
+	err = c.measure(ctx, conn, f)
+	if !expected(err) {
+		conn.SetHealth(err)
+	}
+	err = attribute(address, op, err)
+	c.logFailure(err)
+	c.setInstanceConn(address, conn)
+	c.record(op, start, err)
+	c.slowOperation(Info{Op: op, Address: address}, start)
+	return err
+}
+
+// measure executes f on conn using run, recording the latency of f and any
+// failure for the memcached instance of conn
+func (c *Client) measure(ctx context.Context, conn *iopool.Buffer, f func(*iopool.Buffer) error) error {
+	start := c.now()
+	err := run(ctx, conn, f)
+	c.latencies.observe(conn.Address(), c.now().Sub(start))
+	c.errorCounts.observe(conn.Address(), err)
 	return err
 }
+
+// run executes f on conn, interrupting any blocked network I/O once ctx is
+// done or its deadline is reached, in which case the error of ctx is returned
+func run(ctx context.Context, conn *iopool.Buffer, f func(*iopool.Buffer) error) error {
+	stop := conn.Watch(ctx)
+	err := f(conn)
+	switch {
+	case !stop() && err != nil:
+		return ctx.Err()
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		// the connection deadline set from ctx was reached
+		return context.DeadlineExceeded
+	default:
+		return err
+	}
+}
@@ -4,7 +4,10 @@
 package memc
 
 import (
+	"context"
+	"io"
 	"math"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +22,38 @@ func Test_SetDialTimeout(t *testing.T) {
 	must.Eq(t, 4*time.Second, c.timeout)
 }
 
+func Test_SetMaxOpenConnections(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.Eq(t, 0, c.maxOpen)
+	must.Eq(t, defaultPoolWait, c.poolWait)
+
+	c = New(nil, SetMaxOpenConnections(4), SetPoolWaitTimeout(2*time.Second))
+	must.Eq(t, 4, c.maxOpen)
+	must.Eq(t, 2*time.Second, c.poolWait)
+}
+
+func Test_SetKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil, SetKeepAlive(10*time.Second, 3))
+	must.True(t, c.keepAlive.Enable)
+	must.Eq(t, 10*time.Second, c.keepAlive.Idle)
+	must.Eq(t, 10*time.Second, c.keepAlive.Interval)
+	must.Eq(t, 3, c.keepAlive.Count)
+}
+
+func Test_SetFanOut(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.Eq(t, defaultFanOut, c.fanout)
+
+	c = New(nil, SetFanOut(2))
+	must.Eq(t, 2, c.fanout)
+}
+
 func Test_SetDefaultTTL(t *testing.T) {
 	t.Parallel()
 
@@ -26,15 +61,142 @@ func Test_SetDefaultTTL(t *testing.T) {
 	must.Eq(t, 2*time.Hour, c.expiration)
 }
 
+func Test_SetDefaultFlags(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil, SetDefaultFlags(0x2a))
+	must.Eq(t, 0x2a, c.flags)
+	must.Eq(t, 0x2a, c.options(nil).flags)
+	must.Eq(t, 0x07, c.options([]Option{Flags(0x07)}).flags)
+}
+
+func TestClient_With(t *testing.T) {
+	t.Parallel()
+
+	noop := func(ctx context.Context, info Info, next func() error) error { return next() }
+
+	c := New(nil, SetDefaultTTL(time.Hour), SetKeyPrefix("app:"), SetInterceptor(noop), SetCompressor(Snappy, nil))
+	clone := c.With(SetDefaultTTL(time.Minute), SetInterceptor(noop), SetCompressor(Zstd, nil))
+
+	must.Eq(t, time.Minute, clone.expiration)
+	must.Eq(t, "app:", clone.prefix)
+	must.Len(t, 2, clone.interceptors)
+	must.MapLen(t, 2, clone.compressors)
+
+	// the options of the clone do not apply to c
+	must.Eq(t, time.Hour, c.expiration)
+	must.Len(t, 1, c.interceptors)
+	must.MapLen(t, 1, c.compressors)
+	must.Eq(t, Snappy, c.compression)
+
+	// the connection pools are shared
+	must.Eq(t, c.pools, clone.pools)
+	must.NoError(t, clone.Close())
+	select {
+	case <-c.stop:
+	default:
+		t.Fatal("expected closing the clone to close c")
+	}
+}
+
+func TestClient_hashKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled", func(t *testing.T) {
+		c := New(nil)
+		must.Eq(t, "{user:42}:profile", c.hashKey("{user:42}:profile"))
+	})
+
+	c := New(nil, SetHashTag("{", "}"))
+
+	t.Run("tagged", func(t *testing.T) {
+		must.Eq(t, "user:42", c.hashKey("{user:42}:profile"))
+		must.Eq(t, "user:42", c.hashKey("session:{user:42}"))
+	})
+
+	t.Run("untagged", func(t *testing.T) {
+		must.Eq(t, "user:42:profile", c.hashKey("user:42:profile"))
+	})
+
+	t.Run("empty tag", func(t *testing.T) {
+		must.Eq(t, "{}:profile", c.hashKey("{}:profile"))
+	})
+
+	t.Run("unclosed", func(t *testing.T) {
+		must.Eq(t, "{user:42:profile", c.hashKey("{user:42:profile"))
+	})
+
+	t.Run("multi-byte", func(t *testing.T) {
+		c := New(nil, SetHashTag("<<", ">>"))
+		must.Eq(t, "user:42", c.hashKey("<<user:42>>:profile"))
+	})
+}
+
+func Test_SetHashFunc(t *testing.T) {
+	t.Parallel()
+
+	instances := []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211"}
+	c := New(instances, SetHashFunc(func(key string, n int) int {
+		return len(key) - 1
+	}))
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.Eq(t, "10.0.0.1:11211", c.instance("a"))
+	must.Eq(t, "10.0.0.2:11211", c.instance("ab"))
+	must.Eq(t, "10.0.0.3:11211", c.instance("abc"))
+
+	// out of range results are wrapped around
+	must.Eq(t, "10.0.0.1:11211", c.instance("abcd"))
+	must.Eq(t, "10.0.0.3:11211", c.instance(""))
+}
+
+func TestClient_Context(t *testing.T) {
+	t.Parallel()
+
+	// a server that accepts connections but never responds
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, aerr := ln.Accept()
+			if aerr != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }()
+		}
+	}()
+
+	c := New([]string{ln.Addr().String()})
+	t.Cleanup(func() { _ = c.Close() })
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := Get[string](c, "key", Context(ctx))
+		must.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := Get[string](c, "key", Context(ctx))
+		must.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
 func Test_seconds(t *testing.T) {
 	t.Parallel()
 
-	c := &Client{
+	c := &Client{settings: settings{
 		now: func() time.Time {
 			// January 23rd, 2026, 10:24:00 AM
 			return time.Date(2026, 1, 23, 10, 24, 0, 0, time.UTC)
 		},
-	}
+	}}
 
 	t.Run("zero", func(t *testing.T) {
 		s, err := c.seconds(0)
@@ -70,6 +232,45 @@ func Test_seconds(t *testing.T) {
 	})
 }
 
+func Test_expiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 23, 10, 24, 0, 0, time.UTC)
+	c := &Client{settings: settings{
+		now: func() time.Time { return now },
+	}}
+
+	t.Run("relative", func(t *testing.T) {
+		s, err := c.expiry(&Options{expiration: time.Minute})
+		must.NoError(t, err)
+		must.Eq(t, 60, s)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		midnight := time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)
+		s, err := c.expiry(&Options{expiresAt: midnight})
+		must.NoError(t, err)
+		must.Eq(t, 1769212800, s) // January 24th, 2026, 00:00:00 AM
+	})
+
+	t.Run("past", func(t *testing.T) {
+		_, err := c.expiry(&Options{expiresAt: now})
+		must.ErrorIs(t, err, ErrExpiration)
+	})
+
+	t.Run("last applied", func(t *testing.T) {
+		midnight := time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)
+
+		s, err := c.expiry(c.options([]Option{TTL(time.Minute), TTLAt(midnight)}))
+		must.NoError(t, err)
+		must.Eq(t, 1769212800, s)
+
+		s, err = c.expiry(c.options([]Option{TTLAt(midnight), TTL(time.Minute)}))
+		must.NoError(t, err)
+		must.Eq(t, 60, s)
+	})
+}
+
 func Test_check(t *testing.T) {
 	t.Parallel()
 
@@ -100,6 +301,60 @@ func Test_check(t *testing.T) {
 		s := "abc\t123"
 		must.ErrorIs(t, check(s), ErrKeyNotValid)
 	})
+
+	t.Run("control", func(t *testing.T) {
+		for _, s := range []string{"abc\x00", "abc\x0b123", "abc\x1b", "abc\x7f", "abc\r\nflush_all"} {
+			must.ErrorIs(t, check(s), ErrKeyNotValid, must.Sprintf("%q", s))
+		}
+	})
+
+	t.Run("multibyte", func(t *testing.T) {
+		must.NoError(t, check(strings.Repeat("é", 125)))
+		must.ErrorIs(t, check(strings.Repeat("é", 126)), ErrKeyNotValid)
+	})
+}
+
+func Fuzz_check(f *testing.F) {
+	for _, seed := range []string{"", "key", "a b", "a\r\nb", "\x00", "\x7f", "ключ", strings.Repeat("a", 251)} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, key string) {
+		err := check(key)
+		if err != nil {
+			must.ErrorIs(t, err, ErrKeyNotValid)
+			return
+		}
+
+		// a valid key is within the length limit and cannot end the command
+		must.Between(t, 1, len(key), maxKeyLength)
+		must.False(t, strings.ContainsAny(key, " \t\r\n\x00\x7f"))
+		for i := range len(key) {
+			must.Greater(t, 0x20, key[i])
+		}
+	})
+}
+
+func Fuzz_encodeKey(f *testing.F) {
+	for _, seed := range []string{"key", "a b", "100%", "a\r\nb", "\x00\xff"} {
+		f.Add(seed)
+	}
+
+	percent := New(nil, SetKeyEncoding(PercentKeys))
+	encoded := New(nil, SetKeyEncoding(Base64Keys))
+
+	f.Fuzz(func(t *testing.T, key string) {
+		if key == "" || len(key) > 60 {
+			return
+		}
+
+		// short enough keys of any bytes are valid once encoded
+		for _, c := range []*Client{percent, encoded} {
+			stored, err := c.key(key)
+			must.NoError(t, err)
+			must.NoError(t, check(stored))
+		}
+	})
 }
 
 type person struct {
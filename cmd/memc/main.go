@@ -0,0 +1,107 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Command memc snapshots the values stored on memcached instances, such that a
+// small cache may be carried over the rolling restart of its instances.
+//
+//	memc -servers 10.0.0.1:11211,10.0.0.2:11211 dump > cache.dump
+//	memc -servers 10.0.0.1:11211,10.0.0.2:11211 restore < cache.dump
+//
+// The dump subcommand writes a snapshot of every value to standard output, or
+// to the file given as its argument, and the restore subcommand stores every
+// value of a snapshot read from standard input, or from the file given as its
+// argument. Snapshots are written and read by memc.Client Dump and Restore.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cattlecloud.net/go/memc"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+const usage = `usage: memc [flags] dump|restore [file]`
+
+// run executes the subcommand of args, returning the exit code of the command
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("memc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		flags.PrintDefaults()
+	}
+	servers := flags.String("servers", "localhost:11211", "comma separated addresses of the memcached instances")
+	timeout := flags.Duration("timeout", time.Minute, "maximum time to spend on the subcommand")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	c := memc.New(strings.Split(*servers, ","))
+	defer func() { _ = c.Close() }()
+
+	var err error
+	switch args[0] {
+	case "dump":
+		err = dump(ctx, c, args[1:], stdout)
+	case "restore":
+		err = restore(ctx, c, args[1:], stdin)
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "memc: %s failed: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// dump writes a snapshot to the file of args, if any, or else to stdout
+func dump(ctx context.Context, c *memc.Client, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return c.Dump(stdout, memc.Context(ctx))
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	if err = c.Dump(f, memc.Context(ctx)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// restore reads a snapshot from the file of args, if any, or else from stdin
+func restore(ctx context.Context, c *memc.Client, args []string, stdin io.Reader) error {
+	if len(args) == 0 {
+		return c.Restore(stdin, memc.Context(ctx))
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return c.Restore(f, memc.Context(ctx))
+}
@@ -0,0 +1,74 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/ignore"
+	"github.com/shoenig/test/must"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	source, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	target, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := memc.New([]string{source})
+	defer ignore.Close(c)
+
+	err := memc.Set(c, "greeting", "hello", memc.TTL(time.Hour))
+	must.NoError(t, err)
+
+	t.Run("stdio", func(t *testing.T) {
+		var snapshot, stderr bytes.Buffer
+		code := run([]string{"-servers", source, "dump"}, nil, &snapshot, &stderr)
+		must.Zero(t, code, must.Sprint(stderr.String()))
+		must.StrContains(t, snapshot.String(), "set greeting ")
+
+		code = run([]string{"-servers", target, "restore"}, &snapshot, nil, &stderr)
+		must.Zero(t, code, must.Sprint(stderr.String()))
+
+		restored := memc.New([]string{target})
+		defer ignore.Close(restored)
+
+		v, err := memc.Get[string](restored, "greeting")
+		must.NoError(t, err)
+		must.Eq(t, "hello", v)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.dump")
+
+		var stderr bytes.Buffer
+		code := run([]string{"-servers", source, "dump", path}, nil, nil, &stderr)
+		must.Zero(t, code, must.Sprint(stderr.String()))
+
+		code = run([]string{"-servers", target, "restore", path}, nil, nil, &stderr)
+		must.Zero(t, code, must.Sprint(stderr.String()))
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		var stderr bytes.Buffer
+		code := run([]string{"snapshot"}, nil, nil, &stderr)
+		must.Eq(t, 2, code)
+		must.StrContains(t, stderr.String(), "usage: memc")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		var stderr bytes.Buffer
+		code := run([]string{"-servers", target, "restore"}, bytes.NewBufferString("get greeting\r\n"), nil, &stderr)
+		must.Eq(t, 1, code)
+		must.StrContains(t, stderr.String(), "restore failed")
+	})
+}
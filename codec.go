@@ -0,0 +1,198 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrEncoding is returned when a value cannot be decoded into the requested
+// type, because it was stored using an incompatible encoding.
+var ErrEncoding = errors.New("memc: value encoding does not match type")
+
+// The flags of a value are 32 bits, of which the lower 24 bits are available
+// to applications through the Flags option, and the upper 8 bits are reserved
+// for recording how the value was encoded, compressed, chunked, and encrypted.
+const (
+	encodingShift = 24
+	encodingMask  = 0x3 << encodingShift
+	userFlagsMask = 1<<encodingShift - 1
+)
+
+// encoding identifies how a value was encoded when it was stored
+type encoding int
+
+const (
+	// encodingUnknown is a value stored without recording its encoding, e.g.
+	// by another client or an older version of memc
+	encodingUnknown encoding = iota
+
+	// encodingRaw is a []byte or string value stored as is
+	encodingRaw
+
+	// encodingInteger is an integer value stored in fixed size little endian
+	encodingInteger
+
+	// encodingGob is any other value stored using encoding/gob
+	encodingGob
+)
+
+// encodingOf returns the encoding used by encode for item
+func encodingOf(item any) encoding {
+	switch item.(type) {
+	case []byte, string:
+		return encodingRaw
+	case int8, uint8, int16, uint16, int32, uint32, int64, uint64, int, uint:
+		return encodingInteger
+	default:
+		return encodingGob
+	}
+}
+
+// flags returns the flags recording encoding e alongside the given user flags
+func (e encoding) flags(user int) int {
+	return user&userFlagsMask | int(e)<<encodingShift
+}
+
+// userFlags returns the portion of flags set by the application using the
+// Flags option
+func (c *Client) userFlags(flags int) int {
+	if c.compat {
+		return flags
+	}
+	return flags & userFlagsMask
+}
+
+// flagsEncoding returns the encoding recorded in flags
+func flagsEncoding(flags int) encoding {
+	return encoding((flags & encodingMask) >> encodingShift)
+}
+
+// decodeFlags converts b into a value of type T according to the encoding
+// recorded in flags, rather than assuming b was encoded from a value of type T
+//
+// A value of any encoding can be read as raw []byte or string, and a raw value
+// such as a counter maintained by Increment can be read as an integer.
+func decodeFlags[T any](b []byte, flags int) (T, error) {
+	var result T
+
+	stored := flagsEncoding(flags)
+	wanted := encodingOf(result)
+
+	switch {
+	case wanted == encodingInteger && stored != encodingRaw && len(b) != integerSize(result):
+		return result, fmt.Errorf("%w: cannot decode %d byte integer into %T", ErrEncoding, len(b), result)
+	case stored == encodingUnknown, stored == wanted, wanted == encodingRaw:
+		return decode[T](b)
+	case stored == encodingRaw && wanted == encodingInteger:
+		return parseInteger[T](b)
+	default:
+		return result, fmt.Errorf("%w: cannot decode %s value into %T", ErrEncoding, stored, result)
+	}
+}
+
+// integerSize returns the size of the fixed size encoding of integer item
+func integerSize(item any) int {
+	switch item.(type) {
+	case int8, uint8:
+		return 1
+	case int16, uint16:
+		return 2
+	case int32, uint32:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// pack encodes item for storage under key, compressing and encrypting the
+// encoding if configured, and returns the encoding along with the flags that
+// record how item was encoded alongside the given user flags
+func (c *Client) pack(key string, item any, user int) ([]byte, int, error) {
+	return c.packInto(new(bytes.Buffer), key, item, user)
+}
+
+// packInto is pack, encoding item into buf such that the returned encoding may
+// reference the memory of buf
+func (c *Client) packInto(buf *bytes.Buffer, key string, item any, user int) ([]byte, int, error) {
+	b, err := encodeInto(buf, item)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if c.compat {
+		return b, user, nil
+	}
+
+	flags := encodingOf(item).flags(user)
+
+	if b, flags, err = c.compressValue(b, flags); err != nil {
+		return nil, 0, err
+	}
+
+	return c.encryptValue(key, b, flags)
+}
+
+// unpack decrypts and decompresses b stored under key if flags records it as
+// such, then converts it into a value of type T according to the encoding
+// recorded in flags
+func unpack[T any](c *Client, key string, b []byte, flags int) (T, error) {
+	if c.compat {
+		return decodeFlags[T](b, 0)
+	}
+
+	var empty T
+
+	if c.missed(flags) {
+		return empty, ErrKnownMiss
+	}
+
+	b, err := c.decryptValue(key, b, flags)
+	if err != nil {
+		return empty, err
+	}
+
+	if b, err = c.decompressValue(b, flags); err != nil {
+		return empty, err
+	}
+
+	return decodeFlags[T](b, flags)
+}
+
+// parseInteger converts the ASCII decimal integer in b into a value of type T
+func parseInteger[T any](b []byte) (T, error) {
+	var result T
+
+	s := strings.TrimSpace(string(b))
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		i, ierr := strconv.ParseInt(s, 10, 64)
+		if ierr != nil {
+			return result, fmt.Errorf("%w: %q is not an integer", ErrEncoding, s)
+		}
+		u = uint64(i)
+	}
+
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, u)
+	return decode[T](buf)
+}
+
+func (e encoding) String() string {
+	switch e {
+	case encodingRaw:
+		return "raw"
+	case encodingInteger:
+		return "integer"
+	case encodingGob:
+		return "gob"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_encoding_flags(t *testing.T) {
+	t.Parallel()
+
+	must.Eq(t, 0x01000000, encodingRaw.flags(0))
+	must.Eq(t, 0x02000007, encodingInteger.flags(7))
+	must.Eq(t, 0x03ffffff, encodingGob.flags(-1))
+
+	must.Eq(t, encodingGob, flagsEncoding(encodingGob.flags(42)))
+	must.Eq(t, encodingUnknown, flagsEncoding(42))
+}
+
+func Test_decodeFlags(t *testing.T) {
+	t.Parallel()
+
+	type person struct {
+		Name string
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		v, err := decodeFlags[string]([]byte("hello"), 0)
+		must.NoError(t, err)
+		must.Eq(t, "hello", v)
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		b, err := encode(42)
+		must.NoError(t, err)
+		v, err := decodeFlags[int](b, encodingInteger.flags(0))
+		must.NoError(t, err)
+		must.Eq(t, 42, v)
+	})
+
+	t.Run("raw as integer", func(t *testing.T) {
+		v, err := decodeFlags[uint64]([]byte("101"), encodingRaw.flags(0))
+		must.NoError(t, err)
+		must.Eq(t, 101, v)
+
+		_, err = decodeFlags[int]([]byte("abc"), encodingRaw.flags(0))
+		must.ErrorIs(t, err, ErrEncoding)
+	})
+
+	t.Run("any as raw", func(t *testing.T) {
+		b, err := encode(&person{Name: "bob"})
+		must.NoError(t, err)
+		v, err := decodeFlags[[]byte](b, encodingGob.flags(0))
+		must.NoError(t, err)
+		must.Eq(t, b, v)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := decodeFlags[*person]([]byte("hello"), encodingRaw.flags(0))
+		must.ErrorIs(t, err, ErrEncoding)
+
+		_, err = decodeFlags[int]([]byte{1, 2, 3}, encodingGob.flags(0))
+		must.ErrorIs(t, err, ErrEncoding)
+	})
+
+	t.Run("integer width", func(t *testing.T) {
+		b, err := encode(int16(5))
+		must.NoError(t, err)
+
+		_, err = decodeFlags[int64](b, encodingInteger.flags(0))
+		must.ErrorIs(t, err, ErrEncoding)
+
+		_, err = decodeFlags[int64](b, 0)
+		must.ErrorIs(t, err, ErrEncoding)
+
+		v, err := decodeFlags[int16](b, encodingInteger.flags(0))
+		must.NoError(t, err)
+		must.Eq(t, 5, v)
+	})
+}
+
+func Test_encodeInto(t *testing.T) {
+	t.Parallel()
+
+	values := []any{
+		"hello", int8(-3), uint8(3), int16(-300), uint16(300),
+		int32(-70000), uint32(70000), int64(-1 << 40), uint64(1 << 40),
+		-42, uint(42), &person{Name: "bob"},
+	}
+
+	buf := getScratch()
+	defer putScratch(buf)
+
+	for _, value := range values {
+		exp, err := encode(value)
+		must.NoError(t, err)
+
+		buf.Reset()
+		b, err := encodeInto(buf, value)
+		must.NoError(t, err)
+		must.Eq(t, exp, b, must.Sprintf("%T", value))
+	}
+
+	t.Run("bytes", func(t *testing.T) {
+		value := []byte("raw")
+		b, err := encodeInto(buf, value)
+		must.NoError(t, err)
+		must.True(t, &value[0] == &b[0])
+	})
+}
+
+var sinkEncoding []byte
+
+func Benchmark_encode(b *testing.B) {
+	value := &person{Name: "bob"}
+
+	b.Run("allocated", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			sinkEncoding, _ = encode(value)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			buf := getScratch()
+			sinkEncoding, _ = encodeInto(buf, value)
+			putScratch(buf)
+		}
+	})
+}
+
+func Benchmark_encode_string(b *testing.B) {
+	value := strings.Repeat("x", 16<<10)
+
+	b.Run("allocated", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			sinkEncoding, _ = encode(value)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			buf := getScratch()
+			sinkEncoding, _ = encodeInto(buf, value)
+			putScratch(buf)
+		}
+	})
+}
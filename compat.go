@@ -0,0 +1,27 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+// SetGomemcacheCompat enables compatibility with github.com/bradfitz/gomemcache,
+// so that both clients can read and write the same cache, e.g. while migrating
+// a codebase from one client to the other.
+//
+// When enabled, values are stored without recording their encoding in their
+// flags, the flags given by the Flags option are stored as is, and values are
+// decoded without regard to their flags. As gomemcache stores values as raw
+// bytes, values shared between the clients should be of type []byte or string.
+// Keys are validated using the same rules as gomemcache, i.e. keys must be at
+// most 250 bytes, and must not contain spaces or control characters.
+//
+// Compression, encryption, and chunking of values are not available in
+// gomemcache and are disabled.
+//
+// If unset the default is to record the encoding of values in their flags.
+func SetGomemcacheCompat(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.compat = enabled
+	}
+}
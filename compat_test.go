@@ -0,0 +1,53 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestClient_key_compat(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil, SetGomemcacheCompat(true))
+
+	valid := func(key string) error {
+		_, err := c.key(key)
+		return err
+	}
+
+	must.NoError(t, valid("key"))
+	must.NoError(t, valid(strings.Repeat("a", 250)))
+	must.NoError(t, valid("ключ"))
+
+	must.ErrorIs(t, valid(""), ErrKeyNotValid)
+	must.ErrorIs(t, valid(strings.Repeat("a", 251)), ErrKeyNotValid)
+	must.ErrorIs(t, valid(strings.Repeat("ключ", 50)), ErrKeyNotValid)
+	must.ErrorIs(t, valid("a b"), ErrKeyNotValid)
+	must.ErrorIs(t, valid("a\x00b"), ErrKeyNotValid)
+	must.ErrorIs(t, valid("a\x7fb"), ErrKeyNotValid)
+}
+
+func TestClient_pack_compat(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil,
+		SetGomemcacheCompat(true),
+		SetCompression(1),
+		SetEncryptionKey([]byte("0123456789abcdef")),
+	)
+
+	value := strings.Repeat("value", 100)
+	b, flags, err := c.pack("key", value, 0x7f000001)
+	must.NoError(t, err)
+	must.Eq(t, value, string(b))
+	must.Eq(t, 0x7f000001, flags)
+
+	v, err := unpack[string](c, "key", b, flags)
+	must.NoError(t, err)
+	must.Eq(t, value, v)
+}
@@ -0,0 +1,183 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Bits 28 through 30 of the flags of a value record how the encoded value was
+// compressed, if at all.
+const (
+	compressionShift = 28
+	compressionMask  = 0x7 << compressionShift
+)
+
+// Compression identifies the algorithm used to compress a value. It is recorded
+// in the flags of each compressed value so that any Client configured with a
+// Compressor for the algorithm is able to decompress the value.
+type Compression int
+
+const (
+	// Uncompressed indicates a value is not compressed.
+	Uncompressed Compression = iota
+
+	// Gzip compression is provided by compress/gzip, and is always available.
+	Gzip
+
+	// Snappy compression favors speed over compression ratio. A Compressor
+	// must be provided with SetCompressor, e.g. compressors.Snappy.
+	Snappy
+
+	// Zstd compression offers a high compression ratio at moderate speed. A
+	// Compressor must be provided with SetCompressor, e.g. compressors.Zstd.
+	Zstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Uncompressed:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Snappy:
+		return "snappy"
+	case Zstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("compression(%d)", int(c))
+	}
+}
+
+// A Compressor implements a compression algorithm. Implementations must be
+// safe for concurrent use.
+type Compressor interface {
+	Compress(b []byte) ([]byte, error)
+	Decompress(b []byte) ([]byte, error)
+}
+
+// flagsCompression returns the compression recorded in flags
+func flagsCompression(flags int) Compression {
+	return Compression((flags & compressionMask) >> compressionShift)
+}
+
+// SetCompression enables transparent compression of values whose encoding is
+// at least threshold bytes in size. Compressed values are recorded as such in
+// their flags, and are decompressed when read regardless of whether compression
+// is enabled on the reading Client.
+//
+// Values that do not shrink when compressed are stored uncompressed.
+//
+// Values are compressed using Gzip unless another algorithm is configured with
+// SetCompressor.
+//
+// If unset the default is to never compress values.
+func SetCompression(threshold int) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.compress = threshold
+	}
+}
+
+// SetCompressor configures the Compressor implementing the given algorithm,
+// and selects the algorithm for compressing values when compression is enabled
+// with SetCompression. The option may be applied more than once, in which case
+// values are compressed with the last algorithm applied, and values compressed
+// with any of the configured algorithms can be decompressed.
+//
+// Implementations of Snappy and Zstd are provided by the separate module
+// cattlecloud.net/go/memc/compressors.
+func SetCompressor(algorithm Compression, compressor Compressor) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.compressors == nil {
+			c.compressors = make(map[Compression]Compressor)
+		}
+		c.compressors[algorithm] = compressor
+		c.compression = algorithm
+	}
+}
+
+// compressor returns the Compressor implementing algorithm
+func (c *Client) compressor(algorithm Compression) (Compressor, error) {
+	if compressor, exists := c.compressors[algorithm]; exists {
+		return compressor, nil
+	}
+	if algorithm == Gzip {
+		return gzipCompressor{}, nil
+	}
+	return nil, fmt.Errorf("%w: no compressor configured for %s", ErrEncoding, algorithm)
+}
+
+// compressValue compresses b if it is large enough, returning the compressed
+// value along with the flags recording its compression
+func (c *Client) compressValue(b []byte, flags int) ([]byte, int, error) {
+	if c.compress <= 0 || len(b) < c.compress || c.compression == Uncompressed {
+		return b, flags, nil
+	}
+
+	compressor, err := c.compressor(c.compression)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	compressed, err := compressor.Compress(b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(compressed) >= len(b) {
+		return b, flags, nil
+	}
+
+	return compressed, flags | int(c.compression)<<compressionShift, nil
+}
+
+// decompressValue decompresses b if flags records it as compressed
+func (c *Client) decompressValue(b []byte, flags int) ([]byte, error) {
+	algorithm := flagsCompression(flags)
+	if algorithm == Uncompressed {
+		return b, nil
+	}
+
+	compressor, err := c.compressor(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := compressor.Decompress(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+	}
+
+	return plain, nil
+}
+
+// gzipCompressor implements Gzip using compress/gzip
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
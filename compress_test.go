@@ -0,0 +1,102 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestClient_pack(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("<li>fragment</li>", 100)
+
+	t.Run("disabled", func(t *testing.T) {
+		c := New(nil)
+		b, flags, err := c.pack("key", large, 3)
+		must.NoError(t, err)
+		must.Eq(t, large, string(b))
+		must.Eq(t, Uncompressed, flagsCompression(flags))
+		must.Eq(t, 3, flags&userFlagsMask)
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		c := New(nil, SetCompression(1<<20))
+		b, flags, err := c.pack("key", large, 0)
+		must.NoError(t, err)
+		must.Eq(t, large, string(b))
+		must.Eq(t, Uncompressed, flagsCompression(flags))
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		c := New(nil, SetCompression(64))
+		b, flags, err := c.pack("key", large, 3)
+		must.NoError(t, err)
+		must.Less(t, len(large), len(b))
+		must.Eq(t, Gzip, flagsCompression(flags))
+		must.Eq(t, encodingRaw, flagsEncoding(flags))
+		must.Eq(t, 3, flags&userFlagsMask)
+
+		v, err := unpack[string](c, "key", b, flags)
+		must.NoError(t, err)
+		must.Eq(t, large, v)
+	})
+
+	t.Run("incompressible", func(t *testing.T) {
+		c := New(nil, SetCompression(1))
+		b, flags, err := c.pack("key", "abc", 0)
+		must.NoError(t, err)
+		must.Eq(t, "abc", string(b))
+		must.Eq(t, Uncompressed, flagsCompression(flags))
+	})
+}
+
+func Test_unpack_corrupt(t *testing.T) {
+	t.Parallel()
+
+	flags := encodingRaw.flags(0) | int(Gzip)<<compressionShift
+	_, err := unpack[string](New(nil), "key", []byte("not gzip"), flags)
+	must.ErrorIs(t, err, ErrEncoding)
+}
+
+// prefixed is a Compressor that marks values compressed by gzipCompressor
+type prefixed struct{}
+
+func (prefixed) Compress(b []byte) ([]byte, error) {
+	compressed, err := gzipCompressor{}.Compress(b)
+	return append([]byte("z:"), compressed...), err
+}
+
+func (prefixed) Decompress(b []byte) ([]byte, error) {
+	rest, ok := bytes.CutPrefix(b, []byte("z:"))
+	if !ok {
+		return nil, errors.New("missing prefix")
+	}
+	return gzipCompressor{}.Decompress(rest)
+}
+
+func TestClient_SetCompressor(t *testing.T) {
+	t.Parallel()
+
+	value := strings.Repeat("ab", 100)
+
+	c := New(nil, SetCompression(10), SetCompressor(Zstd, prefixed{}))
+	b, flags, err := c.pack("key", value, 0)
+	must.NoError(t, err)
+	must.Eq(t, Zstd, flagsCompression(flags))
+	must.StrHasPrefix(t, "z:", string(b))
+
+	v, err := unpack[string](c, "key", b, flags)
+	must.NoError(t, err)
+	must.Eq(t, value, v)
+
+	// a client without the compressor cannot decompress the value
+	_, err = unpack[string](New(nil), "key", b, flags)
+	must.ErrorIs(t, err, ErrEncoding)
+}
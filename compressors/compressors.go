@@ -0,0 +1,69 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package compressors provides implementations of the Snappy and Zstd
+// compression algorithms for use with memc.SetCompressor, backed by
+// github.com/klauspost/compress.
+//
+//	c := memc.New(
+//		servers,
+//		memc.SetCompression(1024),
+//		memc.SetCompressor(memc.Zstd, compressors.Zstd()),
+//	)
+//
+// The package is a separate module, such that the root memc module does not
+// depend on github.com/klauspost/compress.
+package compressors
+
+import (
+	"cattlecloud.net/go/memc"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxDecoded is the size of the largest value Zstd decompresses, which is the
+// largest item size memcached can be configured with
+const maxDecoded = 1 << 30
+
+// Snappy returns a memc.Compressor implementing memc.Snappy, using the block
+// format of Snappy.
+func Snappy() memc.Compressor {
+	return snappyCompressor{}
+}
+
+type snappyCompressor struct{}
+
+func (snappyCompressor) Compress(b []byte) ([]byte, error) {
+	return snappy.Encode(nil, b), nil
+}
+
+func (snappyCompressor) Decompress(b []byte) ([]byte, error) {
+	return snappy.Decode(nil, b)
+}
+
+// Zstd returns a memc.Compressor implementing memc.Zstd, compressing values at
+// the default level of Zstandard.
+func Zstd() memc.Compressor {
+	// encoders and decoders without a reader or writer are safe for concurrent
+	// use of EncodeAll and DecodeAll, and creating them without invalid options
+	// cannot fail
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecoded))
+	return &zstdCompressor{
+		encoder: encoder,
+		decoder: decoder,
+	}
+}
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func (z *zstdCompressor) Compress(b []byte) ([]byte, error) {
+	return z.encoder.EncodeAll(b, nil), nil
+}
+
+func (z *zstdCompressor) Decompress(b []byte) ([]byte, error) {
+	return z.decoder.DecodeAll(b, nil)
+}
@@ -0,0 +1,79 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compressors
+
+import (
+	"bytes"
+	"testing"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/ignore"
+	"github.com/shoenig/test/must"
+)
+
+func TestCompressors(t *testing.T) {
+	t.Parallel()
+
+	value := bytes.Repeat([]byte("compressible "), 100)
+
+	for algorithm, compressor := range map[memc.Compression]memc.Compressor{
+		memc.Snappy: Snappy(),
+		memc.Zstd:   Zstd(),
+	} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			t.Parallel()
+
+			compressed, err := compressor.Compress(value)
+			must.NoError(t, err)
+			must.Less(t, len(value), len(compressed))
+
+			plain, err := compressor.Decompress(compressed)
+			must.NoError(t, err)
+			must.Eq(t, value, plain)
+
+			empty, err := compressor.Compress(nil)
+			must.NoError(t, err)
+			plain, err = compressor.Decompress(empty)
+			must.NoError(t, err)
+			must.SliceEmpty(t, plain)
+
+			_, err = compressor.Decompress([]byte("not compressed"))
+			must.Error(t, err)
+		})
+	}
+}
+
+func TestCompressors_client(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	value := bytes.Repeat([]byte("compressible "), 100)
+
+	for algorithm, compressor := range map[memc.Compression]memc.Compressor{
+		memc.Snappy: Snappy(),
+		memc.Zstd:   Zstd(),
+	} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			c := memc.New([]string{address}, memc.SetCompression(1), memc.SetCompressor(algorithm, compressor))
+			defer ignore.Close(c)
+
+			err := memc.Set(c, algorithm.String(), value)
+			must.NoError(t, err)
+
+			v, err := memc.Get[[]byte](c, algorithm.String())
+			must.NoError(t, err)
+			must.Eq(t, value, v)
+
+			// a client without the compressor cannot read the value
+			plain := memc.New([]string{address})
+			defer ignore.Close(plain)
+
+			_, err = memc.Get[[]byte](plain, algorithm.String())
+			must.ErrorIs(t, err, memc.ErrEncoding)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+module cattlecloud.net/go/memc/compressors
+
+go 1.26
+
+require (
+	cattlecloud.net/go/memc v0.0.0-00010101000000-000000000000
+	github.com/klauspost/compress v1.20.1
+	github.com/shoenig/ignore v0.4.0
+	github.com/shoenig/test v1.12.2
+)
+
+require (
+	cattlecloud.net/go/scope v1.2.1 // indirect
+	cattlecloud.net/go/stacks v1.1.2 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
+)
+
+replace cattlecloud.net/go/memc => ../
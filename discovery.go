@@ -0,0 +1,138 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"cmp"
+	"context"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetDiscoverySRV enables discovering the set of memcached instances from the
+// DNS SRV records of name, e.g. "_memcache._tcp.example.com", in addition to
+// any instances given to New. The records are looked up when the Client is
+// created and again every refresh interval, and instances are chosen in
+// proportion to the weight of their records. Only the records of the lowest
+// priority are used.
+//
+// Note that the Go resolver does not expose the TTL of DNS records, so the
+// refresh interval should be set to match the TTL of the SRV records.
+//
+// If unset the default is to use only the instances given to New.
+func SetDiscoverySRV(name string, refresh time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.srvName = name
+		c.srvRefresh = refresh
+	}
+}
+
+func lookupSRV(ctx context.Context, name string) ([]*net.SRV, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	return records, err
+}
+
+// discovery periodically discovers the set of memcached instances from SRV
+// records, until the Client is closed
+func (c *Client) discovery() {
+	ticker := time.NewTicker(c.srvRefresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			_ = c.discover()
+		}
+	}
+}
+
+// discover looks up the SRV records of the Client and replaces the set of
+// memcached instances accordingly, keeping the existing set if the lookup
+// fails or returns no records
+func (c *Client) discover() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	records, err := c.lookupSRV(ctx, c.srvName)
+	if err != nil {
+		c.log.Debug("memc: failed to discover instances", "name", c.srvName, "error", err)
+		c.metrics.Count("memc.discovery.errors", 1)
+		return err
+	}
+
+	discovered := weighted(records)
+	if len(discovered) == 0 {
+		return nil
+	}
+
+	c.core.lock.Lock()
+	c.discovered = discovered
+	c.core.lock.Unlock()
+
+	c.rebalance()
+	return nil
+}
+
+// weighted returns the address of the target of each SRV record of the lowest
+// priority, repeated in proportion to the weight of the record
+func weighted(records []*net.SRV) []string {
+	live := make([]*net.SRV, 0, len(records))
+	for _, record := range records {
+		if record.Target == "." || record.Target == "" {
+			continue // service explicitly unavailable
+		}
+		live = append(live, record)
+	}
+
+	if len(live) == 0 {
+		return nil
+	}
+
+	// keep only the records of the lowest (most preferred) priority
+	lowest := slices.MinFunc(live, func(a, b *net.SRV) int {
+		return cmp.Compare(a.Priority, b.Priority)
+	}).Priority
+	live = slices.DeleteFunc(live, func(record *net.SRV) bool {
+		return record.Priority != lowest
+	})
+
+	// order deterministically, as the resolver shuffles records by weight
+	slices.SortFunc(live, func(a, b *net.SRV) int {
+		return cmp.Or(
+			cmp.Compare(a.Target, b.Target),
+			cmp.Compare(a.Port, b.Port),
+		)
+	})
+
+	// reduce weights by their greatest common divisor, treating a weight of
+	// zero as the smallest possible weight
+	divisor := 0
+	for _, record := range live {
+		divisor = gcd(divisor, max(1, int(record.Weight)))
+	}
+
+	instances := make([]string, 0, len(live))
+	for _, record := range live {
+		host := strings.TrimSuffix(record.Target, ".")
+		address := net.JoinHostPort(host, strconv.Itoa(int(record.Port)))
+		for range max(1, int(record.Weight)) / divisor {
+			instances = append(instances, address)
+		}
+	}
+	return instances
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
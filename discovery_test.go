@@ -0,0 +1,115 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_weighted(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		must.Nil(t, weighted(nil))
+		must.Nil(t, weighted([]*net.SRV{{Target: "."}}))
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		result := weighted([]*net.SRV{
+			{Target: "b.example.", Port: 11211, Weight: 20},
+			{Target: "a.example.", Port: 11211, Weight: 10},
+			{Target: "c.example.", Port: 11212, Weight: 0},
+		})
+		must.Eq(t, []string{
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"a.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+			"c.example:11212",
+		}, result)
+	})
+
+	t.Run("divisor", func(t *testing.T) {
+		result := weighted([]*net.SRV{
+			{Target: "a.example.", Port: 11211, Weight: 10},
+			{Target: "b.example.", Port: 11211, Weight: 20},
+		})
+		must.Eq(t, []string{
+			"a.example:11211",
+			"b.example:11211",
+			"b.example:11211",
+		}, result)
+	})
+
+	t.Run("priority", func(t *testing.T) {
+		result := weighted([]*net.SRV{
+			{Target: "a.example.", Port: 11211, Priority: 20},
+			{Target: "b.example.", Port: 11211, Priority: 10},
+		})
+		must.Eq(t, []string{"b.example:11211"}, result)
+	})
+}
+
+func TestClient_discover(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.Eq(t, "", c.instance("key"))
+	_, err := Get[string](c, "key")
+	must.ErrorIs(t, err, ErrNoInstances)
+
+	records := []*net.SRV{
+		{Target: "a.example.", Port: 11211},
+		{Target: "b.example.", Port: 11211},
+	}
+	c.srvName = "_memcache._tcp.example"
+	c.lookupSRV = func(_ context.Context, name string) ([]*net.SRV, error) {
+		must.Eq(t, "_memcache._tcp.example", name)
+		if records == nil {
+			return nil, errors.New("no such host")
+		}
+		return records, nil
+	}
+
+	must.NoError(t, c.discover())
+	must.Eq(t, []string{"a.example:11211", "b.example:11211"}, c.instances())
+
+	// a failed lookup keeps the existing instances
+	records = nil
+	must.Error(t, c.discover())
+	must.Eq(t, []string{"a.example:11211", "b.example:11211"}, c.instances())
+}
@@ -0,0 +1,201 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// dumpBatch is the number of values read or written in a single round trip by
+// Dump and Restore
+const dumpBatch = 512
+
+// maxDumped is the size of the largest value Restore reads, which is the
+// largest item size memcached can be configured with
+const maxDumped = 1 << 30
+
+// ErrDumpNotValid is returned by Restore when the snapshot being read is not in
+// the format written by Dump.
+var ErrDumpNotValid = errors.New("memc: dump is not valid")
+
+// Dump writes a snapshot of every value stored on each memcached instance to w,
+// such that the values may be stored again with Restore, e.g. to carry a small
+// cache over the rolling restart of its instances. Keys are listed using
+// MetaDump, and their values are read in batches of pipelined gets.
+//
+// Values are written as they are stored, under their stored key including any
+// prefix, and with their flags and expiration, such that values of any client
+// sharing the instances are included. The snapshot is a sequence of memcached
+// "set" commands, each with the absolute time the value expires.
+//
+// Dump is expensive, holding every key in memory, and should not be used on
+// large caches or on the hot path. Values stored or removed while the snapshot
+// is taken may or may not be included.
+//
+// Errors are accumulated using errors.Join, and values of instances that did
+// respond are still written, unless writing to w fails.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Dump(w io.Writer, opts ...Option) error {
+	options := c.options(opts)
+	now := c.now()
+
+	// list the keys of each instance, along with when each expires
+	listed := make(map[string][]string)
+	expirations := make(map[string]int)
+	var errs []error
+	err := c.MetaDump(func(meta *KeyMeta) bool {
+		expiration := 0
+		if !meta.Expiration.IsZero() {
+			if !meta.Expiration.After(now) {
+				return true
+			}
+			expiration = int(meta.Expiration.Unix())
+		}
+		listed[meta.Address] = append(listed[meta.Address], meta.Key)
+		expirations[meta.Key] = expiration
+		return true
+	}, opts...)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	bw := bufio.NewWriter(w)
+	var werr error
+	for _, address := range c.instances() {
+		for batch := range slices.Chunk(listed[address], dumpBatch) {
+			err := c.doInstance(options.ctx, "dump", address, func(conn *iopool.Buffer) error {
+				return c.fetchMulti(conn, batch, func(h header, payload []byte) {
+					if werr == nil {
+						werr = writeDumped(bw, h.key, h.flags, expirations[h.key], payload)
+					}
+				})
+			})
+			switch {
+			case werr != nil:
+				return werr
+			case err != nil:
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// writeDumped writes the value of key to w as a "set" command
+func writeDumped(w *bufio.Writer, key string, flags, expiration int, payload []byte) error {
+	if _, err := fmt.Fprintf(w, "set %s %d %d %d\r\n", key, flags, expiration, len(payload)); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	_, err := w.WriteString("\r\n")
+	return err
+}
+
+// Restore stores each value of a snapshot written by Dump, read from r, in
+// batches of pipelined sets. Values are stored under their key as written,
+// without applying the prefix or key encoding of c, such that they may be
+// restored to a cluster with a different set of memcached instances. Values
+// that have expired since the snapshot was taken are skipped.
+//
+// Reading stops at the first command that is not valid, with ErrDumpNotValid,
+// in which case the values preceding the command have been stored. Errors
+// storing values are otherwise accumulated using errors.Join.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Restore(r io.Reader, opts ...Option) error {
+	options := c.options(opts)
+	now := c.now().Unix()
+
+	var (
+		errs        []error
+		keys        []string
+		encodings   [][]byte
+		flags       []int
+		expirations []int
+	)
+
+	store := func() {
+		errs = append(errs, c.pipelineStore(options.ctx, options.server, "set", keys, encodings, flags, expirations)...)
+		for _, key := range keys {
+			c.local.remove(key)
+		}
+		keys, encodings, flags, expirations = keys[:0], encodings[:0], flags[:0], expirations[:0]
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrDumpNotValid, err)
+		}
+
+		key, flag, expiration, size, err := parseDumped(line)
+		if err != nil {
+			return err
+		}
+
+		encoding := make([]byte, size+2)
+		if _, err := io.ReadFull(br, encoding); err != nil {
+			return fmt.Errorf("%w: %w", ErrDumpNotValid, err)
+		}
+		if string(encoding[size:]) != "\r\n" {
+			return fmt.Errorf("%w: value of %q is not terminated", ErrDumpNotValid, key)
+		}
+
+		if expiration > 0 && int64(expiration) <= now {
+			continue
+		}
+
+		keys = append(keys, key)
+		encodings = append(encodings, encoding[:size])
+		flags = append(flags, flag)
+		expirations = append(expirations, expiration)
+		if len(keys) == dumpBatch {
+			store()
+		}
+	}
+	store()
+
+	return errors.Join(errs...)
+}
+
+// parseDumped parses a command line written by Dump, e.g.
+//
+// "set user:42 16777216 1769212800 5\r\n"
+func parseDumped(line []byte) (string, int, int, int, error) {
+	cmd, rest := field(trimLine(line))
+	key, rest := field(rest)
+	flags, rest := field(rest)
+	expiration, rest := field(rest)
+	size, rest := field(rest)
+
+	f, fok := parseInt(flags)
+	e, eok := parseInt(expiration)
+	n, nok := parseInt(size)
+	switch {
+	case string(cmd) != "set", len(rest) > 0, !fok, !eok, !nok, n > maxDumped:
+		return "", 0, 0, 0, fmt.Errorf("%w: unexpected command %q", ErrDumpNotValid, line)
+	case check(string(key)) != nil:
+		return "", 0, 0, 0, fmt.Errorf("%w: %w", ErrDumpNotValid, ErrKeyNotValid)
+	}
+	return string(key), f, e, n, nil
+}
@@ -0,0 +1,44 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_parseDumped(t *testing.T) {
+	t.Parallel()
+
+	key, flags, expiration, size, err := parseDumped([]byte("set user:42 16777216 1769212800 5\r\n"))
+	must.NoError(t, err)
+	must.Eq(t, "user:42", key)
+	must.Eq(t, 16777216, flags)
+	must.Eq(t, 1769212800, expiration)
+	must.Eq(t, 5, size)
+
+	for _, line := range []string{
+		"get user:42\r\n",
+		"set user:42 0 0\r\n",
+		"set user:42 0 0 5 noreply\r\n",
+		"set user:42 x 0 5\r\n",
+		"set user:42 0 0 2000000000\r\n",
+	} {
+		_, _, _, _, err = parseDumped([]byte(line))
+		must.ErrorIs(t, err, ErrDumpNotValid, must.Sprint(line))
+	}
+}
+
+func Test_writeDumped(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	must.NoError(t, writeDumped(w, "user:42", 7, 0, []byte("value")))
+	must.NoError(t, w.Flush())
+	must.Eq(t, "set user:42 7 0 5\r\nvalue\r\n", buf.String())
+}
@@ -4,12 +4,26 @@
 package memc
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"cattlecloud.net/go/memc/iopool"
 	"cattlecloud.net/go/memc/memctest"
 	"github.com/shoenig/ignore"
 	"github.com/shoenig/test/must"
+	"github.com/shoenig/test/wait"
 )
 
 // Examples using netcat
@@ -96,8 +110,20 @@ func TestE2E_SetGet_expiration(t *testing.T) {
 
 	t.Run("months", func(t *testing.T) {
 		ttl := 90 * 24 * time.Hour // 3 months
-		err := Set(c, "mykey", "myvalue", TTL(ttl))
+		err := Set(c, "months", "myvalue", TTL(ttl))
 		must.NoError(t, err)
+
+		// the value must not expire immediately
+		v, err := Get[string](c, "months")
+		must.NoError(t, err)
+		must.Eq(t, "myvalue", v)
+
+		err = Touch(c, "months", 45*24*time.Hour)
+		must.NoError(t, err)
+
+		v, err = Get[string](c, "months")
+		must.NoError(t, err)
+		must.Eq(t, "myvalue", v)
 	})
 }
 
@@ -222,6 +248,18 @@ func TestE2E_Append(t *testing.T) {
 		must.Eq(t, "value1.appended", v)
 	})
 
+	t.Run("bytes", func(t *testing.T) {
+		err := Set(c, "key2", []byte{1, 2})
+		must.NoError(t, err)
+
+		err = Append(c, "key2", []byte{3, 4})
+		must.NoError(t, err)
+
+		v, verr := Get[[]byte](c, "key2")
+		must.NoError(t, verr)
+		must.Eq(t, []byte{1, 2, 3, 4}, v)
+	})
+
 	t.Run("not found", func(t *testing.T) {
 		err := Append(c, "key-does-not-exist", "value")
 		must.ErrorIs(t, err, ErrNotStored)
@@ -249,6 +287,18 @@ func TestE2E_Prepend(t *testing.T) {
 		must.Eq(t, "prepended.value1", v)
 	})
 
+	t.Run("bytes", func(t *testing.T) {
+		err := Set(c, "key2", []byte{3, 4})
+		must.NoError(t, err)
+
+		err = Prepend(c, "key2", []byte{1, 2})
+		must.NoError(t, err)
+
+		v, verr := Get[[]byte](c, "key2")
+		must.NoError(t, verr)
+		must.Eq(t, []byte{1, 2, 3, 4}, v)
+	})
+
 	t.Run("not found", func(t *testing.T) {
 		err := Prepend(c, "key-does-not-exist", "value")
 		must.ErrorIs(t, err, ErrNotStored)
@@ -438,6 +488,203 @@ func TestE2E_GetMulti_missing(t *testing.T) {
 	must.ErrorIs(t, ErrCacheMiss, results[1].B)
 }
 
+func TestE2E_SetMulti_pipelined(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	for _, protocol := range []Protocol{Text, Meta} {
+		c := New([]string{address1, address2}, SetProtocol(protocol))
+		defer ignore.Close(c)
+
+		items := make([]*Pair[string, int], 0, 500)
+		keys := make([]string, 0, 500)
+		for i := range 500 {
+			key := fmt.Sprintf("%d-key%d", protocol, i)
+			items = append(items, &Pair[string, int]{A: key, B: i})
+			keys = append(keys, key)
+		}
+
+		err := SetMulti(c, items)
+		must.NoError(t, err)
+
+		results := GetMulti[int](c, keys)
+		for i, result := range results {
+			must.Eq(t, &Pair[int, error]{A: i}, result)
+		}
+
+		// adding existing items fails for only those items
+		err = AddMulti(c, []*Pair[string, int]{
+			{A: keys[0], B: 0},
+			{A: fmt.Sprintf("%d-new", protocol), B: 1},
+		})
+		must.ErrorIs(t, err, ErrNotStored)
+
+		v, verr := Get[int](c, fmt.Sprintf("%d-new", protocol))
+		must.NoError(t, verr)
+		must.One(t, v)
+	}
+}
+
+func TestE2E_IncrementMulti(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	for _, protocol := range []Protocol{Text, Meta} {
+		c := New([]string{address1, address2}, SetProtocol(protocol))
+		defer ignore.Close(c)
+
+		a := fmt.Sprintf("%d-counter-a", protocol)
+		b := fmt.Sprintf("%d-counter-b", protocol)
+		must.NoError(t, Set(c, a, "10"))
+		must.NoError(t, Set(c, b, "20"))
+
+		results := IncrementMulti(c, []*Pair[string, uint64]{
+			{A: a, B: 1},
+			{A: "missing", B: 1},
+			{A: b, B: 5},
+		})
+		must.Eq(t, &Pair[uint64, error]{A: 11}, results[0])
+		must.ErrorIs(t, results[1].B, ErrNotFound)
+		must.Eq(t, &Pair[uint64, error]{A: 25}, results[2])
+
+		results = DecrementMulti(c, []*Pair[string, uint64]{
+			{A: a, B: 2},
+			{A: b, B: 10},
+		})
+		must.Eq(t, &Pair[uint64, error]{A: 9}, results[0])
+		must.Eq(t, &Pair[uint64, error]{A: 15}, results[1])
+	}
+}
+
+func TestE2E_GetMulti_sharded(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	for _, protocol := range []Protocol{Text, Meta} {
+		c := New([]string{address1, address2}, SetProtocol(protocol))
+		defer ignore.Close(c)
+
+		keys := make([]string, 0, 20)
+		for i := range 20 {
+			key := fmt.Sprintf("key%d", i)
+			keys = append(keys, key)
+			if i%5 == 0 {
+				continue // leave some keys missing
+			}
+			must.NoError(t, Set(c, key, i))
+		}
+
+		// include a duplicate and an invalid key
+		keys = append(keys, "key1", "not valid")
+
+		results := GetMulti[int](c, keys)
+		must.SliceLen(t, 22, results)
+		for i := range 20 {
+			if i%5 == 0 {
+				must.ErrorIs(t, results[i].B, ErrCacheMiss)
+				continue
+			}
+			must.Eq(t, &Pair[int, error]{A: i}, results[i])
+		}
+		must.Eq(t, &Pair[int, error]{A: 1}, results[20])
+		must.ErrorIs(t, results[21].B, ErrKeyNotValid)
+	}
+}
+
+func TestE2E_GetMultiMap(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	err := SetMulti(c, []*Pair[string, int]{
+		{"one", 1},
+		{"three", 3},
+	})
+	must.NoError(t, err)
+
+	t.Run("found", func(t *testing.T) {
+		values, verr := GetMultiMap[int](c, []string{"one", "two", "three"})
+		must.NoError(t, verr)
+		must.Eq(t, map[string]int{"one": 1, "three": 3}, values)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		values, verr := GetMultiMap[int](c, []string{"one", "not valid"})
+		must.ErrorIs(t, verr, ErrKeyNotValid)
+		must.Eq(t, map[string]int{"one": 1}, values)
+	})
+}
+
+func TestE2E_GetEach(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	err := SetMulti(c, []*Pair[string, int]{
+		{"one", 1},
+		{"three", 3},
+		{"four", 4},
+	})
+	must.NoError(t, err)
+
+	t.Run("all", func(t *testing.T) {
+		values := make(map[string]int)
+		misses := make([]string, 0, 1)
+		for key, result := range GetEach[int](c, []string{"one", "two", "three", "four"}) {
+			switch {
+			case result.B == nil:
+				values[key] = result.A
+			case errors.Is(result.B, ErrCacheMiss):
+				misses = append(misses, key)
+			default:
+				must.Unreachable(t)
+			}
+		}
+		must.Eq(t, map[string]int{"one": 1, "three": 3, "four": 4}, values)
+		must.Eq(t, []string{"two"}, misses)
+	})
+
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		for range GetEach[int](c, []string{"one", "two", "three", "four"}) {
+			count++
+			break
+		}
+		must.One(t, count)
+
+		// the client is still usable
+		v, verr := Get[int](c, "one")
+		must.NoError(t, verr)
+		must.One(t, v)
+	})
+}
+
 func TestE2E_Stats(t *testing.T) {
 	t.Parallel()
 
@@ -463,6 +710,130 @@ func TestE2E_Stats(t *testing.T) {
 	must.Eq(t, 71, s.Items.Bytes)
 }
 
+func TestE2E_StatsConns(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	s, err := StatsConns(c)
+	must.NoError(t, err)
+	must.True(t, slices.ContainsFunc(s, func(conn *ConnStatistics) bool {
+		return conn.State == "conn_parse_cmd"
+	}))
+
+	all, err := c.StatsConns()
+	must.NoError(t, err)
+	must.SliceNotEmpty(t, all[address])
+}
+
+func TestE2E_MetaDump(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	expected := make([]string, 0, 10)
+	for i := range 10 {
+		key := fmt.Sprintf("key%d", i)
+		err := Set(c, key, "value", TTL(time.Hour))
+		must.NoError(t, err)
+		expected = append(expected, key)
+	}
+
+	keys, err := c.Keys()
+	must.NoError(t, err)
+	slices.Sort(keys)
+	must.Eq(t, expected, keys)
+
+	var metas []*KeyMeta
+	err = c.MetaDump(func(meta *KeyMeta) bool {
+		metas = append(metas, meta)
+		return len(metas) < 3
+	})
+	must.NoError(t, err)
+	must.SliceLen(t, 3, metas)
+	must.Eq(t, c.instance(metas[0].Key), metas[0].Address)
+	must.False(t, metas[0].Expiration.IsZero())
+
+	// connections are still usable after stopping early
+	v, err := Get[string](c, "key1")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+}
+
+func TestE2E_Slabs(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	err := c.SlabsAutomove(AutomoveAggressive)
+	must.NoError(t, err)
+
+	err = c.SlabsReassign(address, 1, 2)
+	must.ErrorIs(t, err, ErrSlabsReassign)
+	must.ErrorContains(t, err, "NOSPARE")
+	must.False(t, errors.As(err, new(*ServerError)))
+
+	err = c.SlabsReassign("127.0.0.1:1", 1, 2)
+	must.ErrorIs(t, err, iopool.ErrUnknownInstance)
+}
+
+func TestE2E_ClientStats(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	for i := range 10 {
+		err := Set(c, fmt.Sprintf("key%d", i), "value")
+		must.NoError(t, err)
+	}
+
+	s, err := c.Stats()
+	must.NoError(t, err)
+	must.MapLen(t, 2, s)
+	must.Positive(t, s[address1].Runtime.Threads)
+	must.Positive(t, s[address2].Runtime.Threads)
+	must.Eq(t, 10, s[address1].Items.Current+s[address2].Items.Current)
+
+	items, err := c.StatsItems()
+	must.NoError(t, err)
+	must.MapLen(t, 2, items)
+	must.Eq(t, 10, items[address1][0].Number+items[address2][0].Number)
+
+	slabs, err := c.StatsSlabs()
+	must.NoError(t, err)
+	must.MapLen(t, 2, slabs)
+	must.Positive(t, slabs[address1].ActiveSlabs)
+
+	aggregate, err := c.AggregateStats()
+	must.NoError(t, err)
+	must.Eq(t, 2, aggregate.Instances)
+	must.Eq(t, 10, aggregate.Items)
+	must.Eq(t, s[address1].Memory.Limit+s[address2].Memory.Limit, aggregate.Limit)
+	must.Eq(t, s[address1].Commands.Hit.Get+s[address2].Commands.Hit.Get, aggregate.Hits)
+}
+
 func TestE2E_StatsSlabs(t *testing.T) {
 	t.Parallel()
 
@@ -599,3 +970,2412 @@ func TestE2E_CAS(t *testing.T) {
 		must.ErrorIs(t, err, ErrNotFound)
 	})
 }
+
+func TestE2E_Query(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	calls := 0
+	f := func(query string, args ...any) ([]string, error) {
+		calls++
+		return []string{"alice", "bob"}, nil
+	}
+
+	const query = "SELECT name FROM users WHERE org = ?"
+
+	v, err := Query(c, time.Minute, f, query, []any{7})
+	must.NoError(t, err)
+	must.Eq(t, []string{"alice", "bob"}, v)
+	must.Eq(t, 1, calls)
+
+	v, err = Query(c, time.Minute, f, query, []any{7}, Context(t.Context()))
+	must.NoError(t, err)
+	must.Eq(t, []string{"alice", "bob"}, v)
+	must.Eq(t, 1, calls)
+
+	err = InvalidateQuery(c, query, []any{7})
+	must.NoError(t, err)
+
+	_, err = Query(c, time.Minute, f, query, []any{7})
+	must.NoError(t, err)
+	must.Eq(t, 2, calls)
+
+	// the query is executed when memcached is unavailable
+	unavailable := New([]string{"127.0.0.1:1"})
+	defer ignore.Close(unavailable)
+
+	v, err = Query(unavailable, time.Minute, f, query, []any{7})
+	must.NoError(t, err)
+	must.Eq(t, []string{"alice", "bob"}, v)
+	must.Eq(t, 3, calls)
+
+	// an error executing the query is returned, and nothing is stored
+	_, err = Query(c, time.Minute, func(string, ...any) ([]string, error) {
+		return nil, errors.New("connection refused")
+	}, query, []any{8})
+	must.EqError(t, err, "connection refused")
+
+	_, err = Get[[]string](c, QueryKey(query, 8))
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_Fetch(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	loads := 0
+	load := func(context.Context) (string, error) {
+		loads++
+		return "loaded", nil
+	}
+
+	t.Run("miss then hit", func(t *testing.T) {
+		v, err := Fetch(c, "fetch1", time.Hour, load)
+		must.NoError(t, err)
+		must.Eq(t, "loaded", v)
+		must.Eq(t, 1, loads)
+
+		v, err = Fetch(c, "fetch1", time.Hour, load)
+		must.NoError(t, err)
+		must.Eq(t, "loaded", v)
+		must.Eq(t, 1, loads)
+	})
+
+	t.Run("load error", func(t *testing.T) {
+		failure := errors.New("origin unavailable")
+		_, err := Fetch(c, "fetch2", time.Hour, func(context.Context) (string, error) {
+			return "", failure
+		})
+		must.ErrorIs(t, err, failure)
+
+		_, err = Get[string](c, "fetch2")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		down := New([]string{"127.0.0.1:1"})
+		defer ignore.Close(down)
+
+		v, err := Fetch(down, "fetch3", time.Hour, load)
+		must.NoError(t, err)
+		must.Eq(t, "loaded", v)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := Fetch(c, "fetch4", time.Hour, load, Context(ctx))
+		must.ErrorIs(t, err, context.Canceled)
+	})
+	t.Run("stampede", func(t *testing.T) {
+		var origin atomic.Int64
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Go(func() {
+				v, err := Fetch(c, "fetch5", time.Hour, func(context.Context) (string, error) {
+					origin.Add(1)
+					<-release
+					return "shared", nil
+				})
+				must.NoError(t, err)
+				must.Eq(t, "shared", v)
+			})
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		must.Eq(t, 1, origin.Load())
+	})
+}
+
+func TestE2E_Fetch_softTTL(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	var version atomic.Int64
+	load := func(context.Context) (int64, error) {
+		return version.Add(1), nil
+	}
+
+	v, err := Fetch(c, "soft1", time.Hour, load, SoftTTL(50*time.Millisecond))
+	must.NoError(t, err)
+	must.Eq(t, 1, v)
+
+	// fresh values are returned without loading
+	v, err = Fetch(c, "soft1", time.Hour, load, SoftTTL(50*time.Millisecond))
+	must.NoError(t, err)
+	must.Eq(t, 1, v)
+	must.Eq(t, 1, version.Load())
+
+	// stale values are returned while being refreshed in the background
+	time.Sleep(60 * time.Millisecond)
+	before := time.Now()
+	v, err = Fetch(c, "soft1", time.Hour, load, SoftTTL(time.Minute))
+	must.NoError(t, err)
+	must.Eq(t, 1, v)
+
+	// wait for the refreshed value to be stored
+	var cached softValue[int64]
+	must.Wait(t, wait.InitialSuccess(
+		wait.Timeout(5*time.Second),
+		wait.Gap(10*time.Millisecond),
+		wait.ErrorFunc(func() error {
+			cached, err = Get[softValue[int64]](c, "soft1")
+			switch {
+			case err != nil:
+				return err
+			case cached.Value != 2:
+				return fmt.Errorf("value is %d", cached.Value)
+			default:
+				return nil
+			}
+		}),
+	))
+	must.Eq(t, 2, version.Load())
+
+	// the refreshed value is stored with the soft ttl of the refreshing call
+	stale := time.Unix(0, cached.Stale)
+	must.True(t, !stale.Before(before.Add(time.Minute)))
+	must.True(t, stale.Before(time.Now().Add(time.Minute)))
+
+	v, err = Fetch(c, "soft1", time.Hour, load, SoftTTL(time.Minute))
+	must.NoError(t, err)
+	must.Eq(t, 2, v)
+	must.Eq(t, 2, version.Load())
+}
+
+func TestE2E_Fetch_softTTL_panic(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	var offset atomic.Int64
+	c := New([]string{address}, SetClock(func() time.Time {
+		return time.Now().Add(time.Duration(offset.Load()))
+	}))
+	defer ignore.Close(c)
+
+	v, err := Fetch(c, "soft2", time.Hour, func(context.Context) (int, error) {
+		return 1, nil
+	}, SoftTTL(time.Minute))
+	must.NoError(t, err)
+	must.Eq(t, 1, v)
+
+	// the value is stale once the clock passes the soft ttl
+	offset.Store(int64(2 * time.Minute))
+
+	panicked := make(chan struct{})
+	v, err = Fetch(c, "soft2", time.Hour, func(context.Context) (int, error) {
+		close(panicked)
+		panic("boom")
+	}, SoftTTL(time.Minute))
+	must.NoError(t, err)
+	must.Eq(t, 1, v)
+	<-panicked
+
+	// the failed refresh is recovered from, and a later refresh succeeds
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if v, err = Fetch(c, "soft2", time.Hour, func(context.Context) (int, error) {
+			return 2, nil
+		}, SoftTTL(time.Minute)); v == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	must.NoError(t, err)
+	must.Eq(t, 2, v)
+}
+
+func TestE2E_Memoize(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	type query struct {
+		User  int
+		Scope string
+	}
+
+	calls := 0
+	lookup := Memoize(c, "lookup", time.Hour, func(q query) (string, error) {
+		calls++
+		return fmt.Sprintf("%s-%d", q.Scope, q.User), nil
+	})
+
+	v, err := lookup(query{User: 42, Scope: "read"})
+	must.NoError(t, err)
+	must.Eq(t, "read-42", v)
+
+	v, err = lookup(query{User: 42, Scope: "read"})
+	must.NoError(t, err)
+	must.Eq(t, "read-42", v)
+	must.Eq(t, 1, calls)
+
+	v, err = lookup(query{User: 42, Scope: "write"})
+	must.NoError(t, err)
+	must.Eq(t, "write-42", v)
+	must.Eq(t, 2, calls)
+}
+
+func TestE2E_Lock(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	t.Run("exclusive", func(t *testing.T) {
+		lock, err := TryLock(c, "lock1", time.Minute)
+		must.NoError(t, err)
+		must.Eq(t, "lock1", lock.Key())
+
+		_, err = TryLock(c, "lock1", time.Minute)
+		must.ErrorIs(t, err, ErrLocked)
+
+		must.NoError(t, lock.Refresh())
+		must.NoError(t, lock.Unlock())
+
+		// released locks may be acquired again
+		again, err := TryLock(c, "lock1", time.Minute)
+		must.NoError(t, err)
+		must.NoError(t, again.Unlock())
+	})
+
+	t.Run("released", func(t *testing.T) {
+		lock, err := TryLock(c, "lock2", time.Minute)
+		must.NoError(t, err)
+		must.NoError(t, lock.Unlock())
+
+		must.ErrorIs(t, lock.Unlock(), ErrLockNotHeld)
+		must.ErrorIs(t, lock.Refresh(), ErrLockNotHeld)
+	})
+
+	t.Run("stolen", func(t *testing.T) {
+		lock, err := TryLock(c, "lock3", time.Minute)
+		must.NoError(t, err)
+
+		// simulate the lock expiring and being acquired by another holder
+		must.NoError(t, Delete(c, "lock3"))
+		other, err := TryLock(c, "lock3", time.Minute)
+		must.NoError(t, err)
+
+		must.ErrorIs(t, lock.Unlock(), ErrLockNotHeld)
+		must.ErrorIs(t, lock.Refresh(), ErrLockNotHeld)
+
+		// the lock of the other holder is left in place
+		_, err = TryLock(c, "lock3", time.Minute)
+		must.ErrorIs(t, err, ErrLocked)
+		must.NoError(t, other.Unlock())
+	})
+}
+
+func TestE2E_WriteThrough(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	backing := make(map[string]string)
+	failure := errors.New("database is down")
+	fail := false
+
+	save := WriteThrough(c, time.Hour, func(_ context.Context, key, value string) error {
+		if fail {
+			return failure
+		}
+		backing[key] = value
+		return nil
+	})
+
+	t.Run("persisted", func(t *testing.T) {
+		must.NoError(t, save("write1", "first"))
+		must.Eq(t, "first", backing["write1"])
+
+		v, err := Get[string](c, "write1")
+		must.NoError(t, err)
+		must.Eq(t, "first", v)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		must.NoError(t, save("write2", "first"))
+
+		fail = true
+		defer func() { fail = false }()
+
+		must.ErrorIs(t, save("write2", "second"), failure)
+
+		_, err := Get[string](c, "write2")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		must.ErrorIs(t, save("bad key", "first"), ErrKeyNotValid)
+		must.MapNotContainsKey(t, backing, "bad key")
+	})
+}
+
+func TestE2E_GetSet(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	t.Run("missing", func(t *testing.T) {
+		old, err := GetSet(c, "token1", "first")
+		must.ErrorIs(t, err, ErrCacheMiss)
+		must.Eq(t, "", old)
+
+		v, verr := Get[string](c, "token1")
+		must.NoError(t, verr)
+		must.Eq(t, "first", v)
+	})
+
+	t.Run("swap", func(t *testing.T) {
+		err := Set(c, "token2", "first")
+		must.NoError(t, err)
+
+		old, serr := GetSet(c, "token2", "second")
+		must.NoError(t, serr)
+		must.Eq(t, "first", old)
+
+		v, verr := Get[string](c, "token2")
+		must.NoError(t, verr)
+		must.Eq(t, "second", v)
+	})
+}
+
+func TestE2E_Exists(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	sink := newTestSink()
+	c := New([]string{address}, SetMetricsSink(sink))
+	defer ignore.Close(c)
+
+	t.Run("missing", func(t *testing.T) {
+		exists, err := Exists(c, "missing")
+		must.NoError(t, err)
+		must.False(t, exists)
+	})
+
+	t.Run("present", func(t *testing.T) {
+		err := Set(c, "present", "value")
+		must.NoError(t, err)
+
+		exists, eerr := Exists(c, "present")
+		must.NoError(t, eerr)
+		must.True(t, exists)
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		sink.lock.Lock()
+		defer sink.lock.Unlock()
+		must.Eq(t, 2, sink.counts["memc.exists.calls"])
+		must.MapNotContainsKey(t, sink.counts, "memc.mg.calls")
+	})
+}
+
+type countingConn struct {
+	net.Conn
+	written *atomic.Int64
+}
+
+func (cc *countingConn) Write(b []byte) (int, error) {
+	n, err := cc.Conn.Write(b)
+	cc.written.Add(int64(n))
+	return n, err
+}
+
+func TestE2E_SetConnWrapper(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	written := new(atomic.Int64)
+	c := New([]string{address}, SetConnWrapper(func(conn net.Conn) net.Conn {
+		return &countingConn{Conn: conn, written: written}
+	}))
+	defer ignore.Close(c)
+
+	err := Set(c, "key1", "value1")
+	must.NoError(t, err)
+	must.Eq(t, int64(len("set key1 16777216 3600 6\r\nvalue1\r\n")), written.Load())
+}
+
+func TestE2E_Touch(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	t.Run("not found", func(t *testing.T) {
+		err := Touch(c, "missing", time.Hour)
+		must.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := Touch(c, "key1", 10*time.Millisecond)
+		must.ErrorIs(t, err, ErrExpiration)
+		must.False(t, errors.As(err, new(*ServerError)))
+		must.Zero(t, c.PoolStats()[address].Discarded)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		err := Set(c, "key2", "value2", TTL(2*time.Second))
+		must.NoError(t, err)
+
+		err = Touch(c, "key2", time.Hour)
+		must.NoError(t, err)
+
+		time.Sleep(3 * time.Second)
+
+		v, verr := Get[string](c, "key2")
+		must.NoError(t, verr)
+		must.Eq(t, "value2", v)
+	})
+}
+
+func TestE2E_Version(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	versions, err := c.Version()
+	must.NoError(t, err)
+	must.MapLen(t, 2, versions)
+	must.StrHasPrefix(t, "1.", versions[address1])
+	must.StrHasPrefix(t, "1.", versions[address2])
+}
+
+func TestE2E_AddRemoveServer(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1})
+	defer ignore.Close(c)
+
+	err := Set(c, "key1", "value1")
+	must.NoError(t, err)
+
+	c.AddServer(address2)
+	c.AddServer(address2)
+	must.Eq(t, []string{address1, address2}, c.instances())
+
+	versions, err := c.Version()
+	must.NoError(t, err)
+	must.MapLen(t, 2, versions)
+
+	c.RemoveServer(address2)
+	must.Eq(t, []string{address1}, c.instances())
+
+	// keys are all on the remaining instance again
+	v, err := Get[string](c, "key1")
+	must.NoError(t, err)
+	must.Eq(t, "value1", v)
+
+	c.RemoveServer(address1)
+	_, err = Get[string](c, "key1")
+	must.ErrorIs(t, err, ErrNoInstances)
+}
+
+func TestE2E_Ketama(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2}, SetDistribution(Ketama))
+	defer ignore.Close(c)
+
+	for i := range 20 {
+		err := Set(c, fmt.Sprintf("key%d", i), i)
+		must.NoError(t, err)
+	}
+
+	for i := range 20 {
+		v, err := Get[int](c, fmt.Sprintf("key%d", i))
+		must.NoError(t, err)
+		must.Eq(t, i, v)
+	}
+
+	// each key is stored on exactly one of the instances
+	keys := make([]string, 0, 20)
+	for i := range 20 {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+
+	c1 := New([]string{address1})
+	defer ignore.Close(c1)
+	found1, err := GetMultiMap[int](c1, keys)
+	must.NoError(t, err)
+
+	c2 := New([]string{address2})
+	defer ignore.Close(c2)
+	found2, err := GetMultiMap[int](c2, keys)
+	must.NoError(t, err)
+
+	must.Eq(t, 20, len(found1)+len(found2))
+	must.Positive(t, len(found1))
+	must.Positive(t, len(found2))
+}
+
+func TestE2E_ReadFallback(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	instances := []string{address1, address2}
+
+	c := New(instances, SetDistribution(Ketama), SetReadFallback(true))
+	defer ignore.Close(c)
+
+	// store the value only on the secondary instance of the key
+	secondary := New([]string{c.secondary("key1")})
+	defer ignore.Close(secondary)
+	err := Set(secondary, "key1", "value1")
+	must.NoError(t, err)
+
+	v, err := Get[string](c, "key1")
+	must.NoError(t, err)
+	must.Eq(t, "value1", v)
+
+	plain := New(instances, SetDistribution(Ketama))
+	defer ignore.Close(plain)
+	_, err = Get[string](plain, "key1")
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	_, err = Get[string](c, "key2")
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_Failover(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	// an address nothing is listening on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	unreachable := ln.Addr().String()
+	must.NoError(t, ln.Close())
+
+	c := New(
+		[]string{address, unreachable},
+		SetDistribution(Ketama),
+		SetFailover(1, time.Minute),
+	)
+	defer ignore.Close(c)
+
+	// find a key chosen to be stored on the unreachable instance
+	key := ""
+	for i := 0; key == ""; i++ {
+		if k := fmt.Sprintf("key%d", i); c.instance(k) == unreachable {
+			key = k
+		}
+	}
+
+	err = Set(c, key, "value")
+	must.Error(t, err)
+
+	// the key has failed over to the reachable instance
+	must.Eq(t, address, c.instance(key))
+	err = Set(c, key, "value")
+	must.NoError(t, err)
+
+	v, err := Get[string](c, key)
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+}
+
+func TestE2E_RetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetRetryPolicy(RetryPolicy{
+		Attempts: 3,
+		Delay:    time.Millisecond,
+		Jitter:   0.5,
+	}))
+	defer ignore.Close(c)
+
+	attempts := 0
+	flaky := func(failures int) func(*iopool.Buffer) error {
+		attempts = 0
+		return func(*iopool.Buffer) error {
+			attempts++
+			if attempts <= failures {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+	}
+
+	t.Run("recovers", func(t *testing.T) {
+		err := c.doRetry(&Options{ctx: t.Context()}, "get", "key1", flaky(2))
+		must.NoError(t, err)
+		must.Eq(t, 3, attempts)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		err := c.doRetry(&Options{ctx: t.Context()}, "get", "key1", flaky(3))
+		must.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		must.Eq(t, 3, attempts)
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		attempts = 0
+		err := c.doRetry(&Options{ctx: t.Context()}, "get", "key1", func(*iopool.Buffer) error {
+			attempts++
+			return ErrCacheMiss
+		})
+		must.ErrorIs(t, err, ErrCacheMiss)
+		must.Eq(t, 1, attempts)
+	})
+}
+
+func TestE2E_Chunking(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetMaxItemSize(1000))
+	defer ignore.Close(c)
+
+	large := make([]byte, 4500)
+	for i := range large {
+		large[i] = byte(i)
+	}
+
+	// manifestOf reads the manifest of chunks stored under key
+	manifestOf := func(t *testing.T, key string) *manifest {
+		conn, err := c.getConn(t.Context(), key)
+		must.NoError(t, err)
+		defer c.setConn(key, conn)
+
+		payload, h, err := c.fetch(conn, key, false)
+		must.NoError(t, err)
+		must.True(t, c.chunked(h.flags))
+
+		m, err := parseManifest(*payload)
+		must.NoError(t, err)
+		return m
+	}
+
+	t.Run("set get", func(t *testing.T) {
+		err := Set(c, "large", large)
+		must.NoError(t, err)
+
+		// the value stored under the key is a manifest of the chunks
+		m := manifestOf(t, "large")
+		must.Eq(t, 5, m.count)
+		must.Eq(t, 4500, m.length)
+
+		v, err := Get[[]byte](c, "large")
+		must.NoError(t, err)
+		must.Eq(t, large, v)
+
+		// a client without chunking enabled can still read the value
+		plain := New([]string{address})
+		defer ignore.Close(plain)
+
+		v, err = Get[[]byte](plain, "large")
+		must.NoError(t, err)
+		must.Eq(t, large, v)
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		err := SetMulti(c, []*Pair[string, []byte]{
+			{A: "multi1", B: large},
+			{A: "multi2", B: []byte("small")},
+		})
+		must.NoError(t, err)
+
+		results := GetMulti[[]byte](c, []string{"multi1", "multi2"})
+		must.NoError(t, results[0].B)
+		must.Eq(t, large, results[0].A)
+		must.NoError(t, results[1].B)
+		must.Eq(t, []byte("small"), results[1].A)
+
+		for key, result := range GetEach[[]byte](c, []string{"multi1"}) {
+			must.Eq(t, "multi1", key)
+			must.NoError(t, result.B)
+			must.Eq(t, large, result.A)
+		}
+	})
+
+	t.Run("compare and swap", func(t *testing.T) {
+		err := Set(c, "swap", large)
+		must.NoError(t, err)
+
+		v, cas, err := Gets[[]byte](c, "swap")
+		must.NoError(t, err)
+		must.Eq(t, large, v)
+
+		err = CompareAndSwap(c, "swap", cas, large[:2000])
+		must.NoError(t, err)
+
+		v, err = Get[[]byte](c, "swap")
+		must.NoError(t, err)
+		must.Eq(t, large[:2000], v)
+	})
+
+	t.Run("missing chunk", func(t *testing.T) {
+		err := Set(c, "evicted", large)
+		must.NoError(t, err)
+
+		m := manifestOf(t, "evicted")
+		must.NoError(t, Delete(c, m.key(1)))
+
+		_, err = Get[[]byte](c, "evicted")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+}
+
+func TestE2E_Encryption(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := New([]string{address}, SetEncryptionKey(key), SetMaxItemSize(100))
+	defer ignore.Close(c)
+
+	type person struct {
+		Name string
+		SSN  string
+	}
+
+	p := &person{Name: "bob", SSN: "123-45-6789"}
+	err := Set(c, "person", p)
+	must.NoError(t, err)
+
+	v, err := Get[*person](c, "person")
+	must.NoError(t, err)
+	must.Eq(t, p, v)
+
+	large := strings.Repeat("x", 1000)
+	err = Set(c, "large", large)
+	must.NoError(t, err)
+
+	s, err := Get[string](c, "large")
+	must.NoError(t, err)
+	must.Eq(t, large, s)
+
+	// a client without the encryption key cannot read the value
+	plain := New([]string{address})
+	defer ignore.Close(plain)
+
+	_, err = Get[*person](plain, "person")
+	must.ErrorIs(t, err, ErrEncoding)
+}
+
+func TestE2E_PoolStats(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetMaxOpenConnections(4))
+	defer ignore.Close(c)
+
+	err := Set(c, "key1", "value1")
+	must.NoError(t, err)
+
+	stats := c.PoolStats()
+	must.MapLen(t, 1, stats)
+	must.Eq(t, PoolStats{MaxOpen: 4, Open: 1, Idle: 1, Dials: 1}, stats[address])
+}
+
+func TestE2E_Logger(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := New([]string{address},
+		SetLogger(logger),
+		SetRetryPolicy(RetryPolicy{Attempts: 2, Delay: time.Millisecond}),
+	)
+	defer ignore.Close(c)
+
+	err := Set(c, "key1", "secret value")
+	must.NoError(t, err)
+
+	_, err = Get[string](c, "missing")
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	err = c.doRetry(&Options{ctx: t.Context()}, "get", "key1", func(*iopool.Buffer) error {
+		return io.ErrUnexpectedEOF
+	})
+	must.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	logs := buf.String()
+	must.StrContains(t, logs, `msg="memc: opened connection" address=`+address)
+	must.StrContains(t, logs, `msg="memc: operation failed" op=get address=`+address)
+	must.StrContains(t, logs, `msg="memc: retrying operation" op=get attempt=2`)
+	must.StrContains(t, logs, `reason="connection failed"`)
+	must.StrNotContains(t, logs, "secret")
+	must.StrNotContains(t, logs, "cache miss")
+}
+
+func TestE2E_SlowOperation(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	var slow []Info
+	c := New([]string{address},
+		SetSlowOperation(50*time.Millisecond, func(info Info, elapsed time.Duration) {
+			must.GreaterEq(t, 50*time.Millisecond, elapsed)
+			slow = append(slow, info)
+		}),
+	)
+	defer ignore.Close(c)
+
+	err := Set(c, "fast", "value")
+	must.NoError(t, err)
+	must.SliceEmpty(t, slow)
+
+	err = c.do(&Options{ctx: t.Context()}, "get", "slow", func(*iopool.Buffer) error {
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	})
+	must.NoError(t, err)
+	must.Eq(t, []Info{{Op: "get", Key: "slow", Address: address}}, slow)
+}
+
+func TestE2E_Interceptor(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	var calls []string
+	var infos []Info
+	injected := errors.New("injected")
+
+	c := New([]string{address},
+		SetInterceptor(func(ctx context.Context, info Info, next func() error) error {
+			calls = append(calls, "outer")
+			infos = append(infos, info)
+			return next()
+		}),
+		SetInterceptor(func(ctx context.Context, info Info, next func() error) error {
+			calls = append(calls, "inner")
+			if info.Key == "chaos" {
+				return injected
+			}
+			return next()
+		}),
+	)
+	defer ignore.Close(c)
+
+	err := Set(c, "key1", "value1")
+	must.NoError(t, err)
+	must.Eq(t, []string{"outer", "inner"}, calls)
+	must.Eq(t, Info{Op: "set", Key: "key1", Address: address}, infos[0])
+
+	_ = GetMulti[string](c, []string{"key1"})
+	must.Eq(t, Info{Op: "get_multi", Address: address}, infos[1])
+
+	err = Set(c, "chaos", "value")
+	must.ErrorIs(t, err, injected)
+
+	_, err = Get[string](c, "chaos")
+	must.ErrorIs(t, err, injected)
+}
+
+func TestE2E_Item(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	err := SetItem(c, &Item[string]{
+		Key:        "item",
+		Value:      "value1",
+		Flags:      7,
+		Expiration: time.Hour,
+	})
+	must.NoError(t, err)
+
+	item, err := GetItem[string](c, "item")
+	must.NoError(t, err)
+	must.Eq(t, "item", item.Key)
+	must.Eq(t, "value1", item.Value)
+	must.Eq(t, 7, item.Flags)
+	must.Positive(t, item.CAS)
+
+	// store using the CAS token of item
+	item.Value = "value2"
+	err = SetItem(c, item)
+	must.NoError(t, err)
+
+	// the CAS token is no longer current
+	item.Value = "value3"
+	err = SetItem(c, item)
+	must.ErrorIs(t, err, ErrConflict)
+
+	item, err = GetItem[string](c, "item")
+	must.NoError(t, err)
+	must.Eq(t, "value2", item.Value)
+
+	_, err = GetItem[string](c, "missing")
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_GetWithFlags(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetCompression(10))
+	defer ignore.Close(c)
+
+	err := Set(c, "flagged", strings.Repeat("value", 10), Flags(0xabcd))
+	must.NoError(t, err)
+
+	v, flags, err := GetWithFlags[string](c, "flagged")
+	must.NoError(t, err)
+	must.Eq(t, strings.Repeat("value", 10), v)
+	must.Eq(t, 0xabcd, flags)
+
+	err = Set(c, "unflagged", 1)
+	must.NoError(t, err)
+
+	_, flags, err = GetWithFlags[int](c, "unflagged")
+	must.NoError(t, err)
+	must.Eq(t, 0, flags)
+
+	_, _, err = GetWithFlags[int](c, "missing")
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	compat := New([]string{address}, SetGomemcacheCompat(true))
+	defer ignore.Close(compat)
+
+	err = Set(compat, "legacy", "value", Flags(1<<30))
+	must.NoError(t, err)
+
+	_, flags, err = GetWithFlags[string](compat, "legacy")
+	must.NoError(t, err)
+	must.Eq(t, 1<<30, flags)
+}
+
+func TestE2E_Namespace(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetDefaultTTL(time.Minute))
+	defer ignore.Close(c)
+
+	users := NewNamespace(c, "users")
+
+	first, err := users.Key("42")
+	must.NoError(t, err)
+	must.StrHasPrefix(t, "users:", first)
+	must.StrHasSuffix(t, ":42", first)
+	must.NoError(t, Set(c, first, "alice"))
+
+	// keys are stable within a generation
+	again, err := users.Key("42")
+	must.NoError(t, err)
+	must.Eq(t, first, again)
+
+	v, err := Get[string](c, again)
+	must.NoError(t, err)
+	must.Eq(t, "alice", v)
+
+	// invalidating the namespace changes every derived key
+	must.NoError(t, users.Invalidate())
+
+	second, err := users.Key("42")
+	must.NoError(t, err)
+	must.NotEq(t, first, second)
+
+	_, err = Get[string](c, second)
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	// a lost generation is replaced with a new one rather than reset
+	generation, err := users.Generation()
+	must.NoError(t, err)
+	must.NoError(t, Delete(c, "memc:ns:users"))
+
+	replaced, err := users.Generation()
+	must.NoError(t, err)
+	must.Greater(t, generation, replaced)
+
+	// invalidating a lost generation is not an error
+	must.NoError(t, NewNamespace(c, "empty").Invalidate())
+}
+
+func TestE2E_Namespace_encrypted(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := New([]string{address}, SetEncryptionKey(key), SetCompression(1))
+	defer ignore.Close(c)
+
+	users := NewNamespace(c, "users")
+
+	first, err := users.Key("42")
+	must.NoError(t, err)
+
+	// the generation is stored raw, such that memcached can increment it
+	must.NoError(t, users.Invalidate())
+
+	second, err := users.Key("42")
+	must.NoError(t, err)
+	must.NotEq(t, first, second)
+}
+
+func TestE2E_TaggedKey(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	orders, err := TaggedKey(c, "orders:42", []string{"user:42", "orders"})
+	must.NoError(t, err)
+	must.NoError(t, Set(c, orders, "order list"))
+
+	profile, err := TaggedKey(c, "profile:42", []string{"user:42"})
+	must.NoError(t, err)
+	must.NoError(t, Set(c, profile, "profile"))
+
+	other, err := TaggedKey(c, "profile:7", []string{"user:7"})
+	must.NoError(t, err)
+	must.NoError(t, Set(c, other, "other profile"))
+
+	// keys are stable while their tags are unchanged
+	again, err := TaggedKey(c, "orders:42", []string{"user:42", "orders"})
+	must.NoError(t, err)
+	must.Eq(t, orders, again)
+
+	// invalidating a tag changes the key of every value with the tag
+	must.NoError(t, InvalidateTag(c, "user:42"))
+
+	for key, tags := range map[string][]string{
+		"orders:42":  {"user:42", "orders"},
+		"profile:42": {"user:42"},
+	} {
+		derived, derr := TaggedKey(c, key, tags)
+		must.NoError(t, derr)
+		_, err = Get[string](c, derived)
+		must.ErrorIs(t, err, ErrCacheMiss)
+	}
+
+	// values without the tag are unaffected
+	derived, err := TaggedKey(c, "profile:7", []string{"user:7"})
+	must.NoError(t, err)
+	must.Eq(t, other, derived)
+
+	v, err := Get[string](c, derived)
+	must.NoError(t, err)
+	must.Eq(t, "other profile", v)
+
+	// invalidating a tag never used is not an error
+	must.NoError(t, InvalidateTag(c, "unused"))
+}
+
+func TestE2E_TaggedKey_encrypted(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := New([]string{address}, SetEncryptionKey(key), SetCompression(1))
+	defer ignore.Close(c)
+
+	first, err := TaggedKey(c, "profile:42", []string{"user:42"})
+	must.NoError(t, err)
+
+	// the version of the tag is stored raw, such that memcached can increment it
+	must.NoError(t, InvalidateTag(c, "user:42"))
+
+	second, err := TaggedKey(c, "profile:42", []string{"user:42"})
+	must.NoError(t, err)
+	must.NotEq(t, first, second)
+}
+
+func TestE2E_LocalCache(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetLocalCache(100, time.Hour))
+	defer ignore.Close(c)
+
+	other := New([]string{address})
+	defer ignore.Close(other)
+
+	must.NoError(t, Set(c, "local1", "first"))
+
+	v, err := Get[string](c, "local1")
+	must.NoError(t, err)
+	must.Eq(t, "first", v)
+
+	// changes by another client are not seen until the local value expires
+	must.NoError(t, Set(other, "local1", "second"))
+	v, err = Get[string](c, "local1")
+	must.NoError(t, err)
+	must.Eq(t, "first", v)
+
+	// changes by the client remove the local value
+	must.NoError(t, Set(c, "local1", "third"))
+	v, err = Get[string](c, "local1")
+	must.NoError(t, err)
+	must.Eq(t, "third", v)
+
+	must.NoError(t, Delete(c, "local1"))
+	_, err = Get[string](c, "local1")
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	stats := c.CacheStats()
+	must.Eq(t, TierStats{Hits: 1, Misses: 3}, stats.Local)
+	must.Eq(t, TierStats{Hits: 2, Misses: 1}, stats.Remote)
+}
+
+func TestE2E_SetMiss(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetLocalCache(10, time.Minute))
+	defer ignore.Close(c)
+
+	must.NoError(t, SetMiss(c, "user:404", 5*time.Second))
+
+	_, err := Get[string](c, "user:404")
+	must.ErrorIs(t, err, ErrKnownMiss)
+
+	// known misses are also read from the local cache
+	_, err = Get[string](c, "user:404")
+	must.ErrorIs(t, err, ErrKnownMiss)
+
+	_, _, err = Gets[string](c, "user:404")
+	must.ErrorIs(t, err, ErrKnownMiss)
+
+	results := GetMulti[string](c, []string{"user:404", "user:405"})
+	must.ErrorIs(t, results[0].B, ErrKnownMiss)
+	must.ErrorIs(t, results[1].B, ErrCacheMiss)
+
+	loads := 0
+	_, err = Fetch(c, "user:404", time.Hour, func(context.Context) (string, error) {
+		loads++
+		return "created", nil
+	})
+	must.ErrorIs(t, err, ErrKnownMiss)
+	must.Eq(t, 0, loads)
+
+	// reading a known miss leaves the connection usable
+	plain := New([]string{address})
+	defer ignore.Close(plain)
+
+	for range 3 {
+		_, err = Get[string](plain, "user:404")
+		must.ErrorIs(t, err, ErrKnownMiss)
+		must.False(t, errors.As(err, new(*ServerError)))
+	}
+	stats := plain.PoolStats()[address]
+	must.Eq(t, 1, stats.Dials)
+	must.Zero(t, stats.Discarded)
+	must.MapEmpty(t, plain.ServerErrors())
+
+	// storing a value replaces the known miss
+	must.NoError(t, Set(c, "user:404", "created"))
+	v, err := Get[string](c, "user:404")
+	must.NoError(t, err)
+	must.Eq(t, "created", v)
+
+	compat := New([]string{address}, SetGomemcacheCompat(true))
+	defer ignore.Close(compat)
+	must.Error(t, SetMiss(compat, "user:404", 5*time.Second))
+}
+
+func TestE2E_KeyPrefix(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	raw := New([]string{address})
+	defer ignore.Close(raw)
+
+	first := New([]string{address}, SetKeyPrefix("first:"), SetMaxItemSize(1024))
+	defer ignore.Close(first)
+
+	second := New([]string{address}, SetKeyPrefix("second:"))
+	defer ignore.Close(second)
+
+	t.Run("isolated", func(t *testing.T) {
+		must.NoError(t, Set(first, "key", "one"))
+		must.NoError(t, Set(second, "key", "two"))
+
+		v, err := Get[string](first, "key")
+		must.NoError(t, err)
+		must.Eq(t, "one", v)
+
+		v, err = Get[string](second, "key")
+		must.NoError(t, err)
+		must.Eq(t, "two", v)
+
+		v, err = Get[string](raw, "first:key")
+		must.NoError(t, err)
+		must.Eq(t, "one", v)
+
+		_, err = Get[string](raw, "key")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("multi", func(t *testing.T) {
+		must.NoError(t, SetMulti(first, []*Pair[string, string]{
+			{A: "m1", B: "v1"},
+			{A: "m2", B: "v2"},
+		}))
+
+		values, err := GetMultiMap[string](first, []string{"m1", "m2", "m3"})
+		must.NoError(t, err)
+		must.Eq(t, map[string]string{"m1": "v1", "m2": "v2"}, values)
+
+		found := make(map[string]string)
+		for key, result := range GetEach[string](first, []string{"m1", "m2"}) {
+			must.NoError(t, result.B)
+			found[key] = result.A
+		}
+		must.Eq(t, map[string]string{"m1": "v1", "m2": "v2"}, found)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		large := strings.Repeat("large value ", 1000)
+		must.NoError(t, Set(first, "large", large))
+
+		v, err := Get[string](first, "large")
+		must.NoError(t, err)
+		must.Eq(t, large, v)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		err := Set(first, strings.Repeat("a", 250), "value")
+		must.ErrorIs(t, err, ErrKeyNotValid)
+	})
+}
+
+func TestE2E_GomemcacheCompat(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetGomemcacheCompat(true), SetMaxItemSize(10))
+	defer ignore.Close(c)
+
+	flags := chunkedFlag | encryptedFlag | 42
+	err := Set(c, "legacy", []byte("a value set by gomemcache"), Flags(flags))
+	must.NoError(t, err)
+
+	// the flags are stored as is, and the value is not chunked
+	conn, err := c.getConn(t.Context(), "legacy")
+	must.NoError(t, err)
+	payload, h, err := c.fetch(conn, "legacy", false)
+	must.NoError(t, err)
+	c.setConn("legacy", conn)
+	must.Eq(t, flags, h.flags)
+	must.Eq(t, "a value set by gomemcache", string(*payload))
+
+	v, err := Get[string](c, "legacy")
+	must.NoError(t, err)
+	must.Eq(t, "a value set by gomemcache", v)
+
+	_, err = Get[string](c, "tab\tkey")
+	must.ErrorIs(t, err, ErrKeyNotValid)
+}
+
+func TestE2E_Compression(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetCompression(128))
+	defer ignore.Close(c)
+
+	fragment := strings.Repeat("<div>hello</div>", 64)
+
+	err := Set(c, "fragment", fragment)
+	must.NoError(t, err)
+
+	v, err := Get[string](c, "fragment")
+	must.NoError(t, err)
+	must.Eq(t, fragment, v)
+
+	// a client without compression enabled can still read the value
+	plain := New([]string{address})
+	defer ignore.Close(plain)
+
+	v, err = Get[string](plain, "fragment")
+	must.NoError(t, err)
+	must.Eq(t, fragment, v)
+
+	results := GetMulti[string](c, []string{"fragment"})
+	must.NoError(t, results[0].B)
+	must.Eq(t, fragment, results[0].A)
+}
+
+func TestE2E_encodingFlags(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	t.Run("counter", func(t *testing.T) {
+		err := Set(c, "counter", "41")
+		must.NoError(t, err)
+
+		_, err = Increment(c, "counter", 1)
+		must.NoError(t, err)
+
+		v, err := Get[int](c, "counter")
+		must.NoError(t, err)
+		must.Eq(t, 42, v)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		err := Set(c, "name", "bob")
+		must.NoError(t, err)
+
+		_, err = Get[int](c, "name")
+		must.ErrorIs(t, err, ErrEncoding)
+	})
+
+	t.Run("user flags", func(t *testing.T) {
+		err := Set(c, "flagged", 7, Flags(3))
+		must.NoError(t, err)
+
+		v, err := Get[int](c, "flagged")
+		must.NoError(t, err)
+		must.Eq(t, 7, v)
+	})
+}
+
+func TestE2E_TLS(t *testing.T) {
+	t.Parallel()
+
+	address, config, done := memctest.LaunchTLS(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetConnWrapper(func(conn net.Conn) net.Conn {
+		return tls.Client(conn, config)
+	}))
+	defer ignore.Close(c)
+
+	must.NoError(t, Set(c, "secure", "value"))
+
+	v, err := Get[string](c, "secure")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+}
+
+func TestE2E_Auth(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchAuth(t, nil, "user", "secret")
+	t.Cleanup(done)
+
+	command := func(conn net.Conn, cmd string) string {
+		_, err := io.WriteString(conn, cmd)
+		must.NoError(t, err)
+		line := make([]byte, 128)
+		n, err := conn.Read(line)
+		must.NoError(t, err)
+		return string(line[:n])
+	}
+
+	conn, err := net.Dial("tcp", address)
+	must.NoError(t, err)
+	defer ignore.Close(conn)
+
+	must.StrHasPrefix(t, "CLIENT_ERROR", command(conn, "get key\r\n"))
+	must.Eq(t, "STORED\r\n", command(conn, "set auth 0 0 11\r\nuser secret\r\n"))
+	must.Eq(t, "END\r\n", command(conn, "get key\r\n"))
+}
+
+func TestE2E_SASL(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchSASL(t, nil, "user", "secret")
+	t.Cleanup(done)
+
+	// the text protocol is refused once SASL authentication is enabled
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	must.Error(t, Set(c, "key", "value"))
+}
+
+func TestE2E_Meta(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetProtocol(Meta))
+	defer ignore.Close(c)
+
+	t.Run("set get", func(t *testing.T) {
+		err := Set(c, "key1", "value1")
+		must.NoError(t, err)
+
+		v, verr := Get[string](c, "key1")
+		must.NoError(t, verr)
+		must.Eq(t, "value1", v)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		_, err := Get[string](c, "missing")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("add replace", func(t *testing.T) {
+		err := Replace(c, "key2", "value2")
+		must.ErrorIs(t, err, ErrNotStored)
+
+		err = Add(c, "key2", "value2")
+		must.NoError(t, err)
+
+		err = Add(c, "key2", "value2")
+		must.ErrorIs(t, err, ErrNotStored)
+
+		err = Replace(c, "key2", "replaced")
+		must.NoError(t, err)
+
+		v, verr := Get[string](c, "key2")
+		must.NoError(t, verr)
+		must.Eq(t, "replaced", v)
+	})
+
+	t.Run("append prepend", func(t *testing.T) {
+		err := Set(c, "key3", "middle")
+		must.NoError(t, err)
+
+		err = Append(c, "key3", ".end")
+		must.NoError(t, err)
+
+		err = Prepend(c, "key3", "start.")
+		must.NoError(t, err)
+
+		v, verr := Get[string](c, "key3")
+		must.NoError(t, verr)
+		must.Eq(t, "start.middle.end", v)
+	})
+
+	t.Run("cas", func(t *testing.T) {
+		err := Set(c, "key4", "original")
+		must.NoError(t, err)
+
+		_, cas, gerr := Gets[string](c, "key4")
+		must.NoError(t, gerr)
+
+		err = CompareAndSwap(c, "key4", cas, "updated")
+		must.NoError(t, err)
+
+		err = CompareAndSwap(c, "key4", cas, "stale")
+		must.ErrorIs(t, err, ErrConflict)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := Set(c, "key5", "value5")
+		must.NoError(t, err)
+
+		err = Delete(c, "key5")
+		must.NoError(t, err)
+
+		err = Delete(c, "key5")
+		must.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		err := Touch(c, "missing", time.Hour)
+		must.ErrorIs(t, err, ErrNotFound)
+
+		err = Set(c, "key6", "value6")
+		must.NoError(t, err)
+
+		err = Touch(c, "key6", time.Hour)
+		must.NoError(t, err)
+	})
+
+	t.Run("counters", func(t *testing.T) {
+		_, err := Increment(c, "missing", 1)
+		must.ErrorIs(t, err, ErrNotFound)
+
+		err = Set(c, "counter", "100")
+		must.NoError(t, err)
+
+		v, ierr := Increment(c, "counter", 5)
+		must.NoError(t, ierr)
+		must.Eq(t, 105, v)
+
+		v, ierr = Decrement(c, "counter", 10)
+		must.NoError(t, ierr)
+		must.Eq(t, 95, v)
+
+		err = Set(c, "text", "abc")
+		must.NoError(t, err)
+
+		_, err = Increment(c, "text", 1)
+		must.ErrorIs(t, err, ErrNonNumeric)
+	})
+}
+
+func TestE2E_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	t.Run("drain", func(t *testing.T) {
+		c := New([]string{address})
+		must.NoError(t, Set(c, "key", "value"))
+
+		must.NoError(t, c.Shutdown(t.Context()))
+
+		err := Set(c, "key", "value")
+		must.ErrorIs(t, err, iopool.ErrClientClosed)
+	})
+
+	t.Run("interrupt", func(t *testing.T) {
+		proxy := memctest.LaunchProxy(t, address)
+		c := New([]string{proxy.Address()})
+		must.NoError(t, Set(c, "key", "value"))
+
+		// the request is never answered, until interrupted by the shutdown
+		proxy.Blackhole(true)
+		result := make(chan error, 1)
+		go func() {
+			_, err := Get[string](c, "key")
+			result <- err
+		}()
+
+		for c.pools.Stats()[proxy.Address()].InUse == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+
+		err := c.Shutdown(ctx)
+		must.ErrorIs(t, err, context.DeadlineExceeded)
+		must.Error(t, <-result)
+	})
+}
+
+func TestE2E_Multiplexing(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetMultiplexing(true))
+	defer ignore.Close(c)
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Go(func() {
+			for j := range 25 {
+				key := fmt.Sprintf("mux/%d/%d", i, j)
+				must.NoError(t, Set(c, key, j))
+
+				v, err := Get[int](c, key)
+				must.NoError(t, err)
+				must.Eq(t, j, v)
+
+				_, err = Get[int](c, key+"/missing")
+				must.ErrorIs(t, err, ErrCacheMiss)
+			}
+		})
+	}
+	wg.Wait()
+
+	results := GetMulti[int](c, []string{"mux/0/1", "mux/1/2", "mux/2/3"})
+	for i, result := range results {
+		must.NoError(t, result.B)
+		must.Eq(t, i+1, result.A)
+	}
+
+	stats := c.PoolStats()[address]
+	must.Eq(t, 1, stats.Dials)
+	must.Eq(t, 0, stats.Discarded)
+}
+
+func TestE2E_GetInto(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetCompression(10))
+	defer ignore.Close(c)
+
+	blob := []byte(strings.Repeat("blob", 4096))
+	err := Set(c, "plain", blob[:10], Flags(7))
+	must.NoError(t, err)
+
+	err = Set(c, "compressed", blob)
+	must.NoError(t, err)
+
+	dst := make([]byte, len(blob))
+	n, flags, err := GetInto(c, "plain", dst)
+	must.NoError(t, err)
+	must.Eq(t, blob[:10], dst[:n])
+	must.Eq(t, 7, flags)
+
+	n, _, err = GetInto(c, "compressed", dst)
+	must.NoError(t, err)
+	must.Eq(t, blob, dst[:n])
+
+	n, _, err = GetInto(c, "compressed", dst[:100])
+	must.ErrorIs(t, err, io.ErrShortBuffer)
+	must.Eq(t, len(blob), n)
+
+	_, _, err = GetInto(c, "missing", dst)
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_GetView(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetPayloadReuse(true), SetIdleConnections(1))
+	defer ignore.Close(c)
+
+	blob := []byte(strings.Repeat("blob", 4096))
+	must.NoError(t, Set(c, "plain", blob))
+	must.NoError(t, Set(c, "other", []byte("other")))
+
+	view, err := GetView(c, "plain")
+	must.NoError(t, err)
+	must.NotNil(t, view.buffer)
+
+	// the view is not the scratch buffer of the connection, which is reused
+	other, err := Get[[]byte](c, "other")
+	must.NoError(t, err)
+	must.Eq(t, []byte("other"), other)
+	must.Eq(t, blob, view.Bytes())
+
+	view.Release()
+	view.Release()
+	must.Nil(t, view.Bytes())
+
+	// compressed and chunked values are viewed as copies
+	compressed := New([]string{address}, SetCompression(10))
+	defer ignore.Close(compressed)
+	must.NoError(t, Set(compressed, "compressed", blob))
+
+	chunked := New([]string{address}, SetMaxItemSize(1000))
+	defer ignore.Close(chunked)
+	must.NoError(t, Set(chunked, "chunked", blob))
+
+	for key, client := range map[string]*Client{"compressed": compressed, "chunked": chunked} {
+		view, err = GetView(client, key)
+		must.NoError(t, err)
+		must.Nil(t, view.buffer)
+		must.Eq(t, blob, view.Bytes())
+		view.Release()
+	}
+
+	_, err = GetView(c, "missing")
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_PayloadReuse(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetPayloadReuse(true), SetIdleConnections(1))
+	defer ignore.Close(c)
+
+	type person struct {
+		Name string
+		Age  int
+	}
+
+	items := make([]*Pair[string, *person], 0, 10)
+	for i := range 10 {
+		items = append(items, &Pair[string, *person]{
+			A: fmt.Sprintf("person/%d", i),
+			B: &person{Name: strings.Repeat("n", i*100), Age: i},
+		})
+	}
+	err := SetMulti(c, items)
+	must.NoError(t, err)
+
+	for i, item := range items {
+		v, err := Get[*person](c, item.A)
+		must.NoError(t, err)
+		must.Eq(t, i, v.Age)
+		must.Eq(t, item.B.Name, v.Name)
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.A)
+	}
+	results := GetMulti[*person](c, keys)
+	for i, result := range results {
+		must.NoError(t, result.B)
+		must.Eq(t, i, result.A.Age)
+	}
+}
+
+func TestE2E_HashLongKeys(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetKeyPrefix("app:"), SetHashLongKeys(true))
+	defer ignore.Close(c)
+
+	long := "https://example.com/" + strings.Repeat("segment/", 40)
+	err := Set(c, long, "page")
+	must.NoError(t, err)
+
+	v, err := Get[string](c, long)
+	must.NoError(t, err)
+	must.Eq(t, "page", v)
+
+	// keys are yielded as given, rather than hashed
+	for key, result := range GetEach[string](c, []string{long, "short"}) {
+		switch key {
+		case long:
+			must.NoError(t, result.B)
+			must.Eq(t, "page", result.A)
+		case "short":
+			must.ErrorIs(t, result.B, ErrCacheMiss)
+		default:
+			t.Fatalf("unexpected key %q", key)
+		}
+	}
+
+	plain := New([]string{address})
+	defer ignore.Close(plain)
+
+	err = Set(plain, long, "page")
+	must.ErrorIs(t, err, ErrKeyNotValid)
+}
+
+func TestE2E_KeyEncoding(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	for _, encoding := range []KeyEncoding{PercentKeys, Base64Keys} {
+		c := New([]string{address}, SetKeyEncoding(encoding), SetKeyPrefix(fmt.Sprintf("%d:", encoding)))
+		defer ignore.Close(c)
+
+		keys := []string{"user name", "line\r\nbreak", "100%", "\x00binary\xff"}
+		for i, key := range keys {
+			err := Set(c, key, i)
+			must.NoError(t, err)
+
+			v, err := Get[int](c, key)
+			must.NoError(t, err)
+			must.Eq(t, i, v)
+		}
+
+		// keys are yielded as given, rather than encoded
+		seen := make(map[string]int)
+		for key, result := range GetEach[int](c, keys) {
+			must.NoError(t, result.B)
+			seen[key] = result.A
+		}
+		must.MapLen(t, len(keys), seen)
+		for i, key := range keys {
+			must.Eq(t, i, seen[key])
+		}
+
+		err := Delete(c, "user name")
+		must.NoError(t, err)
+	}
+}
+
+func TestE2E_TTLAt(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	err := Set(c, "at", "value", TTLAt(time.Now().Add(time.Hour)))
+	must.NoError(t, err)
+
+	v, err := Get[string](c, "at")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+
+	err = Set(c, "past", "value", TTLAt(time.Now().Add(-time.Minute)))
+	must.ErrorIs(t, err, ErrExpiration)
+}
+
+func TestE2E_Methods(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	// services depend on the interface rather than on the Client
+	var cache Interface = c
+
+	err := cache.SetString("string", "value")
+	must.NoError(t, err)
+
+	s, err := cache.GetString("string")
+	must.NoError(t, err)
+	must.Eq(t, "value", s)
+
+	err = cache.AddBytes("bytes", []byte{1, 2, 3})
+	must.NoError(t, err)
+
+	err = cache.AddBytes("bytes", []byte{4})
+	must.ErrorIs(t, err, ErrNotStored)
+
+	b, err := cache.GetBytes("bytes")
+	must.NoError(t, err)
+	must.Eq(t, []byte{1, 2, 3}, b)
+
+	type profile struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	err = cache.SetJSON("json", &profile{Name: "bob", Age: 42}, TTL(time.Minute))
+	must.NoError(t, err)
+
+	var p profile
+	err = cache.GetJSON("json", &p)
+	must.NoError(t, err)
+	must.Eq(t, profile{Name: "bob", Age: 42}, p)
+
+	raw, err := cache.GetBytes("json")
+	must.NoError(t, err)
+	must.Eq(t, `{"name":"bob","age":42}`, string(raw))
+
+	err = cache.GetJSON("string", &p)
+	must.ErrorIs(t, err, ErrEncoding)
+
+	err = cache.SetString("counter", "10")
+	must.NoError(t, err)
+
+	n, err := cache.Increment("counter", 5)
+	must.NoError(t, err)
+	must.Eq(t, 15, n)
+
+	n, err = cache.Decrement("counter", 3)
+	must.NoError(t, err)
+	must.Eq(t, 12, n)
+
+	err = cache.Touch("counter", time.Hour)
+	must.NoError(t, err)
+
+	err = cache.Delete("counter")
+	must.NoError(t, err)
+
+	_, err = cache.GetString("counter")
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_Async(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetMultiplexing(true))
+	defer ignore.Close(c)
+
+	stores := make([]*Future[struct{}], 0, 10)
+	for i := range 10 {
+		stores = append(stores, SetAsync(c, fmt.Sprintf("async/%d", i), i))
+	}
+	for _, f := range stores {
+		_, err := f.Wait()
+		must.NoError(t, err)
+	}
+
+	reads := make([]*Future[int], 0, 10)
+	for i := range 10 {
+		reads = append(reads, GetAsync[int](c, fmt.Sprintf("async/%d", i)))
+	}
+	for i, f := range reads {
+		v, err := f.Wait()
+		must.NoError(t, err)
+		must.Eq(t, i, v)
+	}
+
+	_, err := GetAsync[int](c, "async/missing").Wait()
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_With(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address}, SetKeyPrefix("app:"))
+	defer ignore.Close(c)
+
+	sessions := c.With(SetKeyPrefix("sessions:"), SetDefaultTTL(time.Minute))
+
+	err := Set(c, "id", "app")
+	must.NoError(t, err)
+
+	err = Set(sessions, "id", "session")
+	must.NoError(t, err)
+
+	v, err := Get[string](c, "id")
+	must.NoError(t, err)
+	must.Eq(t, "app", v)
+
+	v, err = Get[string](sessions, "id")
+	must.NoError(t, err)
+	must.Eq(t, "session", v)
+
+	// the clone uses the connections of c rather than opening its own
+	stats := c.PoolStats()
+	must.Eq(t, stats, sessions.PoolStats())
+	must.Eq(t, 1, stats[address].Open)
+}
+
+func TestE2E_Server(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	// the same key is stored on each instance, bypassing hashing
+	err := Set(c, "pinned", "one", Server(address1))
+	must.NoError(t, err)
+
+	err = Set(c, "pinned", "two", Server(address2))
+	must.NoError(t, err)
+
+	v, err := Get[string](c, "pinned", Server(address1))
+	must.NoError(t, err)
+	must.Eq(t, "one", v)
+
+	v, err = Get[string](c, "pinned", Server(address2))
+	must.NoError(t, err)
+	must.Eq(t, "two", v)
+
+	err = Delete(c, "pinned", Server(address1))
+	must.NoError(t, err)
+
+	_, err = Get[string](c, "pinned", Server(address1))
+	must.ErrorIs(t, err, ErrCacheMiss)
+
+	// every key of a multi verb is sent to the pinned instance
+	err = SetMulti(c, []*Pair[string, int]{{A: "a", B: 1}, {A: "b", B: 2}, {A: "c", B: 3}}, Server(address2))
+	must.NoError(t, err)
+
+	stats, err := Stats(c, Server(address2))
+	must.NoError(t, err)
+	must.Eq(t, 4, stats.Items.Current)
+
+	stats, err = Stats(c, Server(address1))
+	must.NoError(t, err)
+	must.Eq(t, 0, stats.Items.Current)
+
+	_, err = Get[string](c, "pinned", Server("127.0.0.1:1"))
+	must.ErrorIs(t, err, iopool.ErrUnknownInstance)
+
+	// pinned verbs bypass the local cache
+	local := New([]string{address1, address2}, SetLocalCache(10, time.Minute))
+	defer ignore.Close(local)
+
+	err = Set(local, "cached", "one", Server(address1))
+	must.NoError(t, err)
+
+	v, err = Get[string](local, "cached", Server(address1))
+	must.NoError(t, err)
+	must.Eq(t, "one", v)
+
+	_, err = Get[string](local, "cached", Server(address2))
+	must.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestE2E_DefaultFlags(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	const schema = 3
+
+	c := New([]string{address}, SetDefaultFlags(schema))
+	defer ignore.Close(c)
+
+	err := Set(c, "versioned", "value")
+	must.NoError(t, err)
+
+	v, flags, err := GetWithFlags[string](c, "versioned")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+	must.Eq(t, schema, flags)
+
+	err = SetMulti(c, []*Pair[string, int]{{A: "multi", B: 1}})
+	must.NoError(t, err)
+
+	_, flags, err = GetWithFlags[int](c, "multi")
+	must.NoError(t, err)
+	must.Eq(t, schema, flags)
+
+	// the Flags option overrides the default
+	err = Set(c, "overridden", "value", Flags(schema+1))
+	must.NoError(t, err)
+
+	_, flags, err = GetWithFlags[string](c, "overridden")
+	must.NoError(t, err)
+	must.Eq(t, schema+1, flags)
+}
+
+func TestE2E_DumpRestore(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	address3, done3 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done3)
+
+	c := New([]string{address1, address2}, SetKeyPrefix("app:"), SetMaxItemSize(1024))
+	defer ignore.Close(c)
+
+	for i := range 600 {
+		err := Set(c, fmt.Sprintf("key%d", i), i, TTL(time.Hour))
+		must.NoError(t, err)
+	}
+	err := Set(c, "forever", "value", TTL(0), Flags(7))
+	must.NoError(t, err)
+	large := strings.Repeat("large", 1000)
+	err = Set(c, "large", large)
+	must.NoError(t, err)
+
+	var snapshot bytes.Buffer
+	err = c.Dump(&snapshot)
+	must.NoError(t, err)
+	must.StrContains(t, snapshot.String(), "set app:forever ")
+
+	// values are restored to a cluster of a different instance
+	restored := New([]string{address3}, SetKeyPrefix("app:"), SetMaxItemSize(1024))
+	defer ignore.Close(restored)
+
+	err = restored.Restore(&snapshot)
+	must.NoError(t, err)
+
+	for i := range 600 {
+		v, err := Get[int](restored, fmt.Sprintf("key%d", i))
+		must.NoError(t, err)
+		must.Eq(t, i, v)
+	}
+
+	v, flags, err := GetWithFlags[string](restored, "forever")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+	must.Eq(t, 7, flags)
+
+	v, err = Get[string](restored, "large")
+	must.NoError(t, err)
+	must.Eq(t, large, v)
+
+	err = restored.Restore(strings.NewReader("set app:truncated 0 0 5\r\nval"))
+	must.ErrorIs(t, err, ErrDumpNotValid)
+}
+
+func TestE2E_Each(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	for i := range 10 {
+		err := Set(c, fmt.Sprintf("key%d", i), i)
+		must.NoError(t, err)
+	}
+
+	// flush every instance, without routing by key
+	var visited []string
+	err := c.Each(func(address string, conn *iopool.Buffer) error {
+		visited = append(visited, address)
+		if _, err := io.WriteString(conn, "flush_all\r\n"); err != nil {
+			return err
+		}
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+		line, err := conn.ReadSlice('\n')
+		if err != nil {
+			return err
+		}
+		if string(line) != "OK\r\n" {
+			return unexpected(line)
+		}
+		return nil
+	})
+	must.NoError(t, err)
+	must.SliceContainsAll(t, []string{address1, address2}, visited)
+
+	keys, err := c.Keys()
+	must.NoError(t, err)
+	must.SliceEmpty(t, keys)
+
+	// every instance is visited, even after one fails
+	failure := errors.New("failure")
+	visited = nil
+	err = c.Each(func(address string, conn *iopool.Buffer) error {
+		visited = append(visited, address)
+		if address == address1 {
+			return failure
+		}
+		return nil
+	})
+	must.ErrorIs(t, err, failure)
+	must.SliceLen(t, 2, visited)
+
+	var serr *ServerError
+	must.True(t, errors.As(err, &serr))
+	must.Eq(t, address1, serr.Addr)
+	must.Eq(t, "each", serr.Op)
+}
+
+func TestE2E_Ping(t *testing.T) {
+	t.Parallel()
+
+	address1, done1 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done1)
+
+	address2, done2 := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done2)
+
+	c := New([]string{address1, address2})
+	defer ignore.Close(c)
+
+	err := c.Ping(t.Context())
+	must.NoError(t, err)
+
+	must.Eq(t, map[string]error{address1: nil, address2: nil}, c.PingAll(t.Context()))
+
+	// an instance that is not listening fails the ping
+	down := "127.0.0.1:1"
+	partial := New([]string{address1, down})
+	defer ignore.Close(partial)
+
+	err = partial.Ping(t.Context())
+	var serr *ServerError
+	must.True(t, errors.As(err, &serr))
+	must.Eq(t, down, serr.Addr)
+	must.Eq(t, "ping", serr.Op)
+
+	results := partial.PingAll(t.Context())
+	must.MapLen(t, 2, results)
+	must.NoError(t, results[address1])
+	must.Error(t, results[down])
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err = c.Ping(ctx)
+	must.ErrorIs(t, err, context.Canceled)
+
+	empty := New(nil)
+	defer ignore.Close(empty)
+
+	err = empty.Ping(t.Context())
+	must.ErrorIs(t, err, ErrNoInstances)
+}
+
+func TestE2E_WaitUntilReady(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := New([]string{address})
+	defer ignore.Close(c)
+
+	err := c.WaitUntilReady(t.Context(), 10*time.Millisecond)
+	must.NoError(t, err)
+
+	// an instance that never comes up is waited on until ctx is done
+	down := "127.0.0.1:1"
+	partial := New([]string{address, down})
+	defer ignore.Close(partial)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	err = partial.WaitUntilReady(ctx, 10*time.Millisecond)
+	must.ErrorIs(t, err, context.DeadlineExceeded)
+	must.ErrorContains(t, err, down)
+
+	// a quorum of one is reached without the instance that is down
+	err = partial.WaitUntilQuorum(t.Context(), 10*time.Millisecond, 1)
+	must.NoError(t, err)
+
+	// closing the Client stops waiting
+	closing := New([]string{down})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = closing.Close()
+	}()
+
+	err = closing.WaitUntilReady(t.Context(), 10*time.Millisecond)
+	must.ErrorIs(t, err, ErrClientClosed)
+}
+
+func TestE2E_ServerErrors(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	down := "127.0.0.1:1"
+	c := New([]string{address, down})
+	defer ignore.Close(c)
+
+	err := c.Each(func(address string, conn *iopool.Buffer) error {
+		_, err := readVersion(conn)
+		return err
+	})
+	must.Error(t, err)
+
+	// a command that is not valid fails with an error response
+	err = c.Each(func(address string, conn *iopool.Buffer) error {
+		if _, err := io.WriteString(conn, "bogus\r\n"); err != nil {
+			return err
+		}
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+		line, err := conn.ReadSlice('\n')
+		if err != nil {
+			return err
+		}
+		return unexpected(line)
+	})
+	must.Error(t, err)
+
+	errs := c.ServerErrors()
+	must.Eq(t, ErrorCounts{Dials: 2}, errs[down])
+	must.Eq(t, ErrorCounts{Protocol: 1}, errs[address])
+}
@@ -17,62 +17,53 @@ type Countable interface {
 }
 
 func encode(item any) ([]byte, error) {
+	return encodeInto(new(bytes.Buffer), item)
+}
+
+// encodeInto appends the encoding of item to buf, returning the encoding which
+// references the memory of buf, or of item itself if item is a []byte
+func encodeInto(buf *bytes.Buffer, item any) ([]byte, error) {
 	switch v := item.(type) {
 	case []byte:
 		return v, nil
 	case string:
-		return []byte(v), nil
+		buf.WriteString(v)
 	case int8:
-		b := byte(v)
-		return []byte{b}, nil
+		buf.WriteByte(byte(v))
 	case uint8:
-		b := byte(v)
-		return []byte{b}, nil
+		buf.WriteByte(v)
 	case int16:
-		b := make([]byte, 2)
-		binary.LittleEndian.PutUint16(b, uint16(v))
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint16(buf.AvailableBuffer(), uint16(v)))
 	case uint16:
-		b := make([]byte, 2)
-		binary.LittleEndian.PutUint16(b, v)
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint16(buf.AvailableBuffer(), v))
 	case int32:
-		b := make([]byte, 4)
-		binary.LittleEndian.PutUint32(b, uint32(v))
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint32(buf.AvailableBuffer(), uint32(v)))
 	case uint32:
-		b := make([]byte, 4)
-		binary.LittleEndian.PutUint32(b, v)
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint32(buf.AvailableBuffer(), v))
 	case int64:
-		b := make([]byte, 8)
-		binary.LittleEndian.PutUint64(b, uint64(v))
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint64(buf.AvailableBuffer(), uint64(v)))
 	case uint64:
-		b := make([]byte, 8)
-		binary.LittleEndian.PutUint64(b, v)
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint64(buf.AvailableBuffer(), v))
 	case int:
-		b := make([]byte, 8)
-		binary.LittleEndian.PutUint64(b, uint64(v))
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint64(buf.AvailableBuffer(), uint64(v)))
 	case uint:
-		b := make([]byte, 8)
-		binary.LittleEndian.PutUint64(b, uint64(v))
-		return b, nil
+		buf.Write(binary.LittleEndian.AppendUint64(buf.AvailableBuffer(), uint64(v)))
 	default:
-		buf := new(bytes.Buffer)
 		enc := gob.NewEncoder(buf)
-		err := enc.Encode(item)
-		return buf.Bytes(), err
+		if err := enc.Encode(item); err != nil {
+			return nil, err
+		}
 	}
+	return buf.Bytes(), nil
 }
 
+// decode converts b into a value of type T; the result never references the
+// memory of b, which may be re-used after decode returns
 func decode[T any](b []byte) (T, error) {
 	var result T
 	switch any(result).(type) {
 	case []byte:
-		tmp := any(b).(T)
+		tmp := any(bytes.Clone(b)).(T)
 		return tmp, nil
 	case string:
 		s := string(b)
@@ -0,0 +1,91 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// encryptedFlag is set in the flags of a value that has been encrypted
+const encryptedFlag = 1 << 26
+
+// encrypted reports whether flags records the value as encrypted
+func encrypted(flags int) bool {
+	return flags&encryptedFlag != 0
+}
+
+// SetEncryptionKey enables encryption of values using AES-GCM with the given
+// key, which must be 16, 24, or 32 bytes to select AES-128, AES-192, or
+// AES-256. Values are encrypted after being encoded and compressed, and the
+// key of each value is authenticated along with it, so that an encrypted value
+// cannot be read under any other key.
+//
+// Encrypted values are recorded as such in their flags, and cannot be read by a
+// Client configured without the same encryption key. An invalid key causes
+// every verb that stores or reads a value to fail.
+//
+// Counters stored as a string, e.g. by using Set, are encrypted like any other
+// value, such that memcached can no longer Increment or Decrement them. Such
+// counters must be created by a Client without an encryption key.
+//
+// If unset the default is to store values unencrypted.
+func SetEncryptionKey(key []byte) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.aead, c.aeadErr = newAEAD(key)
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("memc: invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptValue encrypts b stored under key if encryption is enabled, returning
+// the nonce followed by the sealed value, along with the flags recording its
+// encryption
+func (c *Client) encryptValue(key string, b []byte, flags int) ([]byte, int, error) {
+	switch {
+	case c.aeadErr != nil:
+		return nil, 0, c.aeadErr
+	case c.aead == nil:
+		return b, flags, nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(b)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, 0, err
+	}
+
+	return c.aead.Seal(nonce, nonce, b, []byte(key)), flags | encryptedFlag, nil
+}
+
+// decryptValue decrypts b stored under key if flags records it as encrypted
+func (c *Client) decryptValue(key string, b []byte, flags int) ([]byte, error) {
+	switch {
+	case !encrypted(flags):
+		return b, nil
+	case c.aeadErr != nil:
+		return nil, c.aeadErr
+	case c.aead == nil:
+		return nil, fmt.Errorf("%w: value is encrypted but no encryption key is set", ErrEncoding)
+	case len(b) < c.aead.NonceSize():
+		return nil, fmt.Errorf("%w: encrypted value is truncated", ErrEncoding)
+	}
+
+	nonce, sealed := b[:c.aead.NonceSize()], b[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncoding, err)
+	}
+
+	return plain, nil
+}
@@ -0,0 +1,56 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestClient_encryption(t *testing.T) {
+	t.Parallel()
+
+	key := bytes.Repeat([]byte{7}, 32)
+	value := strings.Repeat("secret ", 50)
+
+	t.Run("round trip", func(t *testing.T) {
+		c := New(nil, SetEncryptionKey(key), SetCompression(64))
+		b, flags, err := c.pack("ssn", value, 5)
+		must.NoError(t, err)
+		must.True(t, encrypted(flags))
+		must.Eq(t, Gzip, flagsCompression(flags))
+		must.Eq(t, encodingRaw, flagsEncoding(flags))
+		must.Eq(t, 5, flags&userFlagsMask)
+		must.False(t, bytes.Contains(b, []byte("secret")))
+
+		v, err := unpack[string](c, "ssn", b, flags)
+		must.NoError(t, err)
+		must.Eq(t, value, v)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		c := New(nil, SetEncryptionKey(key))
+		b, flags, err := c.pack("ssn", value, 0)
+		must.NoError(t, err)
+
+		_, err = unpack[string](c, "other", b, flags)
+		must.ErrorIs(t, err, ErrEncoding)
+
+		other := New(nil, SetEncryptionKey(bytes.Repeat([]byte{8}, 32)))
+		_, err = unpack[string](other, "ssn", b, flags)
+		must.ErrorIs(t, err, ErrEncoding)
+
+		_, err = unpack[string](New(nil), "ssn", b, flags)
+		must.ErrorIs(t, err, ErrEncoding)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		c := New(nil, SetEncryptionKey([]byte("short")))
+		_, _, err := c.pack("ssn", value, 0)
+		must.ErrorContains(t, err, "invalid encryption key")
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// ErrorCounts is a snapshot of the number of operations on a memcached instance
+// that failed, by the kind of failure.
+//
+// Expected responses such as ErrCacheMiss, values that cannot be decoded, and
+// operations abandoned by the cancellation of their Context are not counted.
+type ErrorCounts struct {
+	// Dials is the number of operations that failed as a new connection could
+	// not be established.
+	Dials int64
+
+	// Timeouts is the number of operations that failed as their deadline was
+	// reached while waiting on the instance.
+	Timeouts int64
+
+	// Network is the number of operations that failed as the connection was
+	// closed or reset.
+	Network int64
+
+	// Protocol is the number of operations that failed as the instance gave a
+	// response that is not valid or is an error, such as ErrCommandIssue.
+	Protocol int64
+}
+
+// Total returns the total number of failed operations.
+func (e ErrorCounts) Total() int64 {
+	return e.Dials + e.Timeouts + e.Network + e.Protocol
+}
+
+// counters counts the failures of operations on a memcached instance
+type counters struct {
+	dials    atomic.Int64
+	timeouts atomic.Int64
+	network  atomic.Int64
+	protocol atomic.Int64
+}
+
+func (n *counters) snapshot() ErrorCounts {
+	return ErrorCounts{
+		Dials:    n.dials.Load(),
+		Timeouts: n.timeouts.Load(),
+		Network:  n.network.Load(),
+		Protocol: n.protocol.Load(),
+	}
+}
+
+// errorCounts tracks the counters of failures for each memcached instance
+type errorCounts struct {
+	counters sync.Map // address -> *counters
+}
+
+func (e *errorCounts) of(address string) *counters {
+	n, ok := e.counters.Load(address)
+	if !ok {
+		n, _ = e.counters.LoadOrStore(address, new(counters))
+	}
+	return n.(*counters)
+}
+
+// dialed counts err, if any, as a failure to establish a connection to the
+// memcached instance of address, unless no connection was attempted
+func (e *errorCounts) dialed(address string, err error) {
+	switch {
+	case !counted(err):
+		return
+	case errors.Is(err, ErrPoolExhausted), errors.Is(err, ErrNoInstances):
+		return
+	case errors.Is(err, iopool.ErrUnknownInstance), errors.Is(err, iopool.ErrClientClosed):
+		return
+	}
+	e.of(address).dials.Add(1)
+}
+
+// observe counts err, if any, as the failure of an operation on a connection to
+// the memcached instance of address
+func (e *errorCounts) observe(address string, err error) {
+	if !counted(err) {
+		return
+	}
+
+	n := e.of(address)
+	switch {
+	case timeout(err):
+		n.timeouts.Add(1)
+	case disconnected(err):
+		n.network.Add(1)
+	default:
+		n.protocol.Add(1)
+	}
+}
+
+// counted reports whether err is a failure attributable to a memcached instance
+func counted(err error) bool {
+	switch {
+	case err == nil, expected(err):
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	default:
+		return true
+	}
+}
+
+// timeout reports whether err is caused by a deadline being reached
+func timeout(err error) bool {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	default:
+		return false
+	}
+}
+
+// disconnected reports whether err is caused by the connection being closed or
+// reset rather than by a response
+func disconnected(err error) bool {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, net.ErrClosed):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return true
+	default:
+		return errors.As(err, &netErr)
+	}
+}
+
+// ServerErrors returns a snapshot of the number of failed operations on each
+// memcached instance the Client has communicated with, by the address of each
+// instance, e.g. to alert on or to stop using instances that are failing.
+// Counts are accumulated from the creation of the Client, and are shared with
+// its clones made by With.
+func (c *Client) ServerErrors() map[string]ErrorCounts {
+	result := make(map[string]ErrorCounts)
+	c.errorCounts.counters.Range(func(address, n any) bool {
+		result[address.(string)] = n.(*counters).snapshot()
+		return true
+	})
+	return result
+}
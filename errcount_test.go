@@ -0,0 +1,62 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"cattlecloud.net/go/memc/iopool"
+	"github.com/shoenig/test/must"
+)
+
+func Test_errorCounts(t *testing.T) {
+	t.Parallel()
+
+	var e errorCounts
+
+	e.dialed("a:11211", syscall.ECONNREFUSED)
+	e.dialed("a:11211", ErrPoolExhausted)
+	e.dialed("a:11211", iopool.ErrUnknownInstance)
+	e.dialed("a:11211", nil)
+
+	e.observe("a:11211", context.DeadlineExceeded)
+	e.observe("a:11211", os.ErrDeadlineExceeded)
+	e.observe("a:11211", io.ErrUnexpectedEOF)
+	e.observe("a:11211", fmt.Errorf("%w: SERVER_ERROR out of memory", ErrCommandIssue))
+	e.observe("a:11211", unexpected([]byte("BOGUS\r\n")))
+
+	// outcomes which are not failures of the instance
+	e.observe("a:11211", nil)
+	e.observe("a:11211", ErrCacheMiss)
+	e.observe("a:11211", context.Canceled)
+	e.observe("a:11211", fmt.Errorf("%w: malformed chunk manifest", ErrEncoding))
+
+	must.Eq(t, ErrorCounts{Dials: 1, Timeouts: 2, Network: 1, Protocol: 2}, e.of("a:11211").snapshot())
+	must.Eq(t, 6, e.of("a:11211").snapshot().Total())
+}
+
+func TestClient_ServerErrors(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.MapEmpty(t, c.ServerErrors())
+
+	c.errorCounts.observe("a:11211", io.EOF)
+	c.errorCounts.observe("b:11211", errors.New("unexpected response"))
+	c.errorCounts.observe("b:11211", ErrNotStored)
+
+	result := c.ServerErrors()
+	must.MapLen(t, 2, result)
+	must.Eq(t, ErrorCounts{Network: 1}, result["a:11211"])
+	must.Eq(t, ErrorCounts{Protocol: 1}, result["b:11211"])
+
+	// the counts are shared with clones
+	must.Eq(t, result, c.With().ServerErrors())
+}
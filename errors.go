@@ -0,0 +1,99 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// IsTemporary reports whether err is a temporary failure, such as a network,
+// dial, or timeout error, after which attempting the same operation again may
+// succeed.
+//
+// Errors that are a response from the memcached instance, such as ErrCacheMiss,
+// ErrNotStored, or ErrConflict, are not temporary, nor are errors caused by
+// invalid input or by the cancellation of a Context.
+func IsTemporary(err error) bool {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return true
+	case errors.Is(err, ErrPoolExhausted):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE):
+		return true
+	case errors.As(err, &netErr):
+		return true
+	default:
+		return false
+	}
+}
+
+// A ServerError is returned when an operation on a memcached instance fails,
+// identifying the instance and the operation. The underlying error is
+// available through errors.Is and errors.As.
+//
+// Responses that are an expected outcome of an operation, such as ErrCacheMiss
+// or ErrNotStored, are returned as they are rather than as a ServerError.
+type ServerError struct {
+	// Addr is the address of the memcached instance.
+	Addr string
+
+	// Op is the operation that failed, e.g. "get" or "set".
+	Op string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("memc: %s %s: %v", e.Op, e.Addr, e.Err)
+}
+
+func (e *ServerError) Unwrap() error {
+	return e.Err
+}
+
+// attribute returns err as a ServerError of the operation op on the memcached
+// instance of address, unless err is an expected response or caused by the
+// context of the operation
+func attribute(address, op string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case expected(err):
+		return err
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return &ServerError{Addr: address, Op: op, Err: err}
+	}
+}
+
+// expected reports whether err is a response from the memcached instance that
+// is an expected outcome of an operation, such as ErrCacheMiss, or a value that
+// cannot be decoded, either of which is read in full and so leaves the
+// connection usable
+func expected(err error) bool {
+	return errors.Is(err, ErrCacheMiss) ||
+		errors.Is(err, ErrKnownMiss) ||
+		errors.Is(err, ErrEncoding) ||
+		errors.Is(err, ErrNotStored) ||
+		errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrConflict) ||
+		errors.Is(err, ErrNonNumeric) ||
+		errors.Is(err, ErrSlabsReassign)
+}
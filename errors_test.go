@@ -0,0 +1,98 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestIsTemporary(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		exp  bool
+	}{
+		{name: "nil", err: nil, exp: false},
+		{name: "eof", err: io.EOF, exp: true},
+		{name: "reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), exp: true},
+		{name: "dial", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, exp: true},
+		{name: "timeout", err: os.ErrDeadlineExceeded, exp: true},
+		{name: "deadline", err: context.DeadlineExceeded, exp: true},
+		{name: "exhausted", err: ErrPoolExhausted, exp: true},
+		{name: "canceled", err: context.Canceled, exp: false},
+		{name: "miss", err: ErrCacheMiss, exp: false},
+		{name: "not stored", err: ErrNotStored, exp: false},
+		{name: "conflict", err: ErrConflict, exp: false},
+		{name: "key", err: ErrKeyNotValid, exp: false},
+		{name: "other", err: errors.New("oops"), exp: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			must.Eq(t, tc.exp, IsTemporary(tc.err))
+		})
+	}
+}
+
+func TestServerError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("attribute", func(t *testing.T) {
+		err := attribute("10.0.0.1:11211", "get", io.ErrUnexpectedEOF)
+
+		var serr *ServerError
+		must.True(t, errors.As(err, &serr))
+		must.Eq(t, "10.0.0.1:11211", serr.Addr)
+		must.Eq(t, "get", serr.Op)
+		must.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		must.True(t, IsTemporary(err))
+		must.EqError(t, err, "memc: get 10.0.0.1:11211: unexpected EOF")
+	})
+
+	t.Run("responses", func(t *testing.T) {
+		must.Nil(t, attribute("10.0.0.1:11211", "get", nil))
+		must.Eq(t, ErrCacheMiss, attribute("10.0.0.1:11211", "get", ErrCacheMiss))
+		must.Eq(t, ErrNotStored, attribute("10.0.0.1:11211", "add", ErrNotStored))
+		must.ErrorIs(t, attribute("10.0.0.1:11211", "slabs_reassign", fmt.Errorf("%w: BUSY", ErrSlabsReassign)), ErrSlabsReassign)
+		must.False(t, errors.As(attribute("10.0.0.1:11211", "slabs_reassign", fmt.Errorf("%w: BUSY", ErrSlabsReassign)), new(*ServerError)))
+		must.Eq(t, context.Canceled, attribute("10.0.0.1:11211", "get", context.Canceled))
+	})
+
+	t.Run("unexpected response", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		must.NoError(t, err)
+		t.Cleanup(func() { _ = ln.Close() })
+
+		go func() {
+			conn, aerr := ln.Accept()
+			if aerr != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+			_, _ = conn.Write([]byte("BOGUS\r\n"))
+			_, _ = io.Copy(io.Discard, conn)
+		}()
+
+		c := New([]string{ln.Addr().String()})
+		t.Cleanup(func() { _ = c.Close() })
+
+		err = Set(c, "key", "value")
+
+		var serr *ServerError
+		must.True(t, errors.As(err, &serr))
+		must.Eq(t, ln.Addr().String(), serr.Addr)
+		must.Eq(t, "set", serr.Op)
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// expvarLock serializes the lookup and creation of published expvar maps and
+// gauges
+var expvarLock sync.Mutex
+
+// ExpvarSink is a MetricsSink that publishes metrics using the expvar package,
+// so that they are served by the /debug/vars endpoint alongside other expvar
+// variables.
+//
+// Counters are published as integers, gauges as floats, and timings as the
+// cumulative number of seconds spent on each event, which together with the
+// corresponding ".calls" counter gives the mean duration of a command.
+type ExpvarSink struct {
+	vars *expvar.Map
+}
+
+// NewExpvarSink creates an ExpvarSink that publishes metrics under the expvar
+// map of the given name, e.g. "memc". Sinks created with the same name share
+// the same map, so that the metrics of several Clients are aggregated.
+//
+//	client := memc.New(
+//		instances,
+//		memc.SetMetricsSink(memc.NewExpvarSink("memc")),
+//	)
+func NewExpvarSink(name string) *ExpvarSink {
+	expvarLock.Lock()
+	defer expvarLock.Unlock()
+
+	if vars, ok := expvar.Get(name).(*expvar.Map); ok {
+		return &ExpvarSink{vars: vars}
+	}
+	return &ExpvarSink{vars: expvar.NewMap(name)}
+}
+
+// Count increments the counter of name by n.
+func (s *ExpvarSink) Count(name string, n int64) {
+	s.vars.Add(name, n)
+}
+
+// Gauge records the current value of name.
+func (s *ExpvarSink) Gauge(name string, value float64) {
+	if v, ok := s.vars.Get(name).(*expvar.Float); ok {
+		v.Set(value)
+		return
+	}
+
+	expvarLock.Lock()
+	defer expvarLock.Unlock()
+
+	// the gauge is published once, and set in place from then on
+	v, ok := s.vars.Get(name).(*expvar.Float)
+	if !ok {
+		v = new(expvar.Float)
+		s.vars.Set(name, v)
+	}
+	v.Set(value)
+}
+
+// Timing adds the elapsed duration of an event of name to the cumulative
+// number of seconds spent on events of name.
+func (s *ExpvarSink) Timing(name string, elapsed time.Duration) {
+	s.vars.AddFloat(name, elapsed.Seconds())
+}
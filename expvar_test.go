@@ -0,0 +1,40 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestExpvarSink(t *testing.T) {
+	t.Parallel()
+
+	sink := NewExpvarSink("memc_test_expvar")
+	sink.Count("memc.get.calls", 2)
+	sink.Count("memc.get.calls", 1)
+	sink.Gauge("memc.pool.idle", 4)
+	sink.Gauge("memc.pool.idle", 3)
+	sink.Timing("memc.get.duration", 1500*time.Millisecond)
+	sink.Timing("memc.get.duration", 500*time.Millisecond)
+
+	vars := expvar.Get("memc_test_expvar").(*expvar.Map)
+	must.Eq(t, "3", vars.Get("memc.get.calls").String())
+	must.Eq(t, "3", vars.Get("memc.pool.idle").String())
+	must.Eq(t, "2", vars.Get("memc.get.duration").String())
+
+	// gauges are set in place rather than published again
+	gauge := vars.Get("memc.pool.idle")
+	sink.Gauge("memc.pool.idle", 5)
+	must.True(t, gauge == vars.Get("memc.pool.idle"))
+	must.Eq(t, "5", gauge.String())
+
+	// sinks of the same name share the published map
+	other := NewExpvarSink("memc_test_expvar")
+	other.Count("memc.get.calls", 1)
+	must.Eq(t, "4", vars.Get("memc.get.calls").String())
+}
@@ -0,0 +1,85 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errFlightPanicked is returned to callers waiting on a flight whose function
+// panicked
+var errFlightPanicked = errors.New("memc: concurrent load panicked")
+
+// flight is an in progress call whose result is shared by every caller of the
+// same key
+type flight struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// flights deduplicates concurrent calls by key, such that only one call for a
+// key is in progress at a time and its result is shared by every caller
+type flights struct {
+	lock  sync.Mutex
+	calls map[string]*flight
+}
+
+// do calls f unless a call of f for key is already in progress, in which case
+// the result of that call is waited for and returned instead, unless ctx is
+// done first
+func (g *flights) do(ctx context.Context, key string, f func() (any, error)) (any, error) {
+	call, leader := g.join(key)
+	if leader {
+		g.run(key, call, f)
+		return call.value, call.err
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// start calls f in the background unless a call for key is already in
+// progress, without waiting for its result
+func (g *flights) start(key string, f func() (any, error)) {
+	if call, leader := g.join(key); leader {
+		go g.run(key, call, f)
+	}
+}
+
+// join returns the call in progress for key, or registers a new call for key
+// in which case the caller is the leader and must run it
+func (g *flights) join(key string) (*flight, bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if call, exists := g.calls[key]; exists {
+		return call, false
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	call := &flight{done: make(chan struct{}), err: errFlightPanicked}
+	g.calls[key] = call
+	return call, true
+}
+
+// run calls f and records its result on call, releasing every waiter
+func (g *flights) run(key string, call *flight, f func() (any, error)) {
+	defer func() {
+		g.lock.Lock()
+		delete(g.calls, key)
+		g.lock.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = f()
+}
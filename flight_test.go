@@ -0,0 +1,121 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_flights_do(t *testing.T) {
+	t.Parallel()
+
+	t.Run("shared", func(t *testing.T) {
+		var g flights
+		var calls atomic.Int64
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		results := make([]any, 10)
+		for i := range results {
+			wg.Go(func() {
+				v, err := g.do(t.Context(), "key", func() (any, error) {
+					calls.Add(1)
+					<-release
+					return "value", nil
+				})
+				must.NoError(t, err)
+				results[i] = v
+			})
+		}
+
+		// wait for every caller to join the flight
+		for {
+			g.lock.Lock()
+			started := g.calls["key"] != nil
+			g.lock.Unlock()
+			if started && calls.Load() == 1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		must.Eq(t, 1, calls.Load())
+		for _, v := range results {
+			must.Eq(t, "value", v)
+		}
+		must.MapEmpty(t, g.calls)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		var g flights
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		go func() {
+			_, _ = g.do(context.Background(), "key", func() (any, error) {
+				close(started)
+				<-release
+				return "value", nil
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err := g.do(ctx, "key", func() (any, error) {
+			return "other", nil
+		})
+		must.ErrorIs(t, err, context.Canceled)
+		close(release)
+	})
+
+	t.Run("start", func(t *testing.T) {
+		var g flights
+		release := make(chan struct{})
+		var calls atomic.Int64
+
+		f := func() (any, error) {
+			calls.Add(1)
+			<-release
+			return "value", nil
+		}
+		g.start("key", f)
+		g.start("key", f)
+		time.AfterFunc(10*time.Millisecond, func() { close(release) })
+
+		// a call in progress is waited for by callers of do
+		v, err := g.do(t.Context(), "key", func() (any, error) {
+			return "other", nil
+		})
+		must.NoError(t, err)
+		must.Eq(t, "value", v)
+		must.Eq(t, 1, calls.Load())
+	})
+
+	t.Run("panicked", func(t *testing.T) {
+		var g flights
+		func() {
+			defer func() { _ = recover() }()
+			_, _ = g.do(t.Context(), "key", func() (any, error) {
+				panic("boom")
+			})
+		}()
+
+		// the panicking call is forgotten
+		v, err := g.do(t.Context(), "key", func() (any, error) {
+			return "value", nil
+		})
+		must.NoError(t, err)
+		must.Eq(t, "value", v)
+	})
+}
@@ -0,0 +1,23 @@
+module cattlecloud.net/go/memc/grpccache
+
+go 1.26
+
+require (
+	cattlecloud.net/go/memc v0.0.0-00010101000000-000000000000
+	github.com/shoenig/ignore v0.4.0
+	github.com/shoenig/test v1.12.2
+	google.golang.org/grpc v1.84.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	cattlecloud.net/go/scope v1.2.1 // indirect
+	cattlecloud.net/go/stacks v1.1.2 // indirect
+	github.com/google/go-cmp v0.6.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+)
+
+replace cattlecloud.net/go/memc => ../
@@ -0,0 +1,125 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package grpccache provides a gRPC client interceptor that caches the
+// responses of idempotent unary methods in memcached, for read-heavy internal
+// APIs.
+//
+//	conn, err := grpc.NewClient(
+//		target,
+//		grpc.WithUnaryInterceptor(grpccache.UnaryClientInterceptor(
+//			client,
+//			grpccache.Method("/users.v1.Users/GetUser", time.Minute),
+//		)),
+//	)
+//
+// The package is a separate module, such that the root memc module does not
+// depend on google.golang.org/grpc.
+package grpccache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Option configures the interceptor created by UnaryClientInterceptor.
+type Option func(*config)
+
+type config struct {
+	ttls   map[string]time.Duration
+	prefix string
+}
+
+// Method enables caching the responses of the unary method of the given full
+// name, e.g. "/users.v1.Users/GetUser", for ttl. The method must be idempotent,
+// as a cached response is returned in place of invoking the method.
+//
+// Methods are not cached unless enabled by Method.
+func Method(name string, ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttls[name] = ttl
+	}
+}
+
+// Prefix sets the prefix of the keys responses are cached under, e.g. to keep
+// the responses of different deployments of a service apart.
+//
+// If unset the default prefix is "grpc:".
+func Prefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that caches the
+// responses of the methods enabled by Method using Client c. Responses are
+// cached under a key derived from the method and the deterministic protobuf
+// serialization of the request.
+//
+// Only successful responses are cached. Failures of memcached, such as an
+// unreachable instance, are not returned; the method is invoked as though the
+// response was not cached.
+func UnaryClientInterceptor(c *memc.Client, opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := &config{
+		ttls:   make(map[string]time.Duration),
+		prefix: "grpc:",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ttl, cached := cfg.ttls[method]
+		if !cached {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		request, rok := req.(proto.Message)
+		response, pok := reply.(proto.Message)
+		if !rok || !pok {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		key, err := cfg.key(method, request)
+		if err != nil {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+
+		if b, gerr := memc.Get[[]byte](c, key, memc.Context(ctx)); gerr == nil {
+			if proto.Unmarshal(b, response) == nil {
+				return nil
+			}
+			// a response that cannot be decoded is replaced below
+			proto.Reset(response)
+		}
+
+		if err = invoker(ctx, method, req, reply, cc, callOpts...); err != nil {
+			return err
+		}
+
+		if b, merr := proto.Marshal(response); merr == nil {
+			_ = memc.Set(c, key, b, memc.Context(ctx), memc.TTL(ttl))
+		}
+		return nil
+	}
+}
+
+// key returns the key the response to request of method is cached under
+func (c *config) key(method string, request proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(method))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(b)
+	return c.prefix + hex.EncodeToString(h.Sum(nil)), nil
+}
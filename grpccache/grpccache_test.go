@@ -0,0 +1,120 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package grpccache
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/ignore"
+	"github.com/shoenig/test/must"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// launch starts a gRPC server providing the health service, returning a
+// client of the service using the interceptor and the number of requests the
+// server has handled
+func launch(t *testing.T, interceptor grpc.UnaryClientInterceptor) (grpc_health_v1.HealthClient, *atomic.Int64) {
+	handled := new(atomic.Int64)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		handled.Add(1)
+		return handler(ctx, req)
+	}))
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(interceptor),
+	)
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn), handled
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	address, done := memctest.LaunchTCP(t, nil)
+	t.Cleanup(done)
+
+	c := memc.New([]string{address})
+	defer ignore.Close(c)
+
+	health, handled := launch(t, UnaryClientInterceptor(
+		c,
+		Method(grpc_health_v1.Health_Check_FullMethodName, time.Minute),
+		Prefix("test:"),
+	))
+
+	check := func(service string) *grpc_health_v1.HealthCheckResponse {
+		response, err := health.Check(t.Context(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		must.NoError(t, err)
+		return response
+	}
+
+	// the first request is invoked, and its response cached
+	response := check("")
+	must.Eq(t, grpc_health_v1.HealthCheckResponse_SERVING, response.GetStatus())
+	must.Eq(t, 1, handled.Load())
+
+	// the same request is served from the cache
+	response = check("")
+	must.Eq(t, grpc_health_v1.HealthCheckResponse_SERVING, response.GetStatus())
+	must.Eq(t, 1, handled.Load())
+
+	// a different request is invoked
+	_, err := health.Check(t.Context(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	must.Error(t, err)
+	must.Eq(t, 2, handled.Load())
+
+	// failed responses are not cached
+	_, err = health.Check(t.Context(), &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	must.Error(t, err)
+	must.Eq(t, 3, handled.Load())
+
+	// methods not enabled are not cached
+	_, err = health.List(t.Context(), &grpc_health_v1.HealthListRequest{})
+	must.NoError(t, err)
+	_, err = health.List(t.Context(), &grpc_health_v1.HealthListRequest{})
+	must.NoError(t, err)
+	must.Eq(t, 5, handled.Load())
+}
+
+func TestUnaryClientInterceptor_unavailable(t *testing.T) {
+	t.Parallel()
+
+	// a client of no instances fails every operation
+	c := memc.New(nil)
+	defer ignore.Close(c)
+
+	health, handled := launch(t, UnaryClientInterceptor(
+		c,
+		Method(grpc_health_v1.Health_Check_FullMethodName, time.Minute),
+	))
+
+	for range 2 {
+		response, err := health.Check(t.Context(), &grpc_health_v1.HealthCheckRequest{})
+		must.NoError(t, err)
+		must.Eq(t, grpc_health_v1.HealthCheckResponse_SERVING, response.GetStatus())
+	}
+	must.Eq(t, 2, handled.Load())
+}
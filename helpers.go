@@ -0,0 +1,258 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxSwapAttempts is the number of times GetSet will attempt to swap a value
+// before giving up due to concurrent modifications
+const maxSwapAttempts = 10
+
+// GetSet stores item using the given key, returning the value that was
+// previously associated with the key. The swap is performed using Gets and
+// CompareAndSwap, such that no concurrent modification of the value is lost.
+//
+// If no value was previously associated with key, item is stored and
+// ErrCacheMiss is returned along with the zero value of T.
+//
+// If the value is modified concurrently too many times for the swap to
+// succeed, ErrConflict is returned and item is not stored.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// One or more Option(s) may be applied to configure things such as the
+// value expiration TTL or its associated flags.
+func GetSet[T any](c *Client, key string, item T, opts ...Option) (T, error) {
+	var empty T
+
+	for range maxSwapAttempts {
+		old, cas, err := Gets[T](c, key, opts...)
+		switch {
+		case errors.Is(err, ErrCacheMiss):
+			// no previous value, so store item only if that is still true
+			err = Add(c, key, item, opts...)
+			switch {
+			case err == nil:
+				return empty, ErrCacheMiss
+			case errors.Is(err, ErrNotStored):
+				continue
+			default:
+				return empty, err
+			}
+		case err != nil:
+			return empty, err
+		}
+
+		err = CompareAndSwap(c, key, cas, item, opts...)
+		switch {
+		case err == nil:
+			return old, nil
+		case errors.Is(err, ErrConflict), errors.Is(err, ErrNotFound):
+			continue
+		default:
+			return empty, err
+		}
+	}
+
+	return empty, ErrConflict
+}
+
+// softValue is a value stored by Fetch with SoftTTL, along with the time
+// after which the value is stale
+type softValue[T any] struct {
+	Stale int64
+	Value T
+}
+
+// SoftTTL applies a logical expiration shorter than the ttl of a value stored
+// by Fetch. Once a value is older than the soft ttl, Fetch continues to return
+// it while refreshing the value in the background, such that a frequently read
+// value is refreshed before it expires rather than causing a synchronized
+// cache miss.
+//
+// The soft ttl is recorded inside the stored value, so a value stored by Fetch
+// with SoftTTL must only be read by Fetch with SoftTTL.
+func SoftTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.soft = ttl
+	}
+}
+
+// Fetch returns the value associated with the given key if it is cached, and
+// otherwise calls load to compute the value, stores it with the given ttl, and
+// returns it. This implements the cache-aside pattern.
+//
+// Concurrent calls of Fetch that miss the same key share a single call of load
+// and its result, preventing an expired hot key from causing many identical
+// loads of the value.
+//
+// A failure to read or store the value in memcached does not prevent the value
+// from being loaded and returned, so that an unavailable memcached instance
+// degrades into load on the origin of the value rather than into errors. An
+// error returned by load is returned as is, and nothing is stored. If the value
+// is known to be absent, as recorded by SetMiss, ErrKnownMiss is returned
+// without calling load.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance, and is the context passed to load. The SoftTTL
+// Option may be applied to refresh values in the background before they
+// expire.
+func Fetch[T any](c *Client, key string, ttl time.Duration, load func(context.Context) (T, error), opts ...Option) (T, error) {
+	options := c.options(opts)
+	if options.soft > 0 {
+		return fetchSoft(c, key, ttl, options, load, opts)
+	}
+
+	value, err := Get[T](c, key, opts...)
+	switch {
+	case err == nil:
+		return value, nil
+	case errors.Is(err, ErrKeyNotValid), errors.Is(err, ErrKnownMiss), options.ctx.Err() != nil:
+		return value, err
+	}
+
+	return loadShared(c, options.ctx, key, load, func(ctx context.Context, loaded T) {
+		_ = Set(c, key, loaded, append(slices.Clip(opts), TTL(ttl), Context(ctx))...)
+	})
+}
+
+// fetchSoft implements Fetch for values stored along with a soft ttl
+func fetchSoft[T any](c *Client, key string, ttl time.Duration, options *Options, load func(context.Context) (T, error), opts []Option) (T, error) {
+	save := func(ctx context.Context, loaded T) {
+		value := softValue[T]{Stale: c.now().Add(options.soft).UnixNano(), Value: loaded}
+		_ = Set(c, key, value, append(slices.Clip(opts), TTL(ttl), Context(ctx))...)
+	}
+
+	cached, err := Get[softValue[T]](c, key, opts...)
+	switch {
+	case err == nil:
+		if c.now().UnixNano() >= cached.Stale {
+			// the refresh outlives the caller, so must not be canceled with it
+			ctx := context.WithoutCancel(options.ctx)
+			c.flights.start(key, func() (value any, err error) {
+				// a panic of load in the background must not crash the program
+				defer func() {
+					if r := recover(); r != nil {
+						c.log.Debug("memc: refresh of stale value panicked", "panic", r)
+						value, err = nil, errFlightPanicked
+					}
+				}()
+
+				loaded, lerr := load(ctx)
+				if lerr != nil {
+					c.log.Debug("memc: failed to refresh stale value", "error", lerr)
+					return loaded, lerr
+				}
+				save(ctx, loaded)
+				return loaded, nil
+			})
+		}
+		return cached.Value, nil
+	case errors.Is(err, ErrKeyNotValid), errors.Is(err, ErrKnownMiss), options.ctx.Err() != nil:
+		return cached.Value, err
+	}
+
+	return loadShared(c, options.ctx, key, load, save)
+}
+
+// loadShared calls load and saves the loaded value, sharing the call and its
+// result with concurrent callers for the same key
+func loadShared[T any](c *Client, ctx context.Context, key string, load func(context.Context) (T, error), save func(context.Context, T)) (T, error) {
+	result, err := c.flights.do(ctx, key, func() (any, error) {
+		loaded, lerr := load(ctx)
+		if lerr != nil {
+			return loaded, lerr
+		}
+
+		// the value is returned even if it cannot be stored
+		save(ctx, loaded)
+
+		return loaded, nil
+	})
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+
+	value, ok := result.(T)
+	if !ok {
+		// the shared call loaded a value of another type for the same key
+		return load(ctx)
+	}
+	return value, nil
+}
+
+// Memoize wraps fn such that its result for each argument is cached with the
+// given ttl, using Fetch. The key of each result is the namespace followed by
+// a hash of the argument, so namespace should be unique to fn.
+//
+// Arguments are hashed by their Go syntax representation, so arguments that
+// are or contain pointers are keyed by the address they point to rather than
+// the value pointed to.
+//
+// Options are applied to each call of the returned function.
+func Memoize[K comparable, T any](c *Client, namespace string, ttl time.Duration, fn func(K) (T, error), opts ...Option) func(K) (T, error) {
+	return func(arg K) (T, error) {
+		sum := sha256.Sum256(fmt.Appendf(nil, "%T:%#v", arg, arg))
+		key := namespace + ":" + hex.EncodeToString(sum[:])
+		return Fetch(c, key, ttl, func(context.Context) (T, error) {
+			return fn(arg)
+		}, opts...)
+	}
+}
+
+// WriteThrough wraps persist such that each item written to the backing store
+// by persist is also stored in memcached with the given ttl, keeping the cache
+// consistent with the backing store.
+//
+// The returned function calls persist first, and only once persist succeeds
+// stores the item in memcached. If persist fails, the cached value of key is
+// deleted, as the backing store may have been modified, and the error of
+// persist is returned. If the item cannot be stored in memcached, the cached
+// value of key is deleted such that it cannot be read stale, and an error is
+// returned only if that also fails.
+//
+// Options are applied to each call of the returned function, followed by the
+// Options of that call. The context of the Options is passed to persist.
+func WriteThrough[T any](c *Client, ttl time.Duration, persist func(context.Context, string, T) error, opts ...Option) func(string, T, ...Option) error {
+	return func(key string, item T, more ...Option) error {
+		if _, err := c.key(key); err != nil {
+			return err
+		}
+
+		all := append(slices.Clip(opts), more...)
+		ctx := c.options(all).ctx
+
+		if err := persist(ctx, key, item); err != nil {
+			_ = invalidate(c, key, all)
+			return err
+		}
+
+		if err := Set(c, key, item, append(all, TTL(ttl))...); err != nil {
+			return invalidate(c, key, all)
+		}
+
+		return nil
+	}
+}
+
+// invalidate deletes key, treating a key that is not stored as deleted
+func invalidate(c *Client, key string, opts []Option) error {
+	if err := Delete(c, key, opts...); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
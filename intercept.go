@@ -0,0 +1,58 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"slices"
+)
+
+// Info describes an operation a Client executes on a memcached instance.
+type Info struct {
+	// Op is the name of the operation, e.g. "get", "set", or "get_multi", as
+	// used in the names of metrics reported to a MetricsSink.
+	Op string
+
+	// Key is the key of the operation, or empty for operations on many keys
+	// or on an instance as a whole.
+	Key string
+
+	// Address is the address of the memcached instance of the operation.
+	Address string
+}
+
+// An Interceptor is called around each operation a Client executes on a
+// memcached instance, and must call next to execute the operation, returning
+// its error or another. An Interceptor may be used to implement logging,
+// metrics, tracing, or fault injection.
+//
+// Implementations must be safe for concurrent use.
+type Interceptor func(ctx context.Context, info Info, next func() error) error
+
+// SetInterceptor adds an Interceptor called around each operation the Client
+// executes on a memcached instance. The option may be applied more than once,
+// in which case the first Interceptor applied is the outermost.
+//
+// A verb may execute more than one operation, e.g. when retried according to a
+// RetryPolicy, or when the keys of GetMulti are stored on many instances.
+//
+// If unset the default is to execute operations directly.
+func SetInterceptor(interceptor Interceptor) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.interceptors = append(c.interceptors, interceptor)
+	}
+}
+
+// intercept executes next through the configured interceptors
+func (c *Client) intercept(ctx context.Context, info Info, next func() error) error {
+	for _, interceptor := range slices.Backward(c.interceptors) {
+		inner := next
+		next = func() error {
+			return interceptor(ctx, info, inner)
+		}
+	}
+	return next()
+}
@@ -0,0 +1,186 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// GetInto reads the value associated with the given key into dst, returning
+// the number of bytes read and the flags the value was stored with using the
+// Flags option. The value must have been stored as a []byte or string.
+//
+// A value stored as is, without compression, encryption, or chunking, is read
+// from the connection directly into dst, avoiding the allocation of a buffer
+// for each read. Other values are decoded first and then copied into dst.
+//
+// If dst is too small to contain the value, io.ErrShortBuffer is returned along
+// with the size of the value, so that dst may be grown and the read attempted
+// again. Values read by GetInto are not kept by the local cache of
+// SetLocalCache, though values already kept there are copied into dst.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func GetInto(c *Client, key string, dst []byte, opts ...Option) (int, int, error) {
+	key, err := c.key(key)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	options := c.options(opts)
+
+	// a verb pinned to an instance reads what the instance holds
+	if payload, cached, ok := c.local.get(key, c.now()); options.server == "" && ok {
+		n, err := c.into(key, dst, payload, cached)
+		return n, c.userFlags(cached), err
+	}
+
+	var (
+		n        int
+		flags    int
+		manifest []byte
+	)
+
+	err = c.doRetry(options, "get", key, func(conn *iopool.Buffer) error {
+		h, payload, err := c.fetchInto(conn, key, dst)
+		if err != nil {
+			return err
+		}
+		flags = h.flags
+
+		if payload == nil {
+			n = h.size
+			return nil
+		}
+		defer c.payloads(conn).put(payload)
+
+		if c.chunked(h.flags) {
+			manifest = slices.Clone(*payload)
+			return nil
+		}
+
+		n, err = c.into(key, dst, *payload, h.flags)
+		return err
+	})
+	c.countRemote(err)
+
+	if err == nil && manifest != nil {
+		var value []byte
+		if value, err = unchunk[[]byte](options.ctx, c, key, manifest, flags); err == nil {
+			n, err = fill(dst, value)
+		}
+	}
+
+	return n, c.userFlags(flags), err
+}
+
+// into decodes the payload b of a value stored with flags and copies the value
+// into dst
+func (c *Client) into(key string, dst, b []byte, flags int) (int, error) {
+	value, err := unpack[[]byte](c, key, b, flags)
+	if err != nil {
+		return 0, err
+	}
+	return fill(dst, value)
+}
+
+// fill copies value into dst, returning io.ErrShortBuffer along with the size
+// of value if dst is too small
+func fill(dst, value []byte) (int, error) {
+	if len(value) > len(dst) {
+		return len(value), io.ErrShortBuffer
+	}
+	return copy(dst, value), nil
+}
+
+// plain reports whether a value stored with flags is stored as is, such that
+// its payload is the value itself
+func (c *Client) plain(flags int) bool {
+	return !c.compat && flags&(encryptedFlag|chunkedFlag|compressionMask) == 0
+}
+
+// fetchInto requests the value of key using conn, reading the payload directly
+// into dst if the value is stored as is and fits, in which case the returned
+// payload is nil, or into a payload buffer otherwise
+func (c *Client) fetchInto(conn *iopool.Buffer, key string, dst []byte) (header, *[]byte, error) {
+	request := "get %s\r\n"
+	if c.protocol == Meta {
+		request = "mg %s v f\r\n"
+	}
+
+	// write the header components
+	if _, err := fmt.Fprintf(conn, request, key); err != nil {
+		return header{}, nil, err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return header{}, nil, err
+	}
+
+	line, err := conn.ReadSlice('\n')
+	if err != nil {
+		return header{}, nil, err
+	}
+
+	var h header
+	switch {
+	case string(line) == "END\r\n", string(line) == "EN\r\n":
+		return header{}, nil, ErrCacheMiss
+	case c.protocol == Meta:
+		h, err = parseMetaHeader(key, line)
+	default:
+		h, err = parseHeader(key, line)
+	}
+	if err != nil {
+		return header{}, nil, err
+	}
+
+	// read the data into dst or a payload buffer
+	var payload *[]byte
+	target := dst
+	if !c.plain(h.flags) || h.size > len(dst) {
+		payload = c.payloads(conn).get(h.size)
+		target = *payload
+	}
+	if err = readData(conn, target[:h.size], c.protocol == Meta); err != nil {
+		if payload != nil {
+			c.payloads(conn).put(payload)
+		}
+		return header{}, nil, err
+	}
+
+	return h, payload, nil
+}
+
+// readData reads a value payload from conn into b, followed by the trailing
+// "\r\n" and, unless responding to a meta command, the "END\r\n" line
+func readData(conn *iopool.Buffer, b []byte, meta bool) error {
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return err
+	}
+
+	line, err := conn.ReadSlice('\n')
+	switch {
+	case err != nil:
+		return err
+	case string(line) != "\r\n":
+		return unexpected(line)
+	case meta:
+		return nil
+	}
+
+	line, err = conn.ReadSlice('\n')
+	switch {
+	case err != nil:
+		return err
+	case string(line) != "END\r\n":
+		return unexpected(line)
+	}
+	return nil
+}
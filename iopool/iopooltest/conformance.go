@@ -0,0 +1,209 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package iopooltest provides a conformance test suite for implementations of
+// iopool.Connection, such as custom dialers and fakes of memcached, so they can
+// be shown to behave like a net.Conn before being given to a client.
+package iopooltest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+	"github.com/shoenig/test/must"
+)
+
+// deadliner is implemented by connections that support I/O deadlines, which
+// the client uses to interrupt reads and writes once a context is done
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// RunConformance runs a suite of tests against the connections returned by
+// open, each of which must be connected to a memcached instance or something
+// that speaks its text protocol, such as memctest.Fake.
+//
+// The suite checks that reads and writes of any size round trip, that writes
+// are never partial without an error, that deadlines interrupt blocked reads
+// if the connection supports them, and that Close interrupts and fails any
+// further reads and writes. Tests of deadlines are skipped for connections
+// which do not implement SetDeadline.
+func RunConformance(t *testing.T, open func(ctx context.Context) (iopool.Connection, error)) {
+	t.Run("round trip", func(t *testing.T) {
+		conn := connect(t, open)
+		r := bufio.NewReader(conn)
+
+		write(t, conn, "version\r\n")
+		must.StrHasPrefix(t, "VERSION ", line(t, r))
+	})
+
+	t.Run("pipelined", func(t *testing.T) {
+		conn := connect(t, open)
+		r := bufio.NewReader(conn)
+
+		write(t, conn, "version\r\nversion\r\n")
+		must.StrHasPrefix(t, "VERSION ", line(t, r))
+		must.StrHasPrefix(t, "VERSION ", line(t, r))
+	})
+
+	t.Run("split write", func(t *testing.T) {
+		conn := connect(t, open)
+		r := bufio.NewReader(conn)
+
+		write(t, conn, "ver")
+		write(t, conn, "sion\r")
+		write(t, conn, "\n")
+		must.StrHasPrefix(t, "VERSION ", line(t, r))
+	})
+
+	t.Run("split read", func(t *testing.T) {
+		conn := connect(t, open)
+
+		write(t, conn, "version\r\n")
+
+		// read the response one byte at a time
+		var response bytes.Buffer
+		b := make([]byte, 1)
+		for !bytes.HasSuffix(response.Bytes(), []byte("\r\n")) {
+			n, err := conn.Read(b)
+			must.NoError(t, err)
+			response.Write(b[:n])
+		}
+		must.StrHasPrefix(t, "VERSION ", response.String())
+	})
+
+	t.Run("large write", func(t *testing.T) {
+		conn := connect(t, open)
+		r := bufio.NewReader(conn)
+
+		value := strings.Repeat("x", 512*1024)
+		write(t, conn, fmt.Sprintf("set iopooltest 0 0 %d\r\n%s\r\n", len(value), value))
+		must.Eq(t, "STORED\r\n", line(t, r))
+
+		write(t, conn, "get iopooltest\r\n")
+		must.Eq(t, fmt.Sprintf("VALUE iopooltest 0 %d\r\n", len(value)), line(t, r))
+		got := make([]byte, len(value)+2)
+		_, err := io.ReadFull(r, got)
+		must.NoError(t, err)
+		must.Eq(t, value+"\r\n", string(got))
+		must.Eq(t, "END\r\n", line(t, r))
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		conn := connect(t, open)
+		d := deadlines(t, conn)
+
+		// nothing is written, so the read blocks until the deadline
+		must.NoError(t, d.SetDeadline(time.Now().Add(50*time.Millisecond)))
+		err := within(t, func() error {
+			_, err := conn.Read(make([]byte, 8))
+			return err
+		})
+		must.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	})
+
+	t.Run("deadline passed", func(t *testing.T) {
+		conn := connect(t, open)
+		d := deadlines(t, conn)
+
+		must.NoError(t, d.SetDeadline(time.Unix(1, 0)))
+		_, err := conn.Write([]byte("version\r\n"))
+		must.ErrorIs(t, err, os.ErrDeadlineExceeded)
+		_, err = conn.Read(make([]byte, 8))
+		must.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	})
+
+	t.Run("deadline cleared", func(t *testing.T) {
+		conn := connect(t, open)
+		d := deadlines(t, conn)
+		r := bufio.NewReader(conn)
+
+		must.NoError(t, d.SetDeadline(time.Now().Add(time.Hour)))
+		must.NoError(t, d.SetDeadline(time.Time{}))
+
+		write(t, conn, "version\r\n")
+		must.StrHasPrefix(t, "VERSION ", line(t, r))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		conn := connect(t, open)
+
+		must.NoError(t, conn.Close())
+
+		_, err := conn.Write([]byte("version\r\n"))
+		must.Error(t, err)
+		_, err = conn.Read(make([]byte, 8))
+		must.Error(t, err)
+	})
+
+	t.Run("close interrupts read", func(t *testing.T) {
+		conn := connect(t, open)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_ = conn.Close()
+		}()
+
+		// nothing is written, so the read blocks until the close
+		err := within(t, func() error {
+			_, err := conn.Read(make([]byte, 8))
+			return err
+		})
+		must.Error(t, err)
+	})
+}
+
+// connect opens a connection which is closed once the test completes
+func connect(t *testing.T, open func(context.Context) (iopool.Connection, error)) iopool.Connection {
+	conn, err := open(t.Context())
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// deadlines returns conn as a deadliner, skipping the test if conn does not
+// support deadlines
+func deadlines(t *testing.T, conn iopool.Connection) deadliner {
+	d, ok := conn.(deadliner)
+	if !ok {
+		t.Skip("connection does not support deadlines")
+	}
+	return d
+}
+
+// write writes s to conn, which must be written in full
+func write(t *testing.T, conn iopool.Connection, s string) {
+	n, err := conn.Write([]byte(s))
+	must.NoError(t, err)
+	must.Eq(t, len(s), n, must.Sprint("partial write without error"))
+}
+
+// line reads the next line of a response from r
+func line(t *testing.T, r *bufio.Reader) string {
+	s, err := r.ReadString('\n')
+	must.NoError(t, err)
+	return s
+}
+
+// within calls f, failing the test if f does not return in a timely manner
+func within(t *testing.T, f func() error) error {
+	result := make(chan error, 1)
+	go func() { result <- f() }()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection remained blocked")
+		return errors.New("unreachable")
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopooltest_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"cattlecloud.net/go/memc/iopool"
+	"cattlecloud.net/go/memc/iopool/iopooltest"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/test/must"
+)
+
+func TestRunConformance_fake(t *testing.T) {
+	t.Parallel()
+
+	fake := memctest.NewFake()
+	iopooltest.RunConformance(t, func(ctx context.Context) (iopool.Connection, error) {
+		return fake.Open(ctx, "fake")
+	})
+}
+
+func TestRunConformance_tcp(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	fake := memctest.NewFake()
+	go func() {
+		for {
+			conn, aerr := listener.Accept()
+			if aerr != nil {
+				return
+			}
+			go fake.Serve(conn)
+		}
+	}()
+
+	var dialer net.Dialer
+	iopooltest.RunConformance(t, func(ctx context.Context) (iopool.Connection, error) {
+		return dialer.DialContext(ctx, "tcp", listener.Addr().String())
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopool
+
+import (
+	"cmp"
+	"crypto/md5"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+// A Distribution determines which memcached instance each key is stored on.
+type Distribution int
+
+const (
+	// Modulo chooses an instance by hashing the key modulo the number of
+	// instances. Adding or removing an instance moves nearly every key to a
+	// different instance.
+	Modulo Distribution = iota
+
+	// Ketama chooses an instance by hashing the key onto a continuum of points
+	// owned by each instance, compatible with libketama. Adding or removing an
+	// instance moves only about 1/N of keys to a different instance.
+	Ketama
+)
+
+// pointsPerHash is the number of continuum points derived from each md5 hash
+const pointsPerHash = 4
+
+// DefaultVirtualNodes is the number of points each instance owns on the Ketama
+// continuum unless configured otherwise, matching libketama. With 160 points
+// per instance the share of keys owned by each instance is typically within a
+// few percent of even, while the continuum of 100 instances occupies about
+// 200 KiB of memory.
+const DefaultVirtualNodes = 160
+
+type point struct {
+	hash uint32
+	idx  int // index of the owning pool
+}
+
+// continuum is a ring of points sorted by hash, where a key belongs to the
+// pool of the first point at or after the hash of the key
+type continuum []point
+
+// newContinuum creates the continuum of pools, where each instance owns the
+// given number of points, and an instance listed more than once owns
+// proportionally more points
+func newContinuum(pools []*pool, points int) continuum {
+	if points <= 0 {
+		points = DefaultVirtualNodes
+	}
+
+	weights := make(map[*pool]int, len(pools))
+	for _, p := range pools {
+		weights[p]++
+	}
+
+	ring := make(continuum, 0, len(pools)*points)
+	for idx, p := range pools {
+		weight := weights[p]
+		if weight == 0 {
+			continue // already added the points of a repeated instance
+		}
+		weights[p] = 0
+
+		remaining := points * weight
+		for i := 0; remaining > 0; i++ {
+			digest := md5.Sum([]byte(p.address + "-" + strconv.Itoa(i)))
+			for j := range min(pointsPerHash, remaining) {
+				ring = append(ring, point{
+					hash: binary.LittleEndian.Uint32(digest[j*4:]),
+					idx:  idx,
+				})
+			}
+			remaining -= pointsPerHash
+		}
+	}
+
+	slices.SortFunc(ring, func(a, b point) int {
+		return cmp.Compare(a.hash, b.hash)
+	})
+	return ring
+}
+
+// pick returns the index of the pool that owns key
+func (ring continuum) pick(key string) int {
+	return ring[ring.search(key)].idx
+}
+
+// search returns the position of the point on the continuum that owns key
+func (ring continuum) search(key string) int {
+	digest := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(digest[:])
+
+	i, _ := slices.BinarySearchFunc(ring, hash, func(p point, target uint32) int {
+		return cmp.Compare(p.hash, target)
+	})
+	if i == len(ring) {
+		i = 0 // wrap around the continuum
+	}
+	return i
+}
+
+// walk returns the index of each distinct pool in the order they are found
+// walking the continuum from the point that owns key
+func (ring continuum) walk(key string) []int {
+	var indexes []int
+	start := ring.search(key)
+	for i := range len(ring) {
+		idx := ring[(start+i)%len(ring)].idx
+		if !slices.Contains(indexes, idx) {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
@@ -0,0 +1,120 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopool
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestContinuum_pick(t *testing.T) {
+	t.Parallel()
+
+	instances := []string{
+		"10.0.0.1:11211",
+		"10.0.0.2:11211",
+		"10.0.0.3:11211",
+		"10.0.0.4:11211",
+	}
+
+	c := New(instances, Config{Distribution: Ketama})
+	t.Cleanup(func() { _ = c.Close() })
+	must.Len(t, 4*DefaultVirtualNodes, c.state.Load().ring)
+
+	const total = 10_000
+	before := make(map[string]string, total)
+	counts := make(map[string]int)
+	for i := range total {
+		key := "key" + strconv.Itoa(i)
+		address := c.Instance(key)
+		before[key] = address
+		counts[address]++
+	}
+
+	// each instance owns a reasonable share of keys
+	for _, instance := range instances {
+		must.Between(t, total/8, counts[instance], total/2)
+	}
+
+	// removing an instance moves only the keys of that instance
+	c.SetInstances(instances[:3])
+	for key, address := range before {
+		if address != "10.0.0.4:11211" {
+			must.Eq(t, address, c.Instance(key))
+		}
+	}
+}
+
+func TestContinuum_weights(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.2:11211"}, Config{Distribution: Ketama})
+	t.Cleanup(func() { _ = c.Close() })
+	must.Len(t, 3*DefaultVirtualNodes, c.state.Load().ring)
+
+	owned := 0
+	for _, p := range c.state.Load().ring {
+		if c.state.Load().pools[p.idx].address == "10.0.0.2:11211" {
+			owned++
+		}
+	}
+	must.Eq(t, 2*DefaultVirtualNodes, owned)
+}
+
+func TestContinuum_virtualNodes(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"10.0.0.1:11211", "10.0.0.2:11211"}, Config{
+		Distribution: Ketama,
+		VirtualNodes: 10,
+	})
+	t.Cleanup(func() { _ = c.Close() })
+	must.Len(t, 20, c.state.Load().ring)
+
+	// the first points of an instance are the same for any number of points
+	small := newContinuum(c.state.Load().pools[:1], 10)
+	large := newContinuum(c.state.Load().pools[:1], DefaultVirtualNodes)
+	for _, p := range small {
+		must.True(t, slices.Contains(large, p))
+	}
+}
+
+func TestCollection_Sequence(t *testing.T) {
+	t.Parallel()
+
+	instances := []string{"10.0.0.1:11211", "10.0.0.2:11211", "10.0.0.3:11211"}
+
+	t.Run("modulo", func(t *testing.T) {
+		c := New(instances, Config{})
+		t.Cleanup(func() { _ = c.Close() })
+
+		sequence := c.Sequence("abc123")
+		must.Eq(t, c.Instance("abc123"), sequence[0])
+		must.SliceContainsAll(t, instances, sequence)
+	})
+
+	t.Run("ketama", func(t *testing.T) {
+		c := New(instances, Config{Distribution: Ketama})
+		t.Cleanup(func() { _ = c.Close() })
+
+		sequence := c.Sequence("abc123")
+		must.Eq(t, c.Instance("abc123"), sequence[0])
+		must.SliceContainsAll(t, instances, sequence)
+
+		// the second instance is where the key moves once the first is removed
+		c.SetInstances(slices.DeleteFunc(slices.Clone(instances), func(s string) bool {
+			return s == sequence[0]
+		}))
+		must.Eq(t, sequence[1], c.Instance("abc123"))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		c := New(nil, Config{})
+		t.Cleanup(func() { _ = c.Close() })
+		must.Nil(t, c.Sequence("abc123"))
+	})
+}
@@ -4,46 +4,249 @@
 package iopool
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"sync"
+	"time"
 )
 
-type mockConn struct {
-	lock       *sync.Mutex
-	sequence   int
-	setReads   []string
-	expWrites  []string
-	errOnRead  error
-	errOnWrite error
-	errOnClose error
+var (
+	ErrMockMismatch  = errors.New("memc: mock connection written unexpected bytes")
+	ErrMockExhausted = errors.New("memc: mock connections exhausted")
+)
+
+// A MockConn is a Connection which follows a script of the exact bytes the
+// client is expected to write and the canned responses to replay back, for
+// testing the protocol exchanged with memcached without a server.
+//
+// The script is built up with Expect, Respond, and Fail, and is played out in
+// order. Writes must match the expected bytes exactly, though they may be made
+// in any number of pieces. Each chunk given to Respond is served by its own
+// Read (or more, if the read buffer is smaller), such that responses split
+// across reads can be replayed. Once the script is exhausted Read returns
+// io.EOF, as if the server hung up.
+//
+// Once the test is complete, Verify reports whether the script was followed
+// to the end.
+type MockConn struct {
+	lock     sync.Mutex
+	script   []step
+	offset   int // bytes of the current step already consumed
+	written  []byte
+	failure  error
+	closed   bool
+	deadline time.Time
+}
+
+// step is one entry of the script of a MockConn
+type step struct {
+	write string
+	read  string
+	err   error
+}
+
+func (s step) String() string {
+	switch {
+	case s.err != nil:
+		return "fail with " + s.err.Error()
+	case s.read != "":
+		return "respond " + strconv.Quote(s.read)
+	default:
+		return "expect " + strconv.Quote(s.write)
+	}
+}
+
+// NewMockConn creates a MockConn with an empty script.
+func NewMockConn() *MockConn {
+	return new(MockConn)
+}
+
+// Expect adds the exact bytes the client is expected to write next to the
+// script.
+func (mc *MockConn) Expect(write string) *MockConn {
+	return mc.add(step{write: write})
+}
+
+// Respond adds the bytes to be read back by the client next to the script,
+// each chunk being served by a separate Read.
+func (mc *MockConn) Respond(chunks ...string) *MockConn {
+	for _, chunk := range chunks {
+		mc.add(step{read: chunk})
+	}
+	return mc
+}
+
+// Fail adds to the script an error to be returned by the next Read or Write.
+func (mc *MockConn) Fail(err error) *MockConn {
+	return mc.add(step{err: err})
+}
+
+func (mc *MockConn) add(s step) *MockConn {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	if s.write != "" || s.read != "" || s.err != nil {
+		mc.script = append(mc.script, s)
+	}
+	return mc
+}
+
+// Read implements Connection, replaying the next response of the script.
+func (mc *MockConn) Read(b []byte) (int, error) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	if err := mc.usable(); err != nil {
+		return 0, err
+	}
+
+	if len(mc.script) == 0 {
+		return 0, io.EOF
+	}
+
+	s := mc.script[0]
+	switch {
+	case s.err != nil:
+		mc.next()
+		return 0, s.err
+	case s.read == "":
+		mc.failure = fmt.Errorf("%w: read while expecting %q", ErrMockMismatch, s.write[mc.offset:])
+		return 0, mc.failure
+	}
+
+	n := copy(b, s.read[mc.offset:])
+	mc.offset += n
+	if mc.offset == len(s.read) {
+		mc.next()
+	}
+	return n, nil
+}
+
+// Write implements Connection, matching b against the bytes the script
+// expects to be written next.
+func (mc *MockConn) Write(b []byte) (int, error) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	if err := mc.usable(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(b) {
+		if len(mc.script) == 0 {
+			mc.failure = fmt.Errorf("%w: wrote %q past end of script", ErrMockMismatch, b[n:])
+			return n, mc.failure
+		}
+
+		s := mc.script[0]
+		switch {
+		case s.err != nil:
+			mc.next()
+			return n, s.err
+		case s.write == "":
+			mc.failure = fmt.Errorf("%w: wrote %q while expecting to respond", ErrMockMismatch, b[n:])
+			return n, mc.failure
+		}
+
+		want := s.write[mc.offset:]
+		size := min(len(want), len(b)-n)
+		if string(b[n:n+size]) != want[:size] {
+			mc.failure = fmt.Errorf("%w: wrote %q, expected %q", ErrMockMismatch, b[n:], want)
+			return n, mc.failure
+		}
+
+		n += size
+		mc.written = append(mc.written, b[n-size:n]...)
+		mc.offset += size
+		if mc.offset == len(s.write) {
+			mc.next()
+		}
+	}
+	return n, nil
+}
+
+// usable returns the error a Read or Write fails with, if any
+func (mc *MockConn) usable() error {
+	switch {
+	case mc.closed:
+		return os.ErrClosed
+	case mc.failure != nil:
+		return mc.failure
+	case !mc.deadline.IsZero() && !time.Now().Before(mc.deadline):
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+func (mc *MockConn) next() {
+	mc.script = mc.script[1:]
+	mc.offset = 0
 }
 
-func (mc *mockConn) Read([]byte) (int, error) {
+// Close implements Connection.
+func (mc *MockConn) Close() error {
 	mc.lock.Lock()
 	defer mc.lock.Unlock()
-	return 0, mc.errOnRead
+	mc.closed = true
+	return nil
 }
 
-func (mc *mockConn) Write([]byte) (int, error) {
-	return 0, mc.errOnWrite
+// SetDeadline sets the deadline after which Read and Write fail, such that
+// the connection is interrupted like a net.Conn when a context is done.
+func (mc *MockConn) SetDeadline(t time.Time) error {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.deadline = t
+	return nil
 }
 
-func (mc *mockConn) Close() error {
-	return mc.errOnClose
+// Closed returns whether the connection has been closed.
+func (mc *MockConn) Closed() bool {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	return mc.closed
 }
 
-func newMockConn(reads, writes []string) *mockConn {
-	return &mockConn{
-		setReads:  reads,
-		expWrites: writes,
+// Written returns every byte written to the connection so far.
+func (mc *MockConn) Written() string {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	return string(mc.written)
+}
+
+// Verify returns an error if the client wrote bytes that did not match the
+// script, or if the script was not played out to the end.
+func (mc *MockConn) Verify() error {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	switch {
+	case mc.failure != nil:
+		return mc.failure
+	case len(mc.script) > 0:
+		return fmt.Errorf("memc: mock connection script not finished, next step is %s", mc.script[0])
 	}
+	return nil
 }
 
-func mockConnections(connections ...*mockConn) func(string) (Connection, error) {
+// MockConnections returns a function which hands out each of connections in
+// turn, suitable for use as Dialer.Open. Once every connection has been handed
+// out, ErrMockExhausted is returned.
+func MockConnections(connections ...*MockConn) func(context.Context, string) (Connection, error) {
+	var lock sync.Mutex
 	i := 0
-	return func(string) (Connection, error) {
+	return func(context.Context, string) (Connection, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if i >= len(connections) {
+			return nil, ErrMockExhausted
+		}
 		next := connections[i]
 		i++
-		next.sequence = i
 		return next, nil
 	}
 }
@@ -0,0 +1,102 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopool
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestMockConn(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replay", func(t *testing.T) {
+		mc := NewMockConn().Expect("get k\r\n").Respond("VALUE", " k 0 1\r\n")
+
+		n, err := mc.Write([]byte("get "))
+		must.NoError(t, err)
+		must.Eq(t, 4, n)
+		_, err = mc.Write([]byte("k\r\n"))
+		must.NoError(t, err)
+
+		b := make([]byte, 64)
+		n, err = mc.Read(b)
+		must.NoError(t, err)
+		must.Eq(t, "VALUE", string(b[:n]))
+
+		b = make([]byte, 3)
+		n, err = mc.Read(b)
+		must.NoError(t, err)
+		must.Eq(t, " k ", string(b[:n]))
+		n, err = mc.Read(make([]byte, 64))
+		must.NoError(t, err)
+		must.Eq(t, 5, n)
+
+		_, err = mc.Read(b)
+		must.ErrorIs(t, err, io.EOF)
+		must.NoError(t, mc.Verify())
+		must.Eq(t, "get k\r\n", mc.Written())
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		mc := NewMockConn().Expect("get k\r\n")
+
+		_, err := mc.Write([]byte("get x\r\n"))
+		must.ErrorIs(t, err, ErrMockMismatch)
+		must.ErrorIs(t, mc.Verify(), ErrMockMismatch)
+	})
+
+	t.Run("unfinished", func(t *testing.T) {
+		mc := NewMockConn().Expect("get k\r\n").Respond("END\r\n")
+
+		_, err := mc.Write([]byte("get k\r\n"))
+		must.NoError(t, err)
+		must.ErrorContains(t, mc.Verify(), `respond "END\r\n"`)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		oops := errors.New("oops")
+		mc := NewMockConn().Fail(oops)
+
+		_, err := mc.Read(make([]byte, 8))
+		must.ErrorIs(t, err, oops)
+		must.NoError(t, mc.Verify())
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		mc := NewMockConn().Respond("END\r\n")
+		must.NoError(t, mc.SetDeadline(time.Unix(1, 0)))
+
+		_, err := mc.Read(make([]byte, 8))
+		must.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		mc := NewMockConn()
+		must.NoError(t, mc.Close())
+		must.True(t, mc.Closed())
+
+		_, err := mc.Write([]byte("x"))
+		must.ErrorIs(t, err, os.ErrClosed)
+	})
+}
+
+func TestMockConnections(t *testing.T) {
+	t.Parallel()
+
+	mc := NewMockConn()
+	open := MockConnections(mc)
+
+	conn, err := open(t.Context(), "a")
+	must.NoError(t, err)
+	must.Eq[Connection](t, mc, conn)
+
+	_, err = open(t.Context(), "a")
+	must.ErrorIs(t, err, ErrMockExhausted)
+}
@@ -0,0 +1,245 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopool
+
+import (
+	"bufio"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var errMuxFailed = errors.New("memc: multiplexed connection failed")
+
+// A mux shares a single connection among many requests, which write their
+// commands one after another and read their responses in the same order, such
+// that requests are pipelined rather than each waiting on its own connection.
+//
+// A request writes its commands while holding the write lock, having been
+// issued a ticket, and then reads its response once the tickets issued before
+// it have been served. Any bytes read ahead of its response are handed over to
+// the request holding the next ticket.
+type mux struct {
+	conn Connection
+
+	wlock sync.Mutex // held by the request writing to conn
+
+	lock    sync.Mutex
+	turn    *sync.Cond
+	issued  uint64 // the number of tickets issued
+	serving uint64 // the ticket whose response is being read
+	pending []byte // bytes read from conn ahead of the response being read
+	users   int    // the number of requests using the mux
+	err     error  // set once conn has failed or been closed
+	closing bool   // close conn once there are no more users
+}
+
+func newMux(conn Connection) *mux {
+	m := &mux{conn: conn}
+	m.turn = sync.NewCond(&m.lock)
+	return m
+}
+
+// buffer returns a Buffer for a single request using m
+func (m *mux) buffer() *Buffer {
+	m.lock.Lock()
+	m.users++
+	m.lock.Unlock()
+
+	s := &stream{mux: m}
+	b := &Buffer{
+		Reader:  bufio.NewReader(s),
+		Writer:  bufio.NewWriter(s),
+		Closer:  s,
+		conn:    s,
+		failure: new(atomic.Bool),
+		mux:     s,
+	}
+	s.reader = b.Reader
+	return b
+}
+
+// failed returns the error m failed with, if any
+func (m *mux) failed() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.err
+}
+
+// fail closes the connection of m, such that every request using m fails with
+// err, unless m already failed
+func (m *mux) fail(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		return
+	}
+	m.err = err
+	_ = m.conn.Close()
+	m.turn.Broadcast()
+}
+
+// close closes the connection of m once there are no more requests using it
+func (m *mux) close() {
+	m.lock.Lock()
+	m.closing = true
+	idle := m.users == 0
+	m.lock.Unlock()
+
+	if idle {
+		m.fail(ErrClientClosed)
+	}
+}
+
+// done records that a request is no longer using m, and reports whether m has
+// failed
+func (m *mux) done() bool {
+	m.lock.Lock()
+	m.users--
+	last := m.users == 0 && m.closing
+	m.lock.Unlock()
+
+	if last {
+		m.fail(ErrClientClosed)
+	}
+	return m.failed() != nil
+}
+
+// issue returns the next ticket, which must be called while holding the
+// write lock so that tickets are issued in the order commands are written
+func (m *mux) issue() uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	ticket := m.issued
+	m.issued++
+	return ticket
+}
+
+// await blocks until the response of ticket may be read
+func (m *mux) await(ticket uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for m.err == nil && m.serving != ticket {
+		m.turn.Wait()
+	}
+	return m.err
+}
+
+// advance hands over leftover bytes read ahead of the response of ticket to
+// the next ticket, whose response may then be read
+func (m *mux) advance(ticket uint64, leftover []byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.serving != ticket {
+		return
+	}
+	if len(leftover) > 0 {
+		m.pending = append(leftover, m.pending...)
+	}
+	m.serving++
+	m.turn.Broadcast()
+}
+
+// stream states
+const (
+	idle = iota
+	writing
+	reading
+)
+
+// A stream is the view of a mux by a single request, through which the
+// request writes its commands and reads its responses.
+type stream struct {
+	mux    *mux
+	reader *bufio.Reader // the reader of the request buffering the stream
+	state  int
+	ticket uint64
+}
+
+// Write writes b to the connection of the mux, first taking the write lock
+// and a ticket unless the request is already writing.
+func (s *stream) Write(b []byte) (int, error) {
+	if s.state != writing {
+		s.finish()
+		s.mux.wlock.Lock()
+		s.ticket = s.mux.issue()
+		s.state = writing
+	}
+
+	if err := s.mux.failed(); err != nil {
+		return 0, err
+	}
+
+	n, err := s.mux.conn.Write(b)
+	if err != nil {
+		s.mux.fail(err)
+	}
+	return n, err
+}
+
+// Read reads the response of the request, once the responses of the requests
+// that wrote before it have been read.
+func (s *stream) Read(b []byte) (int, error) {
+	switch s.state {
+	case idle:
+		return 0, errMuxFailed
+	case writing:
+		s.mux.wlock.Unlock()
+		s.state = reading
+	}
+
+	if err := s.mux.await(s.ticket); err != nil {
+		return 0, err
+	}
+
+	// holding the turn, so pending is not touched by any other request
+	m := s.mux
+	if len(m.pending) > 0 {
+		n := copy(b, m.pending)
+		m.pending = m.pending[n:]
+		return n, nil
+	}
+
+	n, err := m.conn.Read(b)
+	if err != nil {
+		m.fail(err)
+	}
+	return n, err
+}
+
+// finish gives up the write lock or the turn of the request, handing over any
+// bytes buffered ahead of its response to the next request
+func (s *stream) finish() {
+	switch s.state {
+	case idle:
+		return
+	case writing:
+		s.mux.wlock.Unlock()
+	}
+	s.state = idle
+
+	// wait for the turn of the request even if it read nothing, so that turns
+	// are handed over in order
+	if err := s.mux.await(s.ticket); err != nil {
+		return
+	}
+
+	var leftover []byte
+	if n := s.reader.Buffered(); n > 0 {
+		peeked, _ := s.reader.Peek(n)
+		leftover = append([]byte(nil), peeked...)
+		_, _ = s.reader.Discard(n)
+	}
+	s.mux.advance(s.ticket, leftover)
+}
+
+// Close fails the mux, as the request was interrupted in a way that leaves the
+// connection in an unknown state.
+func (s *stream) Close() error {
+	s.mux.fail(errMuxFailed)
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package iopool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+// echo returns an opener of connections to a server which responds to each
+// line with the same line, coalescing the responses to pipelined lines into
+// fewer writes, and counts the connections opened
+func echo(dials *atomic.Int64) func(context.Context, string) (Connection, error) {
+	return func(context.Context, string) (Connection, error) {
+		dials.Add(1)
+		client, server := net.Pipe()
+		go func() {
+			defer func() { _ = server.Close() }()
+			r := bufio.NewReader(server)
+			w := bufio.NewWriter(server)
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				_, _ = w.WriteString(line)
+				if r.Buffered() == 0 {
+					if w.Flush() != nil {
+						return
+					}
+				}
+			}
+		}()
+		return client, nil
+	}
+}
+
+// roundTrip writes line using conn and reads back the response
+func roundTrip(conn *Buffer, line string) (string, error) {
+	if _, err := conn.WriteString(line); err != nil {
+		return "", err
+	}
+	if err := conn.Flush(); err != nil {
+		return "", err
+	}
+	return conn.ReadString('\n')
+}
+
+func TestMux_pipelined(t *testing.T) {
+	t.Parallel()
+
+	var dials atomic.Int64
+	c := New([]string{"a"}, Config{Multiplex: true, Dialer: Dialer{Open: echo(&dials)}})
+	t.Cleanup(func() { _ = c.Close() })
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			for j := range 20 {
+				conn, err := c.Get(t.Context(), "key")
+				must.NoError(t, err)
+
+				line := fmt.Sprintf("request %d-%d\r\n", i, j)
+				response, err := roundTrip(conn, line)
+				must.NoError(t, err)
+				must.Eq(t, line, response)
+
+				c.Return("key", conn)
+			}
+		})
+	}
+	wg.Wait()
+
+	must.Eq(t, 1, dials.Load())
+	stats := c.Stats()["a"]
+	must.Eq(t, 1, stats.Open)
+	must.Eq(t, 0, stats.Discarded)
+}
+
+func TestMux_rounds(t *testing.T) {
+	t.Parallel()
+
+	var dials atomic.Int64
+	c := New([]string{"a"}, Config{Multiplex: true, Dialer: Dialer{Open: echo(&dials)}})
+	t.Cleanup(func() { _ = c.Close() })
+
+	conn, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+
+	// a request may write and read more than once
+	for i := range 3 {
+		line := fmt.Sprintf("round %d\r\n", i)
+		response, rerr := roundTrip(conn, line)
+		must.NoError(t, rerr)
+		must.Eq(t, line, response)
+	}
+
+	// the turn of the request is handed over once it is returned
+	other, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	c.Return("key", conn)
+
+	response, err := roundTrip(other, "after\r\n")
+	must.NoError(t, err)
+	must.Eq(t, "after\r\n", response)
+	c.Return("key", other)
+}
+
+func TestMux_failure(t *testing.T) {
+	t.Parallel()
+
+	var dials atomic.Int64
+	c := New([]string{"a"}, Config{Multiplex: true, Dialer: Dialer{Open: echo(&dials)}})
+	t.Cleanup(func() { _ = c.Close() })
+
+	conn, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	_, err = roundTrip(conn, "hello\r\n")
+	must.NoError(t, err)
+
+	// a failed request closes the shared connection
+	conn.SetHealth(errors.New("oops"))
+	c.Return("key", conn)
+	must.Eq(t, 1, c.Stats()["a"].Discarded)
+
+	conn, err = c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	response, err := roundTrip(conn, "again\r\n")
+	must.NoError(t, err)
+	must.Eq(t, "again\r\n", response)
+	c.Return("key", conn)
+
+	must.Eq(t, 2, dials.Load())
+}
+
+func TestMux_interrupted(t *testing.T) {
+	t.Parallel()
+
+	// the server reads commands but never responds
+	silent := func(context.Context, string) (Connection, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer func() { _ = server.Close() }()
+			_, _ = io.Copy(io.Discard, server)
+		}()
+		return client, nil
+	}
+
+	c := New([]string{"a"}, Config{Multiplex: true, Dialer: Dialer{Open: silent}})
+	t.Cleanup(func() { _ = c.Close() })
+
+	first, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	second, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	stop := first.Watch(ctx)
+
+	results := make(chan error, 2)
+	go func() {
+		_, rerr := roundTrip(first, "first\r\n")
+		results <- rerr
+	}()
+	go func() {
+		_, rerr := roundTrip(second, "second\r\n")
+		results <- rerr
+	}()
+
+	// interrupting the first request fails the second waiting behind it
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	must.Error(t, <-results)
+	must.Error(t, <-results)
+	stop()
+
+	c.Return("key", first)
+	c.Return("key", second)
+	must.Eq(t, 0, c.Stats()["a"].Open)
+	must.Eq(t, 1, c.Stats()["a"].Discarded)
+}
+
+func TestMux_close(t *testing.T) {
+	t.Parallel()
+
+	var dials atomic.Int64
+	c := New([]string{"a"}, Config{Multiplex: true, Dialer: Dialer{Open: echo(&dials)}})
+
+	conn, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+
+	// the request in flight completes after the collection is closed
+	must.NoError(t, c.Close())
+	response, err := roundTrip(conn, "hello\r\n")
+	must.NoError(t, err)
+	must.Eq(t, "hello\r\n", response)
+
+	c.Return("key", conn)
+	_, err = roundTrip(conn, "again\r\n")
+	must.Error(t, err)
+
+	_, err = c.Get(t.Context(), "key")
+	must.ErrorIs(t, err, ErrClientClosed)
+}
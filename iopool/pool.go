@@ -5,19 +5,25 @@ package iopool
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"io"
+	"log/slog"
 	"net"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
-	"cattlecloud.net/go/scope"
 	"cattlecloud.net/go/stacks"
 )
 
 var (
-	ErrClientClosed = errors.New("memc: client has been closed")
+	ErrClientClosed    = errors.New("memc: client has been closed")
+	ErrUnknownInstance = errors.New("memc: not a configured memcached instance")
+	ErrPoolExhausted   = errors.New("memc: no connection available in pool")
+	ErrNoInstances     = errors.New("memc: no memcached instances available")
 )
 
 // A Connection represents an underlying TCP/Unix socket connection to a single
@@ -39,7 +45,13 @@ type Buffer struct {
 	*bufio.Reader
 	*bufio.Writer
 	io.Closer
+	conn    Connection
 	failure *atomic.Bool
+	since   time.Time // when the buffer became idle
+	gen     uint64    // generation of the pool the buffer was opened in
+	owner   *pool     // the pool the buffer was acquired from
+	mux     *stream   // the stream of a multiplexed connection, if shared
+	scratch []byte    // reused by each call of Scratch
 }
 
 func newBuffer(conn Connection) *Buffer {
@@ -47,112 +59,1111 @@ func newBuffer(conn Connection) *Buffer {
 		Reader:  bufio.NewReader(conn),
 		Writer:  bufio.NewWriter(conn),
 		Closer:  conn,
+		conn:    conn,
 		failure: new(atomic.Bool),
 	}
 }
 
+// Address returns the address of the memcached instance the buffer is
+// connected to.
+func (b *Buffer) Address() string {
+	if b.owner == nil {
+		return ""
+	}
+	return b.owner.address
+}
+
+// Scratch returns a byte slice of length size owned by the buffer, the memory
+// of which is reused by each call such that values may be read from the
+// connection without allocating. The slice is only valid until the next call
+// of Scratch, and must not be used once the buffer is returned to the pool.
+func (b *Buffer) Scratch(size int) *[]byte {
+	if cap(b.scratch) < size {
+		b.scratch = make([]byte, size)
+	}
+	b.scratch = b.scratch[:size]
+	return &b.scratch
+}
+
+// deadliner is implemented by connections that support I/O deadlines, such as
+// any net.Conn
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// Watch arranges for any blocked or future read or write of the buffer to fail
+// once ctx is done, in which case the buffer is also marked as failed so that
+// it is not reused. If ctx has a deadline and the underlying connection
+// supports deadlines, the deadline is also applied to the connection so that
+// reads and writes time out on their own.
+//
+// The returned stop function must be called once the buffer is no longer in
+// use with ctx, and reports whether it stopped the interruption from happening.
+func (b *Buffer) Watch(ctx context.Context) (stop func() bool) {
+	d, ok := b.conn.(deadliner)
+	deadline, bounded := ctx.Deadline()
+	bounded = bounded && ok
+
+	if bounded {
+		_ = d.SetDeadline(deadline)
+	}
+
+	cancel := context.AfterFunc(ctx, func() {
+		b.failure.Store(true)
+		if ok {
+			_ = d.SetDeadline(time.Unix(1, 0))
+			return
+		}
+		_ = b.Close()
+	})
+
+	return func() bool {
+		stopped := cancel()
+		if stopped && bounded {
+			// clear the deadline before the connection is reused
+			_ = d.SetDeadline(time.Time{})
+		}
+		return stopped
+	}
+}
+
 func (b *Buffer) SetHealth(err error) {
 	if err != nil {
 		b.failure.Store(true)
 	}
 }
 
-func New(instances []string, idle int) *Collection {
+// A Wrapper is applied to each newly established network connection, and may
+// return a replacement connection that wraps the original.
+type Wrapper func(net.Conn) net.Conn
+
+// A Dialer configures how connections to each memcached instance are
+// established.
+type Dialer struct {
+	// Timeout is the maximum amount of time to wait on establishing a
+	// connection. If unset the default timeout is 3 seconds.
+	Timeout time.Duration
+
+	// KeepAlive configures TCP keepalive probes on each connection. If unset
+	// the default keepalive settings of net.Dialer are used.
+	KeepAlive net.KeepAliveConfig
+
+	// Wrap is applied to each newly established connection, if set.
+	Wrap Wrapper
+
+	// Open, if set, establishes each connection in place of dialing the
+	// address over the network, e.g. to connect to an in-memory fake of
+	// memcached. Timeout, KeepAlive, and Wrap do not apply to connections
+	// established by Open.
+	Open func(ctx context.Context, address string) (Connection, error)
+}
+
+const defaultDialTimeout = 3 * time.Second
+
+// A Config configures the pool of connections to each memcached instance.
+type Config struct {
+	// Idle is the maximum number of idle connections to keep open.
+	Idle int
+
+	// MinIdle is the minimum number of idle connections to keep open, which
+	// are established by a background routine up front and again whenever
+	// connections are closed, so that bursts of requests do not wait on new
+	// connections. It is capped by Idle. If unset idle connections are only
+	// established on demand.
+	MinIdle int
+
+	// MaxOpen is the maximum number of connections open at once, whether idle
+	// or in use. If unset the number of open connections is unlimited.
+	MaxOpen int
+
+	// Wait is the maximum amount of time to wait on a connection to become
+	// available once MaxOpen connections are open, after which ErrPoolExhausted
+	// is returned. If unset the wait is bounded only by the context.
+	Wait time.Duration
+
+	// IdleTimeout is the maximum amount of time a connection may remain idle
+	// in the pool, after which it is closed. If unset idle connections are
+	// kept open indefinitely.
+	IdleTimeout time.Duration
+
+	// ResolveInterval is how often the hostname of each memcached instance is
+	// resolved again, closing the connections of an instance once the address
+	// records of its hostname change. If unset hostnames are only resolved
+	// when establishing a new connection.
+	ResolveInterval time.Duration
+
+	// Distribution determines which instance each key is stored on. If unset
+	// the default is Modulo.
+	Distribution Distribution
+
+	// VirtualNodes is the number of points each instance owns on the continuum
+	// of the Ketama distribution. If unset the default is DefaultVirtualNodes.
+	VirtualNodes int
+
+	// Hash, if set, chooses the index of the instance of each key out of n
+	// instances, taking precedence over Distribution.
+	Hash func(key string, n int) int
+
+	// FailoverThreshold is the number of consecutive failures to establish a
+	// connection to an instance after which the instance is considered down,
+	// and its keys are chosen from the remaining instances in the order of
+	// Sequence. If unset instances are never considered down.
+	FailoverThreshold int
+
+	// FailoverCooldown is how long an instance is considered down before
+	// connecting to it is attempted again.
+	FailoverCooldown time.Duration
+
+	// Multiplex shares a single connection to each instance among all
+	// requests, which are pipelined over the connection rather than each using
+	// a connection of its own. Idle, MinIdle, MaxOpen, Wait, and IdleTimeout do
+	// not apply to multiplexed connections.
+	Multiplex bool
+
+	// Dialer configures how new connections are established.
+	Dialer Dialer
+
+	// Logger receives debug level logs of the lifecycle of connections and
+	// instances, such as connections being established and closed, and
+	// instances being considered down. If unset nothing is logged.
+	Logger *slog.Logger
+}
+
+func New(instances []string, config Config) *Collection {
+	c := &Collection{config: config, stop: make(chan struct{}), nudge: make(chan struct{}, 1)}
 	pools := make([]*pool, 0, len(instances))
 	for _, instance := range instances {
-		pools = append(pools, newPool(instance, idle))
+		pools = append(pools, c.newPool(instance))
+	}
+	c.store(pools)
+
+	if config.IdleTimeout > 0 {
+		go c.reaper(config.IdleTimeout / 2)
+	}
+	if config.ResolveInterval > 0 {
+		go c.resolver(config.ResolveInterval)
+	}
+	if config.MinIdle > 0 {
+		go c.maintainer(replenishInterval)
 	}
-	return &Collection{pools: pools}
+	return c
 }
 
 type Collection struct {
-	pools []*pool
+	config Config
+
+	// state is replaced as a whole whenever the set of instances changes, so
+	// that choosing the pool of a key requires no locking
+	state atomic.Pointer[topology]
+
+	lock   sync.Mutex // serializes changes to the set of instances
+	closed bool
+
+	stop  chan struct{} // stops background routines, if running
+	nudge chan struct{} // wakes the maintainer, if running
+	once  sync.Once
+}
+
+// A topology is the set of pools of a collection at a point in time, which is
+// never modified once stored.
+type topology struct {
+	pools []*pool // an instance listed more than once is picked more often
+	ring  continuum
+}
+
+// store replaces the topology of the collection with one of pools
+func (c *Collection) store(pools []*pool) {
+	c.state.Store(&topology{pools: pools, ring: c.continuum(pools)})
+}
+
+// newPool creates a pool for the instance of address using the configuration
+// of the collection
+func (c *Collection) newPool(address string) *pool {
+	p := newPool(address, c.config.Idle)
+	p.openf = c.config.Dialer.open
+	p.wait = c.config.Wait
+	p.timeout = c.config.IdleTimeout
+	p.threshold = c.config.FailoverThreshold
+	p.cooldown = c.config.FailoverCooldown
+	p.minIdle = min(c.config.MinIdle, c.config.Idle)
+	p.multiplex = c.config.Multiplex
+	p.notify = c.wake
+	if c.config.Logger != nil {
+		p.log = c.config.Logger
+	}
+	if c.config.MaxOpen > 0 {
+		p.slots = make(chan struct{}, c.config.MaxOpen)
+	}
+	return p
+}
+
+// continuum returns the continuum of pools if the collection is configured for
+// the Ketama distribution, or nil otherwise
+func (c *Collection) continuum(pools []*pool) continuum {
+	switch c.config.Distribution {
+	case Ketama:
+		return newContinuum(pools, c.config.VirtualNodes)
+	default:
+		return nil
+	}
+}
+
+// unique returns each distinct pool of the collection, in order
+func (c *Collection) unique() []*pool {
+	t := c.state.Load()
+	pools := make([]*pool, 0, len(t.pools))
+	for _, p := range t.pools {
+		if !slices.Contains(pools, p) {
+			pools = append(pools, p)
+		}
+	}
+	return pools
+}
+
+// SetInstances replaces the set of memcached instances of the collection. The
+// pools of instances that remain are kept along with their idle connections,
+// and the pools of instances that are removed are closed.
+//
+// An instance listed more than once is chosen proportionally more often.
+func (c *Collection) SetInstances(instances []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	previous := c.unique()
+
+	existing := make(map[string]*pool, len(previous))
+	for _, p := range previous {
+		existing[p.address] = p
+	}
+
+	pools := make([]*pool, 0, len(instances))
+	for _, instance := range instances {
+		p, exists := existing[instance]
+		if !exists {
+			p = c.newPool(instance)
+			existing[instance] = p
+		}
+		pools = append(pools, p)
+	}
+
+	for _, p := range previous {
+		if !slices.Contains(instances, p.address) {
+			p.close()
+		}
+	}
+
+	c.store(pools)
+	c.wake()
+}
+
+// reaper periodically closes the connections that have remained idle for
+// longer than the idle timeout of their pool, until the collection is closed
+func (c *Collection) reaper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			for _, p := range c.unique() {
+				p.reap(now)
+			}
+		}
+	}
+}
+
+// resolver periodically resolves the hostname of each pool, until the
+// collection is closed
+func (c *Collection) resolver(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, p := range c.unique() {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+			p.resolve(ctx)
+			cancel()
+		}
+
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// replenishInterval is how often the maintainer checks whether each pool is
+// below its minimum of idle connections, in case a wake up was missed or an
+// attempt to establish a connection failed
+const replenishInterval = 5 * time.Second
+
+// maintainer establishes idle connections for each pool below its minimum of
+// idle connections, periodically and whenever woken, until the collection is
+// closed
+func (c *Collection) maintainer(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, p := range c.unique() {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+			p.replenish(ctx)
+			cancel()
+		}
+
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		case <-c.nudge:
+		}
+	}
 }
 
-func (c *Collection) pick(key string) int {
-	if len(c.pools) == 1 {
+// wake wakes the maintainer, if running, without blocking
+func (c *Collection) wake() {
+	select {
+	case c.nudge <- struct{}{}:
+	default:
+	}
+}
+
+// choose returns the pool chosen for key, or nil if there are no pools
+func (c *Collection) choose(key string) *pool {
+	t := c.state.Load()
+	if len(t.pools) == 0 {
+		return nil
+	}
+
+	choice := t.pools[c.pick(t, key)]
+	if choice.up(time.Now()) {
+		return choice
+	}
+
+	// the chosen instance is down, so fail over to the next instance that is
+	// not, or stick with the chosen instance if every instance is down
+	for _, idx := range c.order(t, key) {
+		if p := t.pools[idx]; p.up(time.Now()) {
+			return p
+		}
+	}
+	return choice
+}
+
+// pick returns the index of the pool of t chosen for key, which must have at
+// least one pool
+func (c *Collection) pick(t *topology, key string) int {
+	if len(t.pools) == 1 {
 		return 0
 	}
 
+	if hash := c.config.Hash; hash != nil {
+		n := len(t.pools)
+		return (hash(key, n)%n + n) % n
+	}
+
+	if t.ring != nil {
+		return t.ring.pick(key)
+	}
+
 	// compute the server to choose for key
 	// deterministic given set of servers and key
 	x := byte(37)
 	for _, c := range key {
 		x ^= byte(c)
 	}
-	idx := int(int(x) % len(c.pools))
+	idx := int(int(x) % len(t.pools))
 
 	return idx
 }
 
-func (c *Collection) Get(key string) (*Buffer, error) {
-	idx := c.pick(key)
-	choice := c.pools[idx]
-	return choice.get()
+func (c *Collection) Get(ctx context.Context, key string) (*Buffer, error) {
+	choice := c.choose(key)
+	if choice == nil {
+		return nil, ErrNoInstances
+	}
+	return choice.get(ctx)
+}
+
+func (c *Collection) Return(_ string, conn *Buffer) {
+	c.free(conn)
+}
+
+// free returns conn to the pool it was acquired from, which may no longer be
+// part of the collection
+func (c *Collection) free(conn *Buffer) {
+	if conn.owner == nil {
+		_ = conn.Close()
+		return
+	}
+	conn.owner.free(conn)
+}
+
+// Instance returns the address of the memcached instance chosen for key, or
+// the empty string if there are no instances.
+func (c *Collection) Instance(key string) string {
+	choice := c.choose(key)
+	if choice == nil {
+		return ""
+	}
+	return choice.address
 }
 
-func (c *Collection) Return(key string, conn *Buffer) {
-	idx := c.pick(key)
-	choice := c.pools[idx]
-	choice.free(conn)
+// Sequence returns the address of each memcached instance in the order of
+// preference for key, beginning with the instance chosen for key. For the
+// Ketama distribution the order is that of the continuum, otherwise instances
+// follow the chosen instance in the order they are listed.
+func (c *Collection) Sequence(key string) []string {
+	t := c.state.Load()
+	if len(t.pools) == 0 {
+		return nil
+	}
+
+	indexes := c.order(t, key)
+	addresses := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		address := t.pools[idx].address
+		if !slices.Contains(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// order returns the index of each pool of t in the order of preference for
+// key, which must have at least one pool
+func (c *Collection) order(t *topology, key string) []int {
+	if c.config.Hash == nil && t.ring != nil {
+		return t.ring.walk(key)
+	}
+
+	start := c.pick(t, key)
+	indexes := make([]int, 0, len(t.pools))
+	for i := range len(t.pools) {
+		indexes = append(indexes, (start+i)%len(t.pools))
+	}
+	return indexes
+}
+
+// Instances returns the address of each memcached instance in the collection.
+func (c *Collection) Instances() []string {
+	pools := c.unique()
+	addresses := make([]string, 0, len(pools))
+	for _, p := range pools {
+		addresses = append(addresses, p.address)
+	}
+	return addresses
+}
+
+func (c *Collection) find(address string) (*pool, error) {
+	for _, p := range c.state.Load().pools {
+		if p.address == address {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownInstance
+}
+
+// GetInstance returns a connection to the memcached instance of address,
+// rather than the instance chosen by hashing a key.
+func (c *Collection) GetInstance(ctx context.Context, address string) (*Buffer, error) {
+	p, err := c.find(address)
+	if err != nil {
+		return nil, err
+	}
+	return p.get(ctx)
+}
+
+// ReturnInstance returns a connection acquired through GetInstance.
+func (c *Collection) ReturnInstance(_ string, conn *Buffer) {
+	c.free(conn)
+}
+
+// Idle returns the total number of idle connections across all pools.
+func (c *Collection) Idle() int {
+	n := 0
+	for _, p := range c.unique() {
+		n += p.size()
+	}
+	return n
+}
+
+// Open returns the total number of established connections across all pools,
+// both in use and idle.
+func (c *Collection) Open() int {
+	n := 0
+	for _, p := range c.unique() {
+		p.lock.Lock()
+		n += p.open
+		p.lock.Unlock()
+	}
+	return n
+}
+
+// Stats describes the connections of the pool of a memcached instance.
+type Stats struct {
+	// MaxOpen is the maximum number of open connections, or 0 if unlimited.
+	MaxOpen int
+
+	// Open is the number of established connections, both in use and idle.
+	Open int
+
+	// InUse is the number of connections currently in use.
+	InUse int
+
+	// Idle is the number of idle connections.
+	Idle int
+
+	// WaitCount is the number of times a connection was waited for, as
+	// MaxOpen connections were open.
+	WaitCount int64
+
+	// WaitDuration is the total time spent waiting for a connection.
+	WaitDuration time.Duration
+
+	// Dials is the number of attempts to establish a new connection.
+	Dials int64
+
+	// DialFailures is the number of attempts to establish a new connection
+	// that failed.
+	DialFailures int64
+
+	// Discarded is the number of connections closed because they failed, or
+	// the address records of the instance changed.
+	Discarded int64
+
+	// IdleClosed is the number of connections closed because the limit of
+	// idle connections was reached, or due to the idle timeout.
+	IdleClosed int64
+}
+
+// Stats returns the Stats of the pool of each memcached instance, by the
+// address of each instance.
+func (c *Collection) Stats() map[string]Stats {
+	pools := c.unique()
+	result := make(map[string]Stats, len(pools))
+	for _, p := range pools {
+		result[p.address] = p.stats()
+	}
+	return result
 }
 
 func (c *Collection) Close() error {
-	for _, p := range c.pools {
+	c.once.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+
+	pools := c.unique()
+
+	c.lock.Lock()
+	c.closed = true
+	c.lock.Unlock()
+
+	for _, p := range pools {
 		p.close()
 	}
 	return nil
 }
 
+// Shutdown closes the collection like Close, and then waits for every
+// connection in use to be returned. If ctx is done first, the connections still
+// in use are closed, interrupting their requests, and the error of ctx is
+// returned.
+func (c *Collection) Shutdown(ctx context.Context) error {
+	pools := c.unique()
+	_ = c.Close()
+
+	interval := time.Millisecond
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		busy := 0
+		for _, p := range pools {
+			busy += p.busy()
+		}
+		if busy == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, p := range pools {
+				p.abort()
+			}
+			return ctx.Err()
+		case <-timer.C:
+			// poll less frequently the longer the wait, up to a limit
+			interval = min(2*interval, shutdownPollMax)
+			timer.Reset(interval)
+		}
+	}
+}
+
+// shutdownPollMax is the longest interval between checks for connections in
+// use during Shutdown
+const shutdownPollMax = 500 * time.Millisecond
+
 const closed = -1
 
 type pool struct {
-	address   string
+	address string
+	openf   func(context.Context, string) (Connection, error)
+
+	// slots limits the number of open connections, if set
+	slots chan struct{}
+	wait  time.Duration
+
+	// timeout is how long a connection may remain idle, if set
+	timeout time.Duration
+
+	// lookup resolves the hostname of address into the set of addrs, which
+	// when changed increments gen so that existing connections are closed
+	lookup func(context.Context, string) ([]string, error)
+	addrs  []string
+	gen    uint64
+
+	// threshold is the number of consecutive dial failures after which the
+	// pool is down until the cooldown has passed, if set
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	down      time.Time
+
+	// minIdle is the number of idle connections replenished by the maintainer
+	// of the collection, which notify wakes once connections are closed
+	minIdle int
+	notify  func()
+
+	// multiplex shares the single connection of shared among all requests,
+	// which is established while holding dialing
+	multiplex bool
+	shared    *mux
+	dialing   sync.Mutex
+
+	log *slog.Logger
+
+	// counters describing the connections of the pool, reported by stats
+	open         int
+	waits        int64
+	waited       time.Duration
+	dials        int64
+	dialFailures int64
+	discarded    int64
+	idleClosed   int64
+
+	lock      sync.Mutex
 	available stacks.Stack[*Buffer]
+	count     atomic.Int64 // the size of available, readable without the lock
+	inuse     map[*Buffer]struct{}
 	idle      int
-	openf     func(string) (Connection, error)
 }
 
 func newPool(address string, idle int) *pool {
 	return &pool{
 		address:   address,
 		idle:      idle,
-		openf:     open,
+		openf:     Dialer{}.open,
+		notify:    func() {},
+		lookup:    net.DefaultResolver.LookupHost,
+		log:       slog.New(slog.DiscardHandler),
 		available: stacks.Simple[*Buffer](),
+		inuse:     make(map[*Buffer]struct{}),
 	}
 }
 
 func (p *pool) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	p.idle = closed // close down the pool
 
 	// pop off each idle connection and close it
 	for !p.available.Empty() {
 		conn := p.available.Pop()
 		_ = conn.Close()
+		p.open--
+	}
+	p.recount()
+
+	// the shared connection is closed once its requests complete
+	if p.shared != nil {
+		p.shared.close()
+		p.shared = nil
+		p.open--
 	}
 }
 
-func (p *pool) get() (*Buffer, error) {
+// busy returns the number of connections of p currently in use
+func (p *pool) busy() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return len(p.inuse)
+}
+
+// abort closes every connection of p currently in use, interrupting any
+// request blocked on it, and marks each as failed so it is not reused
+func (p *pool) abort() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for conn := range p.inuse {
+		conn.failure.Store(true)
+		_ = conn.Close()
+	}
+}
+
+func (p *pool) stats() Stats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	idle := p.available.Size()
+	return Stats{
+		MaxOpen:      cap(p.slots),
+		Open:         p.open,
+		InUse:        p.open - idle,
+		Idle:         idle,
+		WaitCount:    p.waits,
+		WaitDuration: p.waited,
+		Dials:        p.dials,
+		DialFailures: p.dialFailures,
+		Discarded:    p.discarded,
+		IdleClosed:   p.idleClosed,
+	}
+}
+
+// size returns the number of idle connections of p, without locking
+func (p *pool) size() int {
+	return int(p.count.Load())
+}
+
+// recount records the number of idle connections of p reported by size, which
+// must be called while holding the lock after connections are pushed or popped
+func (p *pool) recount() {
+	p.count.Store(int64(p.available.Size()))
+}
+
+func (p *pool) get(ctx context.Context) (*Buffer, error) {
+	if p.multiplex {
+		return p.share(ctx)
+	}
+
+	if err := p.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
 	if p.idle == closed {
+		p.lock.Unlock()
+		p.release()
 		return nil, ErrClientClosed
 	}
 
-	if p.available.Empty() {
-		conn, err := p.openf(p.address)
-		if err != nil {
-			return nil, err
-		}
-		return newBuffer(conn), nil
+	if !p.available.Empty() {
+		b := p.available.Pop()
+		p.recount()
+		p.inuse[b] = struct{}{}
+		b.owner = p
+		p.lock.Unlock()
+		return b, nil
 	}
+	gen := p.gen
+	p.lock.Unlock()
 
-	b := p.available.Pop()
+	// establish a new connection without holding the lock
+	conn, err := p.openf(ctx, p.address)
+	p.dialed(err)
+	if err != nil {
+		p.log.Debug("memc: failed to open connection", "address", p.address, "error", err)
+		p.release()
+		return nil, err
+	}
+	p.log.Debug("memc: opened connection", "address", p.address)
+
+	b := newBuffer(conn)
+	b.gen = gen
+	b.owner = p
+
+	p.lock.Lock()
+	p.inuse[b] = struct{}{}
+	p.lock.Unlock()
 	return b, nil
 }
 
-func open(address string) (Connection, error) {
-	dialer := &net.Dialer{Timeout: 3 * time.Second}
+// share returns a Buffer of the multiplexed connection of p, establishing the
+// connection if there is none or it has failed
+func (p *pool) share(ctx context.Context) (*Buffer, error) {
+	if b, ok := p.reuse(); ok {
+		return b, nil
+	}
+
+	p.dialing.Lock()
+	defer p.dialing.Unlock()
 
-	ctx, cancel := scope.TTL(3 * time.Second)
+	// another request may have established the connection in the meantime
+	if b, ok := p.reuse(); ok {
+		return b, nil
+	}
+
+	p.lock.Lock()
+	if p.idle == closed {
+		p.lock.Unlock()
+		return nil, ErrClientClosed
+	}
+	p.lock.Unlock()
+
+	conn, err := p.openf(ctx, p.address)
+	p.dialed(err)
+	if err != nil {
+		p.log.Debug("memc: failed to open connection", "address", p.address, "error", err)
+		return nil, err
+	}
+	p.log.Debug("memc: opened connection", "address", p.address, "reason", "multiplexed")
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.idle == closed {
+		_ = conn.Close()
+		p.open--
+		return nil, ErrClientClosed
+	}
+
+	if p.shared != nil {
+		// replace the connection which failed
+		p.open--
+		p.discarded++
+	}
+	p.shared = newMux(conn)
+	return p.attach(), nil
+}
+
+// reuse returns a Buffer of the multiplexed connection of p, if established
+// and not failed
+func (p *pool) reuse() (*Buffer, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.idle == closed || p.shared == nil || p.shared.failed() != nil {
+		return nil, false
+	}
+	return p.attach(), true
+}
+
+// attach returns a Buffer of the multiplexed connection of p for a single
+// request, which must be called while holding the lock
+func (p *pool) attach() *Buffer {
+	b := p.shared.buffer()
+	b.gen = p.gen
+	b.owner = p
+	p.inuse[b] = struct{}{}
+	return b
+}
+
+// unshare completes the request of a Buffer of a multiplexed connection,
+// closing the connection if the request failed. A connection replaced after
+// the address records of the instance changed is closed by its mux once its
+// last request completes.
+func (p *pool) unshare(conn *Buffer) {
+	m := conn.mux.mux
+
+	conn.mux.finish()
+	if conn.failure.Load() {
+		m.fail(errMuxFailed)
+	}
+	failed := m.done()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.inuse, conn)
+
+	if p.shared != m {
+		return // already replaced or closed
+	}
+
+	if failed {
+		p.log.Debug("memc: closed connection", "address", p.address, "reason", "connection failed")
+		p.shared = nil
+		p.open--
+		p.discarded++
+	}
+}
+
+// resolve looks up the hostname of p, closing every idle connection and
+// marking every connection in use as stale if its address records changed
+// since the previous lookup
+func (p *pool) resolve(ctx context.Context) {
+	host, _, err := net.SplitHostPort(p.address)
+	if err != nil || net.ParseIP(host) != nil {
+		// a unix socket or an ip address, neither of which can change
+		return
+	}
+
+	addrs, err := p.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		// keep using existing connections until the next lookup
+		return
+	}
+	slices.Sort(addrs)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	switch {
+	case p.addrs == nil:
+		p.addrs = addrs
+	case !slices.Equal(p.addrs, addrs):
+		p.log.Debug("memc: instance addresses changed", "address", p.address, "previous", p.addrs, "current", addrs)
+		p.addrs = addrs
+		p.gen++
+		for !p.available.Empty() {
+			conn := p.available.Pop()
+			_ = conn.Close()
+			p.open--
+			p.discarded++
+		}
+		p.recount()
+		if p.shared != nil {
+			p.shared.close()
+			p.shared = nil
+			p.open--
+			p.discarded++
+		}
+		p.notify()
+	}
+}
+
+// dialed records the outcome of establishing a new connection, marking p as
+// down once the failover threshold of consecutive failures is reached
+func (p *pool) dialed(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.dials++
+	if err != nil {
+		p.dialFailures++
+	} else {
+		p.open++
+	}
+
+	if p.threshold <= 0 {
+		return
+	}
+
+	switch {
+	case err == nil:
+		p.failures = 0
+	case errors.Is(err, context.Canceled):
+		// the caller gave up, which says nothing about the instance
+	default:
+		p.failures++
+		if p.failures >= p.threshold {
+			p.down = time.Now().Add(p.cooldown)
+			p.log.Debug("memc: instance is down", "address", p.address, "failures", p.failures, "cooldown", p.cooldown)
+		}
+	}
+}
+
+// up reports whether p is not considered down as of now
+func (p *pool) up(now time.Time) bool {
+	if p.threshold <= 0 {
+		return true
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.failures < p.threshold || !now.Before(p.down)
+}
+
+// acquire reserves one of the open connection slots of p, waiting on a slot to
+// be released if all of them are in use
+func (p *pool) acquire(ctx context.Context) error {
+	if p.slots == nil {
+		return nil
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if p.wait > 0 {
+		timer := time.NewTimer(p.wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	start := time.Now()
+	defer func() {
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		p.waits++
+		p.waited += time.Since(start)
+	}()
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrPoolExhausted
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release gives up a slot reserved by acquire
+func (p *pool) release() {
+	if p.slots == nil {
+		return
+	}
+
+	select {
+	case <-p.slots:
+	default:
+	}
+}
+
+func (d Dialer) open(ctx context.Context, address string) (Connection, error) {
+	if d.Open != nil {
+		return d.Open(ctx, address)
+	}
+
+	conn, err := d.dial(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if d.Wrap != nil {
+		return d.Wrap(conn), nil
+	}
+	return conn, nil
+}
+
+func (d Dialer) dial(ctx context.Context, address string) (net.Conn, error) {
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = defaultDialTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:         timeout,
+		KeepAliveConfig: d.KeepAlive,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	switch strings.HasPrefix(address, "/") {
@@ -164,14 +1175,128 @@ func open(address string) (Connection, error) {
 }
 
 func (p *pool) free(conn *Buffer) {
+	if conn.mux != nil {
+		p.unshare(conn)
+		return
+	}
+
+	defer p.release()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.inuse, conn)
+
 	switch {
 	case p.idle == closed:
 		_ = conn.Close()
-	case p.available.Size() >= p.idle:
-		_ = conn.Close()
+		p.open--
 	case conn.failure.Load():
+		p.log.Debug("memc: closed connection", "address", p.address, "reason", "connection failed")
+		_ = conn.Close()
+		p.open--
+		p.discarded++
+		p.notify()
+	case conn.gen != p.gen:
+		p.log.Debug("memc: closed connection", "address", p.address, "reason", "instance addresses changed")
+		_ = conn.Close()
+		p.open--
+		p.discarded++
+		p.notify()
+	case p.available.Size() >= p.idle:
+		p.log.Debug("memc: closed connection", "address", p.address, "reason", "too many idle connections")
 		_ = conn.Close()
+		p.open--
+		p.idleClosed++
 	default:
+		conn.since = time.Now()
+		p.available.Push(conn)
+		p.recount()
+	}
+}
+
+// reap closes each idle connection that has been idle for longer than the
+// idle timeout of p as of now
+func (p *pool) reap(now time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.timeout <= 0 || p.idle == closed {
+		return
+	}
+
+	// the most recently used connections are at the top of the stack, so pop
+	// off every connection and push back only those still fresh, in order
+	fresh := make([]*Buffer, 0, p.available.Size())
+	for !p.available.Empty() {
+		conn := p.available.Pop()
+		if now.Sub(conn.since) >= p.timeout {
+			p.log.Debug("memc: closed connection", "address", p.address, "reason", "idle timeout")
+			_ = conn.Close()
+			p.open--
+			p.idleClosed++
+			continue
+		}
+		fresh = append(fresh, conn)
+	}
+
+	for _, conn := range slices.Backward(fresh) {
 		p.available.Push(conn)
 	}
+	p.recount()
+
+	if len(fresh) < p.minIdle {
+		p.notify()
+	}
+}
+
+// replenish establishes new idle connections until p has its minimum of idle
+// connections, stopping at the first failure
+func (p *pool) replenish(ctx context.Context) {
+	for p.short() {
+		if !p.up(time.Now()) || !p.reserve() {
+			return
+		}
+
+		p.lock.Lock()
+		gen := p.gen
+		p.lock.Unlock()
+
+		conn, err := p.openf(ctx, p.address)
+		p.dialed(err)
+		if err != nil {
+			p.log.Debug("memc: failed to open connection", "address", p.address, "error", err)
+			p.release()
+			return
+		}
+		p.log.Debug("memc: opened connection", "address", p.address, "reason", "minimum idle connections")
+
+		b := newBuffer(conn)
+		b.gen = gen
+		b.owner = p
+		p.free(b)
+	}
+}
+
+// short reports whether p has fewer idle connections than its minimum
+func (p *pool) short() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.idle != closed && !p.multiplex && p.available.Size() < p.minIdle
+}
+
+// reserve reserves one of the open connection slots of p without waiting, and
+// reports whether a slot was available
+func (p *pool) reserve() bool {
+	if p.slots == nil {
+		return true
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
 }
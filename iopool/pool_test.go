@@ -4,9 +4,14 @@
 package iopool
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shoenig/test/must"
 )
@@ -32,45 +37,162 @@ func TestBuffer_SetHealth(t *testing.T) {
 	})
 }
 
+func TestBuffer_Scratch(t *testing.T) {
+	t.Parallel()
+
+	b := newBuffer(nil)
+
+	s := b.Scratch(100)
+	must.SliceLen(t, 100, *s)
+	(*s)[0] = 'x'
+
+	s = b.Scratch(10)
+	must.SliceLen(t, 10, *s)
+	must.Eq(t, 'x', (*s)[0])
+	must.Eq(t, 100, cap(*s))
+
+	s = b.Scratch(200)
+	must.SliceLen(t, 200, *s)
+}
+
+func TestBuffer_Watch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("deadline", func(t *testing.T) {
+		mc := NewMockConn()
+		b := newBuffer(mc)
+
+		deadline := time.Now().Add(time.Hour)
+		ctx, cancel := context.WithDeadline(t.Context(), deadline)
+		defer cancel()
+
+		stop := b.Watch(ctx)
+		must.Eq(t, deadline, mc.deadline)
+
+		must.True(t, stop())
+		must.True(t, mc.deadline.IsZero())
+		must.False(t, b.failure.Load())
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		mc := NewMockConn()
+		b := newBuffer(mc)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		stop := b.Watch(ctx)
+		cancel()
+
+		must.False(t, stop())
+		for !b.failure.Load() {
+			time.Sleep(time.Millisecond)
+		}
+	})
+}
+
+// collection returns a Collection of pools, without any background routines
+func collection(pools ...*pool) *Collection {
+	c := new(Collection)
+	c.store(pools)
+	return c
+}
+
 func TestPool_get(t *testing.T) {
 	t.Parallel()
 
 	t.Run("closed", func(t *testing.T) {
 		p := newPool("10.0.0.1", 1)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
 		)
 		p.idle = closed
-		c, err := p.get()
+		c, err := p.get(t.Context())
 		must.ErrorIs(t, err, ErrClientClosed)
 		must.Nil(t, c)
 	})
 
 	t.Run("normal", func(t *testing.T) {
 		p := newPool("10.0.0.1", 1)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
 		)
-		c, err := p.get()
+		c, err := p.get(t.Context())
 		must.NoError(t, err)
 		must.NotNil(t, c)
 	})
 
 	t.Run("second", func(t *testing.T) {
 		p := newPool("10.0.0.1", 1)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
+			NewMockConn(),
 		)
 
-		c, err := p.get()
+		c, err := p.get(t.Context())
 		must.NoError(t, err)
 		must.NotNil(t, c)
 
-		c, err = p.get()
+		c, err = p.get(t.Context())
 		must.NoError(t, err)
 		must.NotNil(t, c)
 	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		p := newPool("10.0.0.1", 1)
+		p.openf = MockConnections(
+			NewMockConn(),
+		)
+		p.slots = make(chan struct{}, 1)
+		p.wait = 10 * time.Millisecond
+
+		c1, err := p.get(t.Context())
+		must.NoError(t, err)
+
+		_, err = p.get(t.Context())
+		must.ErrorIs(t, err, ErrPoolExhausted)
+
+		// returning the connection makes room for another
+		p.free(c1)
+		c2, err := p.get(t.Context())
+		must.NoError(t, err)
+		must.Eq(t, c1, c2)
+	})
+
+	t.Run("waiting", func(t *testing.T) {
+		p := newPool("10.0.0.1", 1)
+		p.openf = MockConnections(
+			NewMockConn(),
+		)
+		p.slots = make(chan struct{}, 1)
+
+		c1, err := p.get(t.Context())
+		must.NoError(t, err)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			p.free(c1)
+		}()
+
+		c2, err := p.get(t.Context())
+		must.NoError(t, err)
+		must.Eq(t, c1, c2)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		p := newPool("10.0.0.1", 1)
+		p.openf = MockConnections(
+			NewMockConn(),
+		)
+		p.slots = make(chan struct{}, 1)
+
+		_, err := p.get(t.Context())
+		must.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err = p.get(ctx)
+		must.ErrorIs(t, err, context.Canceled)
+	})
 }
 
 func TestPool_free(t *testing.T) {
@@ -78,11 +200,11 @@ func TestPool_free(t *testing.T) {
 
 	t.Run("closed", func(t *testing.T) {
 		p := newPool("10.0.0.1", 1)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
 		)
 
-		c, err := p.get()
+		c, err := p.get(t.Context())
 		must.NoError(t, err)
 
 		p.close()
@@ -92,19 +214,19 @@ func TestPool_free(t *testing.T) {
 
 	t.Run("full", func(t *testing.T) {
 		p := newPool("10.0.0.1", 2)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
-			newMockConn(nil, nil),
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
+			NewMockConn(),
+			NewMockConn(),
 		)
 
-		c1, err1 := p.get()
+		c1, err1 := p.get(t.Context())
 		must.NoError(t, err1)
 
-		c2, err2 := p.get()
+		c2, err2 := p.get(t.Context())
 		must.NoError(t, err2)
 
-		c3, err3 := p.get()
+		c3, err3 := p.get(t.Context())
 		must.NoError(t, err3)
 
 		// totally empty
@@ -125,11 +247,11 @@ func TestPool_free(t *testing.T) {
 
 	t.Run("failure", func(t *testing.T) {
 		p := newPool("10.0.0.1", 2)
-		p.openf = mockConnections(
-			newMockConn(nil, nil),
+		p.openf = MockConnections(
+			NewMockConn(),
 		)
 
-		c, err := p.get()
+		c, err := p.get(t.Context())
 		must.NoError(t, err)
 
 		c.SetHealth(errors.New("oops"))
@@ -141,20 +263,204 @@ func TestPool_free(t *testing.T) {
 	})
 }
 
-func TestCollection_pick_distribution(t *testing.T) {
+func TestPool_reap(t *testing.T) {
+	t.Parallel()
+
+	p := newPool("10.0.0.1", 3)
+	p.timeout = time.Minute
+	p.openf = MockConnections(
+		NewMockConn(),
+		NewMockConn(),
+		NewMockConn(),
+	)
+
+	c1, err := p.get(t.Context())
+	must.NoError(t, err)
+	c2, err := p.get(t.Context())
+	must.NoError(t, err)
+	c3, err := p.get(t.Context())
+	must.NoError(t, err)
+
+	p.free(c1)
+	p.free(c2)
+	p.free(c3)
+
+	c1.since = time.Now().Add(-2 * time.Minute)
+	c2.since = time.Now().Add(-2 * time.Minute)
+
+	p.reap(time.Now())
+	must.Eq(t, 1, p.size())
+	must.Eq(t, c3, p.available.Peek())
+}
+
+func TestPool_resolve(t *testing.T) {
 	t.Parallel()
 
-	c := &Collection{
-		pools: []*pool{
-			{}, {}, {},
+	records := []string{"10.0.0.1"}
+
+	p := newPool("memcached.example:11211", 2)
+	p.lookup = func(_ context.Context, host string) ([]string, error) {
+		must.Eq(t, "memcached.example", host)
+		return slices.Clone(records), nil
+	}
+	p.openf = MockConnections(
+		NewMockConn(),
+		NewMockConn(),
+	)
+
+	c1, err := p.get(t.Context())
+	must.NoError(t, err)
+	c2, err := p.get(t.Context())
+	must.NoError(t, err)
+	p.free(c1)
+
+	// the first lookup establishes the baseline
+	p.resolve(t.Context())
+	must.Eq(t, 1, p.size())
+
+	// an unchanged lookup keeps connections
+	p.resolve(t.Context())
+	must.Eq(t, 1, p.size())
+
+	// a changed lookup closes idle and stale connections
+	records = []string{"10.0.0.2"}
+	p.resolve(t.Context())
+	must.Eq(t, 0, p.size())
+
+	p.free(c2)
+	must.Eq(t, 0, p.size())
+}
+
+func TestCollection_reaper(t *testing.T) {
+	t.Parallel()
+
+	p := newPool("10.0.0.1", 1)
+	p.timeout = 10 * time.Millisecond
+	p.openf = MockConnections(
+		NewMockConn(),
+	)
+
+	c := collection(p)
+	c.stop = make(chan struct{})
+	go c.reaper(5 * time.Millisecond)
+
+	conn, err := p.get(t.Context())
+	must.NoError(t, err)
+	p.free(conn)
+
+	for p.size() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	must.NoError(t, c.Close())
+}
+
+func TestPool_replenish(t *testing.T) {
+	t.Parallel()
+
+	t.Run("fill", func(t *testing.T) {
+		p := newPool("10.0.0.1", 3)
+		p.minIdle = 2
+		p.openf = MockConnections(
+			NewMockConn(),
+			NewMockConn(),
+		)
+
+		p.replenish(t.Context())
+		must.Eq(t, 2, p.size())
+		must.Eq(t, 2, p.stats().Dials)
+
+		// already at the minimum
+		p.replenish(t.Context())
+		must.Eq(t, 2, p.stats().Dials)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		p := newPool("10.0.0.1", 3)
+		p.minIdle = 2
+		p.openf = func(context.Context, string) (Connection, error) {
+			return nil, errors.New("refused")
+		}
+
+		p.replenish(t.Context())
+		must.Eq(t, 0, p.size())
+		must.Eq(t, 1, p.stats().DialFailures)
+	})
+
+	t.Run("slots", func(t *testing.T) {
+		p := newPool("10.0.0.1", 3)
+		p.minIdle = 2
+		p.slots = make(chan struct{}, 1)
+		p.openf = MockConnections(
+			NewMockConn(),
+			NewMockConn(),
+		)
+
+		conn, err := p.get(t.Context())
+		must.NoError(t, err)
+
+		// the only slot is in use
+		p.replenish(t.Context())
+		must.Eq(t, 0, p.size())
+
+		p.free(conn)
+		must.Eq(t, 1, p.size())
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		p := newPool("10.0.0.1", 3)
+		p.minIdle = 2
+		p.close()
+
+		p.replenish(t.Context())
+		must.Eq(t, 0, p.size())
+	})
+}
+
+func TestCollection_maintainer(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"a"}, Config{
+		Idle:    2,
+		MinIdle: 2,
+		Dialer: Dialer{
+			Open: MockConnections(
+				NewMockConn(),
+				NewMockConn(),
+				NewMockConn(),
+			),
 		},
+	})
+	t.Cleanup(func() { _ = c.Close() })
+
+	p := c.state.Load().pools[0]
+	for p.size() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// a failed connection is replaced once discarded
+	conn, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	conn.SetHealth(errors.New("oops"))
+	c.Return("key", conn)
+
+	for p.size() < 2 {
+		time.Sleep(time.Millisecond)
 	}
+	must.Eq(t, 3, p.stats().Dials)
+}
+
+func TestCollection_pick_distribution(t *testing.T) {
+	t.Parallel()
+
+	c := collection(&pool{}, &pool{}, &pool{})
+	top := c.state.Load()
 
 	counts := make(map[int]int)
 
 	for i := 0; i < 1000; i++ {
 		key := fmt.Sprintf("key%d", i)
-		idx := c.pick(key)
+		idx := c.pick(top, key)
 		counts[idx]++
 	}
 
@@ -167,15 +473,13 @@ func TestCollection_GetReturn(t *testing.T) {
 	t.Parallel()
 
 	p := newPool("10.0.0.1", 1)
-	p.openf = mockConnections(
-		newMockConn(nil, nil),
+	p.openf = MockConnections(
+		NewMockConn(),
 	)
 
-	c := &Collection{
-		pools: []*pool{p},
-	}
+	c := collection(p)
 
-	conn, err := c.Get("abc123")
+	conn, err := c.Get(t.Context(), "abc123")
 	must.NoError(t, err)
 
 	c.Return("abc123", conn)
@@ -185,15 +489,13 @@ func TestCollection_GetCloseReturn(t *testing.T) {
 	t.Parallel()
 
 	p := newPool("10.0.0.1", 1)
-	p.openf = mockConnections(
-		newMockConn(nil, nil),
+	p.openf = MockConnections(
+		NewMockConn(),
 	)
 
-	c := &Collection{
-		pools: []*pool{p},
-	}
+	c := collection(p)
 
-	conn, err := c.Get("abc123")
+	conn, err := c.Get(t.Context(), "abc123")
 	must.NoError(t, err)
 
 	err = c.Close()
@@ -201,3 +503,312 @@ func TestCollection_GetCloseReturn(t *testing.T) {
 
 	c.Return("abc123", conn)
 }
+
+func TestCollection_GetInstance(t *testing.T) {
+	t.Parallel()
+
+	p1 := newPool("10.0.0.1", 1)
+	p1.openf = MockConnections(
+		NewMockConn(),
+	)
+
+	p2 := newPool("10.0.0.2", 1)
+	p2.openf = MockConnections(
+		NewMockConn(),
+	)
+
+	c := collection(p1, p2)
+
+	must.Eq(t, []string{"10.0.0.1", "10.0.0.2"}, c.Instances())
+
+	conn, err := c.GetInstance(t.Context(), "10.0.0.2")
+	must.NoError(t, err)
+
+	c.ReturnInstance("10.0.0.2", conn)
+	must.Eq(t, 0, p1.available.Size())
+	must.Eq(t, 1, p2.available.Size())
+
+	_, err = c.GetInstance(t.Context(), "10.0.0.3")
+	must.ErrorIs(t, err, ErrUnknownInstance)
+}
+
+type wrappedConn struct {
+	net.Conn
+}
+
+func TestDialer_open(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	t.Run("plain", func(t *testing.T) {
+		conn, err := Dialer{}.open(t.Context(), ln.Addr().String())
+		must.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, ok := conn.(*net.TCPConn)
+		must.True(t, ok)
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		d := Dialer{
+			Timeout: time.Second,
+			Wrap: func(conn net.Conn) net.Conn {
+				return &wrappedConn{Conn: conn}
+			},
+		}
+
+		conn, err := d.open(t.Context(), ln.Addr().String())
+		must.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, ok := conn.(*wrappedConn)
+		must.True(t, ok)
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		d := Dialer{Timeout: time.Second}
+		_, err := d.open(t.Context(), "/does/not/exist.sock")
+		must.Error(t, err)
+	})
+}
+
+func TestCollection_SetInstances(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"10.0.0.1:11211", "10.0.0.2:11211"}, Config{Idle: 1})
+	t.Cleanup(func() { _ = c.Close() })
+
+	p1, err := c.find("10.0.0.1:11211")
+	must.NoError(t, err)
+	p1.openf = MockConnections(NewMockConn())
+
+	p2, err := c.find("10.0.0.2:11211")
+	must.NoError(t, err)
+	p2.openf = MockConnections(NewMockConn())
+
+	conn, err := c.GetInstance(t.Context(), "10.0.0.2:11211")
+	must.NoError(t, err)
+
+	c.SetInstances([]string{"10.0.0.1:11211", "10.0.0.3:11211", "10.0.0.3:11211"})
+	must.Eq(t, []string{"10.0.0.1:11211", "10.0.0.3:11211"}, c.Instances())
+
+	// the pool of a remaining instance is kept
+	kept, err := c.find("10.0.0.1:11211")
+	must.NoError(t, err)
+	must.Eq(t, p1, kept)
+
+	// a connection of a removed instance is closed once returned
+	c.ReturnInstance("10.0.0.2:11211", conn)
+	must.Eq(t, 0, p2.size())
+	must.Eq(t, closed, p2.idle)
+}
+
+func TestCollection_failover(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"10.0.0.1:11211", "10.0.0.2:11211"}, Config{
+		FailoverThreshold: 2,
+		FailoverCooldown:  time.Hour,
+	})
+	t.Cleanup(func() { _ = c.Close() })
+
+	key := "abc123"
+	primary := c.Instance(key)
+
+	p, err := c.find(primary)
+	must.NoError(t, err)
+	p.openf = func(context.Context, string) (Connection, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	// the first failure does not reach the threshold
+	_, err = c.Get(t.Context(), key)
+	must.Error(t, err)
+	must.Eq(t, primary, c.Instance(key))
+
+	// the second failure does, so the key fails over to the other instance
+	_, err = c.Get(t.Context(), key)
+	must.Error(t, err)
+	must.NotEq(t, primary, c.Instance(key))
+
+	// once the cooldown has passed the instance is tried again
+	must.False(t, p.up(time.Now()))
+	must.True(t, p.up(time.Now().Add(2*time.Hour)))
+
+	// a successful connection resets the failures
+	p.dialed(nil)
+	must.Eq(t, primary, c.Instance(key))
+}
+
+func TestCollection_Open(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"a", "b"}, Config{
+		Idle: 2,
+		Dialer: Dialer{
+			Open: func(context.Context, string) (Connection, error) {
+				return NewMockConn(), nil
+			},
+		},
+	})
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.Zero(t, c.Open())
+
+	conn, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	must.Eq(t, 1, c.Open())
+	must.Zero(t, c.Idle())
+
+	// a returned connection remains open while idle
+	c.Return("key", conn)
+	must.Eq(t, 1, c.Open())
+	must.Eq(t, 1, c.Idle())
+}
+
+func TestCollection_concurrent(t *testing.T) {
+	t.Parallel()
+
+	c := New([]string{"a", "b"}, Config{
+		Idle: 2,
+		Dialer: Dialer{
+			Open: func(context.Context, string) (Connection, error) {
+				return NewMockConn(), nil
+			},
+		},
+	})
+	t.Cleanup(func() { _ = c.Close() })
+
+	// requests proceed while the set of instances changes underneath them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 100 {
+			if i%2 == 0 {
+				c.SetInstances([]string{"a", "b", "c"})
+			} else {
+				c.SetInstances([]string{"a", "b"})
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for j := range 100 {
+				key := fmt.Sprintf("key%d-%d", i, j)
+				conn, err := c.Get(t.Context(), key)
+				if err != nil {
+					continue // the pool of the instance may have just closed
+				}
+				c.Return(key, conn)
+			}
+		})
+	}
+	wg.Wait()
+	<-done
+
+	must.SliceContainsAll(t, []string{"a", "b"}, c.Instances())
+}
+
+func TestCollection_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drain", func(t *testing.T) {
+		mc := NewMockConn()
+		c := New([]string{"a"}, Config{Idle: 1, Dialer: Dialer{Open: MockConnections(mc)}})
+
+		conn, err := c.Get(t.Context(), "key")
+		must.NoError(t, err)
+		time.AfterFunc(20*time.Millisecond, func() { c.Return("key", conn) })
+
+		err = c.Shutdown(t.Context())
+		must.NoError(t, err)
+		must.True(t, mc.Closed())
+
+		_, err = c.Get(t.Context(), "key")
+		must.ErrorIs(t, err, ErrClientClosed)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		mc := NewMockConn()
+		c := New([]string{"a"}, Config{Idle: 1, Dialer: Dialer{Open: MockConnections(mc)}})
+
+		conn, err := c.Get(t.Context(), "key")
+		must.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+
+		// the connection is never returned, so it is closed once ctx is done
+		err = c.Shutdown(ctx)
+		must.ErrorIs(t, err, context.DeadlineExceeded)
+		must.True(t, mc.Closed())
+		must.True(t, conn.failure.Load())
+
+		c.Return("key", conn)
+		must.Eq(t, 0, c.Stats()["a"].Open)
+	})
+}
+
+func TestCollection_Stats(t *testing.T) {
+	t.Parallel()
+
+	dials := 0
+	c := New([]string{"a"}, Config{Idle: 1, MaxOpen: 2, Wait: 10 * time.Millisecond})
+	t.Cleanup(func() { _ = c.Close() })
+
+	p := c.state.Load().pools[0]
+	p.openf = func(context.Context, string) (Connection, error) {
+		dials++
+		if dials == 3 {
+			return nil, errors.New("refused")
+		}
+		return NewMockConn(), nil
+	}
+
+	b1, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	b2, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+
+	// both slots are in use
+	_, err = c.Get(t.Context(), "key")
+	must.ErrorIs(t, err, ErrPoolExhausted)
+
+	stats := c.Stats()["a"]
+	must.Eq(t, 2, stats.MaxOpen)
+	must.Eq(t, 2, stats.Open)
+	must.Eq(t, 2, stats.InUse)
+	must.Eq(t, 0, stats.Idle)
+	must.Eq(t, 1, stats.WaitCount)
+	must.GreaterEq(t, 10*time.Millisecond, stats.WaitDuration)
+	must.Eq(t, 2, stats.Dials)
+
+	// one connection is kept idle, and the other closed
+	b1.SetHealth(nil)
+	c.Return("key", b1)
+	b2.SetHealth(errors.New("broken"))
+	c.Return("key", b2)
+
+	stats = c.Stats()["a"]
+	must.Eq(t, 1, stats.Open)
+	must.Eq(t, 0, stats.InUse)
+	must.Eq(t, 1, stats.Idle)
+	must.Eq(t, 1, stats.Discarded)
+
+	// the idle connection is reused, and a new one fails to dial
+	b3, err := c.Get(t.Context(), "key")
+	must.NoError(t, err)
+	_, err = c.Get(t.Context(), "key")
+	must.Error(t, err)
+	c.Return("key", b3)
+
+	stats = c.Stats()["a"]
+	must.Eq(t, 3, stats.Dials)
+	must.Eq(t, 1, stats.DialFailures)
+	must.Eq(t, 1, stats.Open)
+}
@@ -0,0 +1,75 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"slices"
+	"time"
+)
+
+// An Item is a value stored in memcached along with its metadata.
+type Item[T any] struct {
+	// Key is the key the value is stored under.
+	Key string
+
+	// Value is the value stored under Key.
+	Value T
+
+	// Flags are the flags the value is stored with, as set by the Flags option.
+	Flags int
+
+	// CAS is the CAS token of the value. When set, SetItem stores the value
+	// only if it has not been modified since it was retrieved.
+	CAS CAS
+
+	// Expiration is the expiration TTL of the value. It is used by SetItem,
+	// where a zero Expiration uses the default TTL of the Client, and is not
+	// set by GetItem as memcached does not report it.
+	Expiration time.Duration
+}
+
+// GetItem gets the Item stored under the given key, including its flags and
+// CAS token.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func GetItem[T any](c *Client, key string, opts ...Option) (*Item[T], error) {
+	value, casToken, flags, err := gets[T](c, key, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Item[T]{
+		Key:   key,
+		Value: value,
+		Flags: c.userFlags(flags),
+		CAS:   casToken,
+	}, nil
+}
+
+// SetItem stores item under its key with its flags and expiration TTL. If the
+// CAS token of item is set, item is stored only if the value has not been
+// modified since it was retrieved, otherwise returning ErrConflict, or
+// ErrNotFound if the value no longer exists.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance; options for the flags and expiration TTL are
+// superseded by those of item.
+func SetItem[T any](c *Client, item *Item[T], opts ...Option) error {
+	opts = append(slices.Clip(opts), Flags(item.Flags))
+	if item.Expiration != 0 {
+		opts = append(opts, TTL(item.Expiration))
+	}
+
+	if item.CAS != 0 {
+		return store(c, "cas", item.Key, item.Value, item.CAS, opts)
+	}
+	return store(c, "set", item.Key, item.Value, 0, opts)
+}
@@ -0,0 +1,157 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// maxKeyLength is the maximum length of a key accepted by memcached
+const maxKeyLength = 250
+
+// hashedKeyMarker precedes the hash of a key hashed by SetHashLongKeys, such
+// that hashed keys are recognizable among the keys stored in memcached
+const hashedKeyMarker = "sha256:"
+
+// SetKeyPrefix sets a prefix that is transparently prepended to the key given
+// to every verb, such that multiple applications may share memcached instances
+// without their keys colliding. The prefixed key must itself be a valid key,
+// so the prefix reduces the maximum length of keys given to verbs.
+//
+// Keys are returned to the caller without the prefix, except by MetaDump and
+// Keys which report the keys as stored in memcached.
+//
+// If unset the default is to use keys as is.
+func SetKeyPrefix(prefix string) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.prefix = prefix
+	}
+}
+
+// KeyEncoding is the encoding applied to keys by SetKeyEncoding, such that
+// arbitrary strings may be used as keys.
+type KeyEncoding int
+
+const (
+	// RawKeys are used as is, and must not contain spaces or control
+	// characters.
+	RawKeys KeyEncoding = iota
+
+	// PercentKeys have each space, control character, and "%" replaced by a
+	// "%" followed by the two hex digits of the byte, leaving keys made of
+	// other bytes readable and unchanged.
+	PercentKeys
+
+	// Base64Keys are encoded entirely with unpadded URL-safe base64, which
+	// suits keys made mostly of binary data.
+	Base64Keys
+)
+
+// SetKeyEncoding sets the encoding applied to the key given to every verb,
+// such that keys containing spaces, control characters, or binary data may be
+// used without every caller sanitizing their keys. The key is encoded before
+// the prefix of SetKeyPrefix is prepended, and the encoded key must still be
+// within the key length limit unless SetHashLongKeys is enabled.
+//
+// Keys are returned to the caller as given, except by MetaDump and Keys which
+// report the encoded keys as stored in memcached.
+//
+// If unset the default is to use RawKeys.
+func SetKeyEncoding(encoding KeyEncoding) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.keyEncoding = encoding
+	}
+}
+
+// encodeKey returns key encoded with the configured key encoding
+func (c *Client) encodeKey(key string) string {
+	switch c.keyEncoding {
+	case PercentKeys:
+		return percentKey(key)
+	case Base64Keys:
+		return base64.RawURLEncoding.EncodeToString([]byte(key))
+	default:
+		return key
+	}
+}
+
+// escapeKeyByte reports whether b is percent encoded by PercentKeys
+func escapeKeyByte(b byte) bool {
+	return b <= ' ' || b == 0x7f || b == '%'
+}
+
+// percentKey returns key with each byte escaped by PercentKeys percent encoded,
+// or key itself if there are no such bytes
+func percentKey(key string) string {
+	escapes := 0
+	for i := range len(key) {
+		if escapeKeyByte(key[i]) {
+			escapes++
+		}
+	}
+	if escapes == 0 {
+		return key
+	}
+
+	const digits = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	sb.Grow(len(key) + 2*escapes)
+	for i := range len(key) {
+		b := key[i]
+		if escapeKeyByte(b) {
+			sb.WriteByte('%')
+			sb.WriteByte(digits[b>>4])
+			sb.WriteByte(digits[b&0xf])
+			continue
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
+
+// SetHashLongKeys sets whether keys too long for memcached are transparently
+// replaced by their SHA-256 hash, such that natural keys of any length such as
+// URLs may be used rather than failing with ErrKeyNotValid. A key is hashed if
+// it would be longer than 250 bytes once encoded by SetKeyEncoding and prefixed
+// by SetKeyPrefix, in which
+// case it is stored as the prefix followed by "sha256:" and the hex encoding
+// of the hash. The hashed key may contain any bytes.
+//
+// Keys are returned to the caller as given, except by MetaDump and Keys which
+// report the hashed keys as stored in memcached.
+//
+// If unset the default is to fail with ErrKeyNotValid for keys that are too
+// long.
+func SetHashLongKeys(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.hashLong = enabled
+	}
+}
+
+// key returns the key under which the value of key is stored in memcached, or
+// ErrKeyNotValid if key or the resulting key is not valid
+func (c *Client) key(key string) (string, error) {
+	if key == "" {
+		return "", ErrKeyNotValid
+	}
+	stored := c.prefix + c.encodeKey(key)
+	if c.hashLong && len(stored) > maxKeyLength {
+		sum := sha256.Sum256([]byte(key))
+		stored = c.prefix + hashedKeyMarker + hex.EncodeToString(sum[:])
+	}
+	if err := check(stored); err != nil {
+		return "", err
+	}
+	return stored, nil
+}
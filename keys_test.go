@@ -0,0 +1,122 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestClient_key(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unprefixed", func(t *testing.T) {
+		c := New(nil)
+
+		key, err := c.key("key")
+		must.NoError(t, err)
+		must.Eq(t, "key", key)
+
+		_, err = c.key("")
+		must.ErrorIs(t, err, ErrKeyNotValid)
+	})
+
+	t.Run("prefixed", func(t *testing.T) {
+		c := New(nil, SetKeyPrefix("app:"))
+
+		key, err := c.key("key")
+		must.NoError(t, err)
+		must.Eq(t, "app:key", key)
+
+		_, err = c.key("")
+		must.ErrorIs(t, err, ErrKeyNotValid)
+
+		// the prefixed key must be within the key length limit
+		_, err = c.key(strings.Repeat("a", 246))
+		must.NoError(t, err)
+		_, err = c.key(strings.Repeat("a", 247))
+		must.ErrorIs(t, err, ErrKeyNotValid)
+	})
+
+	t.Run("hashed", func(t *testing.T) {
+		c := New(nil, SetKeyPrefix("app:"), SetHashLongKeys(true))
+
+		// keys within the limit are not hashed
+		short := strings.Repeat("a", 246)
+		key, err := c.key(short)
+		must.NoError(t, err)
+		must.Eq(t, "app:"+short, key)
+
+		long := "https://example.com/" + strings.Repeat("path/", 50) + "?q=a b"
+		key, err = c.key(long)
+		must.NoError(t, err)
+		must.Eq(t, "app:sha256:", key[:11])
+		must.Eq(t, 11+64, len(key))
+
+		again, err := c.key(long)
+		must.NoError(t, err)
+		must.Eq(t, key, again)
+
+		other, err := c.key(long + "/")
+		must.NoError(t, err)
+		must.NotEq(t, key, other)
+
+		// short keys are still checked
+		_, err = c.key("a b")
+		must.ErrorIs(t, err, ErrKeyNotValid)
+	})
+}
+
+func TestClient_key_encoding(t *testing.T) {
+	t.Parallel()
+
+	t.Run("raw", func(t *testing.T) {
+		c := New(nil)
+
+		_, err := c.key("user name")
+		must.ErrorIs(t, err, ErrKeyNotValid)
+	})
+
+	t.Run("percent", func(t *testing.T) {
+		c := New(nil, SetKeyPrefix("app:"), SetKeyEncoding(PercentKeys))
+
+		cases := map[string]string{
+			"plain":         "app:plain",
+			"user name":     "app:user%20name",
+			"100%":          "app:100%25",
+			"line\r\nbreak": "app:line%0D%0Abreak",
+			"tab\tdel\x7f":  "app:tab%09del%7F",
+			"naïve":         "app:naïve",
+		}
+		for key, exp := range cases {
+			stored, err := c.key(key)
+			must.NoError(t, err)
+			must.Eq(t, exp, stored)
+		}
+
+		// escaping "%" keeps encoded keys distinct
+		a, _ := c.key("a b")
+		b, _ := c.key("a%20b")
+		must.NotEq(t, a, b)
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		c := New(nil, SetKeyEncoding(Base64Keys))
+
+		stored, err := c.key("\x00\xff binary")
+		must.NoError(t, err)
+		must.Eq(t, "AP8gYmluYXJ5", stored)
+	})
+
+	t.Run("hashed", func(t *testing.T) {
+		c := New(nil, SetKeyEncoding(PercentKeys), SetHashLongKeys(true))
+
+		// the encoded key is over the limit, though the key itself is not
+		stored, err := c.key(strings.Repeat(" ", 100))
+		must.NoError(t, err)
+		must.Eq(t, "sha256:", stored[:7])
+	})
+}
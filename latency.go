@@ -0,0 +1,114 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets is the number of buckets of a latency histogram, where bucket
+// i counts latencies of less than 2^i microseconds, the last bucket counting
+// any longer latency
+const latencyBuckets = 26
+
+// Latencies is a snapshot of the distribution of the latency of operations on
+// a memcached instance, measured from sending a request on a connection to
+// reading its response.
+//
+// Percentiles are estimated from a histogram with buckets of exponentially
+// increasing size, and are accurate to within a factor of two.
+type Latencies struct {
+	// Count is the number of operations measured.
+	Count int64
+
+	// P50 is the median latency.
+	P50 time.Duration
+
+	// P95 is the 95th percentile latency.
+	P95 time.Duration
+
+	// P99 is the 99th percentile latency.
+	P99 time.Duration
+}
+
+// histogram counts latencies into buckets of exponentially increasing size
+type histogram struct {
+	buckets [latencyBuckets]atomic.Int64
+}
+
+// bucket returns the index of the bucket counting latency d
+func bucket(d time.Duration) int {
+	us := d.Microseconds()
+	if us <= 0 {
+		return 0
+	}
+	return min(bits.Len64(uint64(us)), latencyBuckets-1)
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.buckets[bucket(d)].Add(1)
+}
+
+// snapshot returns the Latencies estimated from the counts of h
+func (h *histogram) snapshot() Latencies {
+	var counts [latencyBuckets]int64
+	var total int64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+
+	// quantile returns the upper bound of the bucket containing quantile q
+	quantile := func(q float64) time.Duration {
+		rank := int64(q * float64(total))
+		var seen int64
+		for i, n := range counts {
+			seen += n
+			if seen > rank {
+				return time.Duration(1<<i) * time.Microsecond
+			}
+		}
+		return time.Duration(1<<(latencyBuckets-1)) * time.Microsecond
+	}
+
+	if total == 0 {
+		return Latencies{}
+	}
+
+	return Latencies{
+		Count: total,
+		P50:   quantile(0.50),
+		P95:   quantile(0.95),
+		P99:   quantile(0.99),
+	}
+}
+
+// latencies tracks a histogram of latencies for each memcached instance
+type latencies struct {
+	histograms sync.Map // address -> *histogram
+}
+
+func (l *latencies) observe(address string, d time.Duration) {
+	h, ok := l.histograms.Load(address)
+	if !ok {
+		h, _ = l.histograms.LoadOrStore(address, new(histogram))
+	}
+	h.(*histogram).observe(d)
+}
+
+// ServerLatencies returns a snapshot of the distribution of the latency of
+// operations on each memcached instance the Client has communicated with, by
+// the address of each instance, e.g. to detect instances that are slower than
+// the rest. Latencies are accumulated from the creation of the Client.
+func (c *Client) ServerLatencies() map[string]Latencies {
+	result := make(map[string]Latencies)
+	c.latencies.histograms.Range(func(address, h any) bool {
+		result[address.(string)] = h.(*histogram).snapshot()
+		return true
+	})
+	return result
+}
@@ -0,0 +1,61 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_bucket(t *testing.T) {
+	t.Parallel()
+
+	must.Eq(t, 0, bucket(0))
+	must.Eq(t, 0, bucket(999*time.Nanosecond))
+	must.Eq(t, 1, bucket(time.Microsecond))
+	must.Eq(t, 2, bucket(3*time.Microsecond))
+	must.Eq(t, 10, bucket(time.Millisecond))
+	must.Eq(t, latencyBuckets-1, bucket(time.Hour))
+}
+
+func Test_histogram_snapshot(t *testing.T) {
+	t.Parallel()
+
+	h := new(histogram)
+	must.Eq(t, Latencies{}, h.snapshot())
+
+	for range 90 {
+		h.observe(100 * time.Microsecond)
+	}
+	for range 9 {
+		h.observe(10 * time.Millisecond)
+	}
+	h.observe(time.Second)
+
+	must.Eq(t, Latencies{
+		Count: 100,
+		P50:   128 * time.Microsecond,
+		P95:   16384 * time.Microsecond,
+		P99:   1048576 * time.Microsecond,
+	}, h.snapshot())
+}
+
+func TestClient_ServerLatencies(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.MapEmpty(t, c.ServerLatencies())
+
+	c.latencies.observe("a:11211", time.Millisecond)
+	c.latencies.observe("a:11211", time.Millisecond)
+	c.latencies.observe("b:11211", time.Second)
+
+	result := c.ServerLatencies()
+	must.MapLen(t, 2, result)
+	must.Eq(t, 2, result["a:11211"].Count)
+	must.Eq(t, 1024*time.Microsecond, result["a:11211"].P99)
+	must.Eq(t, 1048576*time.Microsecond, result["b:11211"].P50)
+}
@@ -0,0 +1,185 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// SetLocalCache enables an in-process LRU cache of at most size values, which
+// is consulted by Get before memcached, absorbing reads of very frequently read
+// keys. Values are kept for at most ttl, bounding how stale a value changed by
+// another process may be, and are removed when changed by this Client.
+//
+// Only values read by Get and GetWithFlags are cached locally. Values stored as
+// chunks are not cached locally.
+//
+// If unset the default is to read every value from memcached.
+func SetLocalCache(size int, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.local = nil
+		if size > 0 && ttl > 0 {
+			c.local = newLocalCache(size, ttl)
+		}
+	}
+}
+
+// TierStats contains the number of hits and misses of reads from one tier of
+// the cache.
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRatio returns the fraction of reads that were hits, or 0 if there were no
+// reads.
+func (s TierStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// CacheStats contains the statistics of reads by Get from the in-process cache
+// enabled by SetLocalCache, and from memcached.
+type CacheStats struct {
+	Local  TierStats
+	Remote TierStats
+}
+
+// CacheStats returns the statistics of reads by Get from each tier of the
+// cache, since the Client was created.
+func (c *Client) CacheStats() CacheStats {
+	return CacheStats{
+		Local:  c.local.stats(),
+		Remote: TierStats{Hits: c.remoteHits.Load(), Misses: c.remoteMisses.Load()},
+	}
+}
+
+// countRemote counts a read by Get from memcached that resulted in err
+func (c *Client) countRemote(err error) {
+	switch {
+	case err == nil:
+		c.remoteHits.Add(1)
+	case errors.Is(err, ErrCacheMiss):
+		c.remoteMisses.Add(1)
+	}
+}
+
+// localEntry is a value in the local cache, as read from memcached
+type localEntry struct {
+	key     string
+	payload []byte
+	flags   int
+	expires time.Time
+}
+
+// localCache is a size and ttl bounded LRU cache of values read from memcached,
+// where a nil localCache caches nothing
+type localCache struct {
+	lock    sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // most recently used at the front
+	hits    uint64
+	misses  uint64
+}
+
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// get returns the payload and flags of key, if cached and not expired by now
+func (l *localCache) get(key string, now time.Time) ([]byte, int, bool) {
+	if l == nil {
+		return nil, 0, false
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	element, exists := l.entries[key]
+	if !exists {
+		l.misses++
+		return nil, 0, false
+	}
+
+	entry := element.Value.(*localEntry)
+	if !now.Before(entry.expires) {
+		l.order.Remove(element)
+		delete(l.entries, key)
+		l.misses++
+		return nil, 0, false
+	}
+
+	l.order.MoveToFront(element)
+	l.hits++
+	return entry.payload, entry.flags, true
+}
+
+// put caches the payload and flags of key, evicting the least recently used
+// value if the cache is full
+func (l *localCache) put(key string, payload []byte, flags int, now time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	entry := &localEntry{key: key, payload: payload, flags: flags, expires: now.Add(l.ttl)}
+
+	if element, exists := l.entries[key]; exists {
+		element.Value = entry
+		l.order.MoveToFront(element)
+		return
+	}
+
+	if l.order.Len() >= l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*localEntry).key)
+	}
+
+	l.entries[key] = l.order.PushFront(entry)
+}
+
+// remove removes key from the cache
+func (l *localCache) remove(key string) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if element, exists := l.entries[key]; exists {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+// stats returns the hits and misses of the cache
+func (l *localCache) stats() TierStats {
+	if l == nil {
+		return TierStats{}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return TierStats{Hits: l.hits, Misses: l.misses}
+}
@@ -0,0 +1,84 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_localCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("nil", func(t *testing.T) {
+		var l *localCache
+		l.put("key", []byte("value"), 0, now)
+		_, _, ok := l.get("key", now)
+		must.False(t, ok)
+		l.remove("key")
+		must.Eq(t, TierStats{}, l.stats())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		l := newLocalCache(2, time.Minute)
+		l.put("key", []byte("value"), 7, now)
+
+		payload, flags, ok := l.get("key", now)
+		must.True(t, ok)
+		must.Eq(t, []byte("value"), payload)
+		must.Eq(t, 7, flags)
+
+		_, _, ok = l.get("other", now)
+		must.False(t, ok)
+		must.Eq(t, TierStats{Hits: 1, Misses: 1}, l.stats())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		l := newLocalCache(2, time.Minute)
+		l.put("key", []byte("value"), 0, now)
+
+		_, _, ok := l.get("key", now.Add(time.Minute))
+		must.False(t, ok)
+		must.MapEmpty(t, l.entries)
+	})
+
+	t.Run("evicted", func(t *testing.T) {
+		l := newLocalCache(2, time.Minute)
+		l.put("a", []byte("1"), 0, now)
+		l.put("b", []byte("2"), 0, now)
+
+		// reading a makes b the least recently used
+		_, _, ok := l.get("a", now)
+		must.True(t, ok)
+
+		l.put("c", []byte("3"), 0, now)
+		_, _, ok = l.get("b", now)
+		must.False(t, ok)
+		_, _, ok = l.get("a", now)
+		must.True(t, ok)
+		_, _, ok = l.get("c", now)
+		must.True(t, ok)
+		must.Eq(t, 2, l.order.Len())
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		l := newLocalCache(2, time.Minute)
+		l.put("key", []byte("value"), 0, now)
+		l.remove("key")
+
+		_, _, ok := l.get("key", now)
+		must.False(t, ok)
+	})
+}
+
+func TestTierStats_HitRatio(t *testing.T) {
+	t.Parallel()
+
+	must.Eq(t, 0, TierStats{}.HitRatio())
+	must.Eq(t, 0.75, TierStats{Hits: 3, Misses: 1}.HitRatio())
+}
@@ -0,0 +1,133 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"crypto/rand"
+	"errors"
+	"slices"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+var (
+	// ErrLocked is returned by TryLock when the lock is already held.
+	ErrLocked = errors.New("memc: lock is held")
+
+	// ErrLockNotHeld is returned by Unlock and Refresh when the lock has
+	// expired or has since been acquired by someone else.
+	ErrLockNotHeld = errors.New("memc: lock is not held")
+)
+
+// Lock is a distributed lock held in memcached, useful for coordinating
+// exclusive work such as scheduled jobs between processes.
+//
+// A Lock is acquired with TryLock, and identified by a random token stored as
+// the value of its key, so that it can only be released or refreshed by its
+// holder. The lock expires automatically after its ttl unless refreshed, such
+// that a crashed holder cannot hold the lock forever.
+//
+// Note that memcached may evict a lock before it expires, so a Lock is not
+// suitable for coordination where correctness depends on mutual exclusion.
+type Lock struct {
+	client *Client
+	key    string
+	token  string
+	ttl    time.Duration
+}
+
+// TryLock attempts to acquire the lock of the given key, which expires after
+// the given ttl unless refreshed. If the lock is already held, ErrLocked is
+// returned.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func TryLock(c *Client, key string, ttl time.Duration, opts ...Option) (*Lock, error) {
+	token := rand.Text()
+
+	err := Add(c, key, token, append(slices.Clip(opts), TTL(ttl))...)
+	switch {
+	case errors.Is(err, ErrNotStored):
+		return nil, ErrLocked
+	case err != nil:
+		return nil, err
+	}
+
+	return &Lock{
+		client: c,
+		key:    key,
+		token:  token,
+		ttl:    ttl,
+	}, nil
+}
+
+// Key returns the key of the lock.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Unlock releases the lock. If the lock has expired or has since been acquired
+// by someone else, ErrLockNotHeld is returned and the lock is left unchanged.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func (l *Lock) Unlock(opts ...Option) error {
+	cas, err := l.held(opts)
+	if err != nil {
+		return err
+	}
+
+	options := l.client.options(opts)
+	key, err := l.client.key(l.key)
+	if err != nil {
+		return err
+	}
+
+	// delete the lock only if it is unchanged since being read
+	defer l.client.local.remove(key)
+	err = l.client.do(options, "delete", key, func(conn *iopool.Buffer) error {
+		return metaDeleteCAS(conn, key, cas)
+	})
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		return ErrLockNotHeld
+	}
+	return err
+}
+
+// Refresh extends the expiration of the lock to the ttl it was acquired with,
+// counted from now. If the lock has expired or has since been acquired by
+// someone else, ErrLockNotHeld is returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func (l *Lock) Refresh(opts ...Option) error {
+	cas, err := l.held(opts)
+	if err != nil {
+		return err
+	}
+
+	err = CompareAndSwap(l.client, l.key, cas, l.token, append(slices.Clip(opts), TTL(l.ttl))...)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) {
+		return ErrLockNotHeld
+	}
+	return err
+}
+
+// held returns the CAS token of the lock, if the lock is still held
+func (l *Lock) held(opts []Option) (CAS, error) {
+	token, cas, err := Gets[string](l.client, l.key, opts...)
+	switch {
+	case errors.Is(err, ErrCacheMiss):
+		return 0, ErrLockNotHeld
+	case err != nil:
+		return 0, err
+	case token != l.token:
+		return 0, ErrLockNotHeld
+	}
+	return cas, nil
+}
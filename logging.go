@@ -0,0 +1,37 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// SetLogger sets the Logger the Client writes debug level logs to, covering
+// the lifecycle of connections, memcached instances being considered down,
+// retries, discovery and reload failures, and operations failing with network
+// or protocol errors.
+//
+// Values are never logged.
+//
+// If unset the default is to discard all logs.
+func SetLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.log = logger
+		if logger == nil {
+			c.log = slog.New(slog.DiscardHandler)
+		}
+	}
+}
+
+// logFailure logs err if it is a failure of the memcached instance or of the
+// connection to it, rather than an outcome such as a cache miss
+func (c *Client) logFailure(err error) {
+	var serr *ServerError
+	if errors.As(err, &serr) {
+		c.log.Debug("memc: operation failed", "op", serr.Op, "address", serr.Addr, "error", serr.Err)
+	}
+}
@@ -0,0 +1,550 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memctest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+const (
+	// maxKeyLength is the maximum length of a key accepted by memcached
+	maxKeyLength = 250
+
+	// maxItemSize is the default item size limit of memcached
+	maxItemSize = 1 << 20
+
+	// maxRelative is the largest expiration memcached treats as a number of
+	// seconds from now rather than a unix timestamp
+	maxRelative = 60 * 60 * 24 * 30
+)
+
+// Fake is an in-memory fake of a memcached instance, implementing the text
+// protocol commands get, gets, set, add, replace, append, prepend, cas, delete,
+// incr, decr, touch, flush_all, stats, version, verbosity, and quit.
+//
+// A Fake is used by a Client configured with memc.SetConnOpener(fake.Open),
+// such that tests run without the memcached executable. Every connection
+// opened by the Client shares the items of the Fake, whatever the address.
+type Fake struct {
+	lock    sync.Mutex
+	items   map[string]*entry
+	unique  uint64
+	offset  time.Duration
+	started time.Time
+	stats   map[string]int
+}
+
+// entry is an item stored in a Fake
+type entry struct {
+	value   []byte
+	flags   uint32
+	expires time.Time // zero if the item never expires
+	cas     uint64
+}
+
+// NewFake returns a Fake with no items.
+func NewFake() *Fake {
+	return &Fake{
+		items:   make(map[string]*entry),
+		started: time.Now(),
+		stats:   make(map[string]int),
+	}
+}
+
+// Open returns a new in-memory connection to f, ignoring the address. Open is
+// meant to be given to memc.SetConnOpener.
+func (f *Fake) Open(context.Context, string) (iopool.Connection, error) {
+	client, server := net.Pipe()
+	go f.Serve(server)
+	return client, nil
+}
+
+// Advance moves the clock of f forward by d, expiring any items whose
+// expiration is reached, without waiting for d to pass.
+func (f *Fake) Advance(d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.offset += d
+}
+
+// now returns the current time of the clock of f
+func (f *Fake) now() time.Time {
+	return time.Now().Add(f.offset)
+}
+
+// Serve responds to the commands read from conn, until conn is closed or the
+// quit command is read, and then closes conn.
+func (f *Fake) Serve(conn io.ReadWriteCloser) {
+	defer func() { _ = conn.Close() }()
+
+	f.count("curr_connections", 1)
+	f.count("total_connections", 1)
+	defer f.count("curr_connections", -1)
+
+	// responses are written separately from commands being read, so that a
+	// client pipelining many commands is never blocked writing them while
+	// responses wait to be read
+	out := newOutbox()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		out.drain(conn)
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		var response bytes.Buffer
+		more := f.handle(r, &response)
+		out.push(response.Bytes())
+		if !more {
+			break
+		}
+	}
+
+	out.close()
+	<-done
+}
+
+// handle reads a single command from r and writes its response into w, and
+// reports whether more commands may be read
+func (f *Fake) handle(r *bufio.Reader, w *bytes.Buffer) bool {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		w.WriteString("ERROR\r\n")
+		return true
+	}
+	cmd, args := fields[0], fields[1:]
+
+	// the data of storage commands is read before the items are locked
+	var data []byte
+	switch cmd {
+	case "set", "add", "replace", "append", "prepend", "cas":
+		var response string
+		if data, response, err = payload(r, cmd, args); err != nil {
+			return false
+		}
+		if response != "" {
+			w.WriteString(response)
+			return true
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch cmd {
+	case "get", "gets":
+		f.get(w, args, cmd == "gets")
+	case "set", "add", "replace", "append", "prepend", "cas":
+		f.store(w, cmd, args, data)
+	case "delete":
+		f.delete(w, args)
+	case "incr", "decr":
+		f.arithmetic(w, cmd, args)
+	case "touch":
+		f.touch(w, args)
+	case "flush_all":
+		f.flush(w, args)
+	case "stats":
+		f.report(w, args)
+	case "version":
+		w.WriteString("VERSION 1.6.0-memctest\r\n")
+	case "verbosity":
+		reply(w, args, "OK\r\n")
+	case "quit":
+		return false
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return true
+}
+
+// reply writes response into w, unless args ends with noreply
+func reply(w *bytes.Buffer, args []string, response string) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return
+	}
+	w.WriteString(response)
+}
+
+// lookup returns the item of key, unless it does not exist or has expired
+func (f *Fake) lookup(key string) *entry {
+	item, exists := f.items[key]
+	if !exists {
+		return nil
+	}
+	if !item.expires.IsZero() && !f.now().Before(item.expires) {
+		delete(f.items, key)
+		return nil
+	}
+	return item
+}
+
+// expiration converts the expiration time of a command into the time at which
+// the item expires
+func (f *Fake) expiration(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	switch {
+	case err != nil:
+		return time.Time{}, err
+	case n == 0:
+		return time.Time{}, nil
+	case n < 0:
+		return f.now(), nil
+	case n <= maxRelative:
+		return f.now().Add(time.Duration(n) * time.Second), nil
+	default:
+		return time.Unix(n, 0), nil
+	}
+}
+
+// next returns the next unique CAS value
+func (f *Fake) next() uint64 {
+	f.unique++
+	return f.unique
+}
+
+// count adds n to the named statistic
+func (f *Fake) count(name string, n int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.stats[name] += n
+}
+
+func (f *Fake) get(w *bytes.Buffer, keys []string, cas bool) {
+	for _, key := range keys {
+		f.stats["cmd_get"]++
+		item := f.lookup(key)
+		if item == nil {
+			f.stats["get_misses"]++
+			continue
+		}
+		f.stats["get_hits"]++
+
+		if cas {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, item.flags, len(item.value), item.cas)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, item.flags, len(item.value))
+		}
+		w.Write(item.value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// payload reads the data block of the storage command cmd, returning the error
+// response to the command instead if the command or data block is malformed
+func payload(r *bufio.Reader, cmd string, args []string) ([]byte, string, error) {
+	required := 4
+	if cmd == "cas" {
+		required = 5
+	}
+	if len(args) < required {
+		return nil, "ERROR\r\n", nil
+	}
+
+	size, err := strconv.Atoi(args[3])
+	if err != nil || size < 0 {
+		return nil, "CLIENT_ERROR bad command line format\r\n", nil
+	}
+
+	data := make([]byte, size+2)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return nil, "", err
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		return nil, "CLIENT_ERROR bad data chunk\r\n", nil
+	}
+	return data[:size], "", nil
+}
+
+func (f *Fake) store(w *bytes.Buffer, cmd string, args []string, data []byte) {
+	key := args[0]
+	flags, ferr := strconv.ParseUint(args[1], 10, 32)
+	expires, eerr := f.expiration(args[2])
+	switch {
+	case len(key) > maxKeyLength, ferr != nil, eerr != nil:
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return
+	case len(data) > maxItemSize:
+		w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		return
+	}
+
+	f.stats["cmd_set"]++
+	item := f.lookup(key)
+	replacement := &entry{value: data, flags: uint32(flags), expires: expires, cas: f.next()}
+
+	switch cmd {
+	case "add":
+		if item != nil {
+			reply(w, args, "NOT_STORED\r\n")
+			return
+		}
+	case "replace":
+		if item == nil {
+			reply(w, args, "NOT_STORED\r\n")
+			return
+		}
+	case "append", "prepend":
+		if item == nil {
+			reply(w, args, "NOT_STORED\r\n")
+			return
+		}
+		// the flags and expiration of the existing item are kept
+		replacement.flags, replacement.expires = item.flags, item.expires
+		if cmd == "append" {
+			replacement.value = append(bytes.Clone(item.value), data...)
+		} else {
+			replacement.value = append(bytes.Clone(data), item.value...)
+		}
+	case "cas":
+		unique, cerr := strconv.ParseUint(args[4], 10, 64)
+		switch {
+		case cerr != nil:
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		case item == nil:
+			f.stats["cas_misses"]++
+			reply(w, args, "NOT_FOUND\r\n")
+			return
+		case item.cas != unique:
+			f.stats["cas_badval"]++
+			reply(w, args, "EXISTS\r\n")
+			return
+		}
+		f.stats["cas_hits"]++
+	}
+
+	f.items[key] = replacement
+	f.stats["total_items"]++
+	reply(w, args, "STORED\r\n")
+}
+
+func (f *Fake) delete(w *bytes.Buffer, args []string) {
+	if len(args) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+
+	if f.lookup(args[0]) == nil {
+		f.stats["delete_misses"]++
+		reply(w, args, "NOT_FOUND\r\n")
+		return
+	}
+
+	delete(f.items, args[0])
+	f.stats["delete_hits"]++
+	reply(w, args, "DELETED\r\n")
+}
+
+func (f *Fake) arithmetic(w *bytes.Buffer, cmd string, args []string) {
+	if len(args) < 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+
+	delta, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		w.WriteString("CLIENT_ERROR invalid numeric delta argument\r\n")
+		return
+	}
+
+	item := f.lookup(args[0])
+	if item == nil {
+		f.stats[cmd+"_misses"]++
+		reply(w, args, "NOT_FOUND\r\n")
+		return
+	}
+
+	value, err := strconv.ParseUint(string(item.value), 10, 64)
+	if err != nil {
+		w.WriteString("CLIENT_ERROR cannot increment or decrement non-numeric value\r\n")
+		return
+	}
+
+	switch {
+	case cmd == "incr":
+		value += delta // wraps around at 64 bits
+	case delta > value:
+		value = 0 // decrementing never goes below 0
+	default:
+		value -= delta
+	}
+
+	f.stats[cmd+"_hits"]++
+	item.value = strconv.AppendUint(nil, value, 10)
+	item.cas = f.next()
+	reply(w, args, strconv.FormatUint(value, 10)+"\r\n")
+}
+
+func (f *Fake) touch(w *bytes.Buffer, args []string) {
+	if len(args) < 2 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+
+	expires, err := f.expiration(args[1])
+	if err != nil {
+		w.WriteString("CLIENT_ERROR invalid exptime argument\r\n")
+		return
+	}
+
+	f.stats["cmd_touch"]++
+	item := f.lookup(args[0])
+	if item == nil {
+		f.stats["touch_misses"]++
+		reply(w, args, "NOT_FOUND\r\n")
+		return
+	}
+
+	f.stats["touch_hits"]++
+	item.expires = expires
+	reply(w, args, "TOUCHED\r\n")
+}
+
+func (f *Fake) flush(w *bytes.Buffer, args []string) {
+	f.stats["cmd_flush"]++
+
+	delay := 0
+	if len(args) > 0 && args[0] != "noreply" {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+		delay = n
+	}
+
+	if delay <= 0 {
+		clear(f.items)
+	} else {
+		// items expire once the delay has passed
+		at := f.now().Add(time.Duration(delay) * time.Second)
+		for _, item := range f.items {
+			if item.expires.IsZero() || item.expires.After(at) {
+				item.expires = at
+			}
+		}
+	}
+
+	reply(w, args, "OK\r\n")
+}
+
+func (f *Fake) report(w *bytes.Buffer, args []string) {
+	if len(args) > 0 {
+		// statistics groups such as slabs and items are not implemented
+		w.WriteString("END\r\n")
+		return
+	}
+
+	bytesUsed, current := 0, 0
+	for key := range f.items {
+		if item := f.lookup(key); item != nil {
+			bytesUsed += len(key) + len(item.value) + 48
+			current++
+		}
+	}
+
+	now := f.now()
+	stat := func(name string, value any) {
+		fmt.Fprintf(w, "STAT %s %v\r\n", name, value)
+	}
+
+	stat("pid", os.Getpid())
+	stat("uptime", int(now.Sub(f.started).Seconds()))
+	stat("time", now.Unix())
+	stat("version", "1.6.0-memctest")
+	stat("pointer_size", 64)
+	stat("threads", 1)
+	stat("max_connections", 1024)
+	stat("curr_connections", f.stats["curr_connections"])
+	stat("total_connections", f.stats["total_connections"])
+	for _, name := range []string{
+		"cmd_get", "cmd_set", "cmd_flush", "cmd_touch",
+		"get_hits", "get_misses", "delete_hits", "delete_misses",
+		"incr_hits", "incr_misses", "decr_hits", "decr_misses",
+		"cas_hits", "cas_misses", "cas_badval", "touch_hits", "touch_misses",
+	} {
+		stat(name, f.stats[name])
+	}
+	stat("limit_maxbytes", 64<<20)
+	stat("bytes", bytesUsed)
+	stat("curr_items", current)
+	stat("total_items", f.stats["total_items"])
+	stat("evictions", 0)
+	w.WriteString("END\r\n")
+}
+
+// outbox is an unbounded queue of responses written to a connection in order
+type outbox struct {
+	lock   sync.Mutex
+	ready  *sync.Cond
+	queue  [][]byte
+	closed bool
+}
+
+func newOutbox() *outbox {
+	o := new(outbox)
+	o.ready = sync.NewCond(&o.lock)
+	return o
+}
+
+// push queues b to be written
+func (o *outbox) push(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.queue = append(o.queue, b)
+	o.ready.Signal()
+}
+
+// close stops drain once every queued response is written
+func (o *outbox) close() {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.closed = true
+	o.ready.Signal()
+}
+
+// drain writes queued responses to w until closed, or until writing fails
+func (o *outbox) drain(w io.Writer) {
+	for {
+		o.lock.Lock()
+		for len(o.queue) == 0 && !o.closed {
+			o.ready.Wait()
+		}
+		if len(o.queue) == 0 {
+			o.lock.Unlock()
+			return
+		}
+		b := o.queue[0]
+		o.queue = o.queue[1:]
+		o.lock.Unlock()
+
+		if _, err := w.Write(b); err != nil {
+			return
+		}
+	}
+}
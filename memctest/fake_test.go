@@ -0,0 +1,114 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memctest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/test/must"
+)
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	fake := memctest.NewFake()
+	c := memc.New([]string{"fake:11211"}, memc.SetConnOpener(fake.Open))
+	t.Cleanup(func() { _ = c.Close() })
+
+	t.Run("set and get", func(t *testing.T) {
+		must.NoError(t, memc.Set(c, "k1", "v1"))
+
+		v, err := memc.Get[string](c, "k1")
+		must.NoError(t, err)
+		must.Eq(t, "v1", v)
+
+		_, err = memc.Get[string](c, "missing")
+		must.ErrorIs(t, err, memc.ErrCacheMiss)
+	})
+
+	t.Run("add and delete", func(t *testing.T) {
+		must.NoError(t, memc.Add(c, "k2", 2))
+		must.ErrorIs(t, memc.Add(c, "k2", 3), memc.ErrNotStored)
+
+		must.NoError(t, memc.Delete(c, "k2"))
+		must.ErrorIs(t, memc.Delete(c, "k2"), memc.ErrNotFound)
+	})
+
+	t.Run("cas", func(t *testing.T) {
+		must.NoError(t, memc.Set(c, "k3", "first"))
+
+		_, token, err := memc.Gets[string](c, "k3")
+		must.NoError(t, err)
+
+		must.NoError(t, memc.CompareAndSwap(c, "k3", token, "second"))
+		must.ErrorIs(t, memc.CompareAndSwap(c, "k3", token, "third"), memc.ErrConflict)
+	})
+
+	t.Run("arithmetic", func(t *testing.T) {
+		must.NoError(t, memc.Set(c, "k4", "10"))
+
+		v, err := memc.Increment(c, "k4", 5)
+		must.NoError(t, err)
+		must.Eq(t, 15, v)
+
+		v, err = memc.Decrement(c, "k4", 20)
+		must.NoError(t, err)
+		must.Eq(t, 0, v)
+
+		_, err = memc.Increment(c, "missing", 1)
+		must.ErrorIs(t, err, memc.ErrNotFound)
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		must.NoError(t, memc.Set(c, "k5", "v5", memc.TTL(time.Minute)))
+		must.NoError(t, memc.Set(c, "k6", "v6", memc.TTL(time.Minute)))
+		must.NoError(t, memc.Touch(c, "k6", time.Hour))
+
+		fake.Advance(2 * time.Minute)
+
+		_, err := memc.Get[string](c, "k5")
+		must.ErrorIs(t, err, memc.ErrCacheMiss)
+
+		v, err := memc.Get[string](c, "k6")
+		must.NoError(t, err)
+		must.Eq(t, "v6", v)
+	})
+
+	t.Run("pipelined", func(t *testing.T) {
+		items := make([]*memc.Pair[string, string], 0, 500)
+		keys := make([]string, 0, 500)
+		for i := range 500 {
+			key := fmt.Sprintf("multi%d", i)
+			items = append(items, &memc.Pair[string, string]{A: key, B: key})
+			keys = append(keys, key)
+		}
+		must.NoError(t, memc.SetMulti(c, items))
+
+		values, err := memc.GetMultiMap[string](c, keys)
+		must.NoError(t, err)
+		must.MapLen(t, len(keys), values)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := c.Stats()
+		must.NoError(t, err)
+		must.MapLen(t, 1, stats)
+		for _, s := range stats {
+			must.Positive(t, s.Items.Current)
+			must.Positive(t, s.Commands.Get)
+		}
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		must.NoError(t, memc.Set(c, "k7", "v7"))
+		must.NoError(t, memc.Flush(c, 0))
+
+		_, err := memc.Get[string](c, "k7")
+		must.ErrorIs(t, err, memc.ErrCacheMiss)
+	})
+}
@@ -0,0 +1,211 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memctest
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+// Proxy is a TCP proxy in front of a memcached instance which injects faults
+// on command, such as latency, dropped connections, truncated responses, and
+// traffic that is never answered, for testing how a client handles failures.
+//
+// Faults apply to the connections already established through the proxy as
+// well as to new connections.
+type Proxy struct {
+	listener net.Listener
+	target   string
+
+	lock       sync.Mutex
+	latency    time.Duration
+	truncating bool
+	truncate   int
+	blackhole  bool
+	conns      map[net.Conn]struct{}
+}
+
+// LaunchProxy starts a Proxy listening on a loopback address, forwarding
+// connections to the memcached instance at target. The Proxy is closed once
+// the test completes.
+func LaunchProxy(t *testing.T, target string) *Proxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+
+	p := &Proxy{
+		listener: listener,
+		target:   target,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	go p.accept()
+
+	t.Cleanup(p.close)
+	return p
+}
+
+// Address returns the address the proxy is listening on, to be given to the
+// client in place of the address of the memcached instance.
+func (p *Proxy) Address() string {
+	return p.listener.Addr().String()
+}
+
+// SetLatency delays every response by d before it is forwarded to the client.
+func (p *Proxy) SetLatency(d time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.latency = d
+}
+
+// Truncate forwards only the first n bytes of the next response of each
+// connection, and then closes the connection, as if the memcached instance
+// crashed while responding, until Reset is called.
+func (p *Proxy) Truncate(n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.truncating = true
+	p.truncate = n
+}
+
+// Blackhole sets whether traffic is silently discarded in both directions,
+// such that connections stay open but commands are never answered, as if the
+// network path to the memcached instance were lost.
+func (p *Proxy) Blackhole(enabled bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.blackhole = enabled
+}
+
+// Drop closes every connection currently established through the proxy, as if
+// the memcached instance restarted. New connections are still accepted.
+func (p *Proxy) Drop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+}
+
+// Reset removes every fault, such that traffic is forwarded as is.
+func (p *Proxy) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.latency = 0
+	p.truncating = false
+	p.blackhole = false
+}
+
+func (p *Proxy) close() {
+	_ = p.listener.Close()
+	p.Drop()
+}
+
+// track records conn as established through the proxy, until untracked
+func (p *Proxy) track(conn net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.conns[conn] = struct{}{}
+}
+
+func (p *Proxy) untrack(conn net.Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	delete(p.conns, conn)
+}
+
+// faults returns the latency and blackhole faults currently in effect
+func (p *Proxy) faults() (time.Duration, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.latency, p.blackhole
+}
+
+// truncated returns the number of bytes of a response to forward before
+// closing the connection, if the truncation fault is in effect
+func (p *Proxy) truncated() (int, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.truncate, p.truncating
+}
+
+func (p *Proxy) accept() {
+	for {
+		client, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.serve(client)
+	}
+}
+
+func (p *Proxy) serve(client net.Conn) {
+	defer func() { _ = client.Close() }()
+
+	server, err := net.Dial("tcp", p.target)
+	if err != nil {
+		return
+	}
+	defer func() { _ = server.Close() }()
+
+	p.track(client)
+	p.track(server)
+	defer p.untrack(client)
+	defer p.untrack(server)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		p.pump(server, client, false)
+		done <- struct{}{}
+	}()
+	go func() {
+		p.pump(client, server, true)
+		done <- struct{}{}
+	}()
+
+	// once either direction stops, both connections are closed
+	<-done
+}
+
+// pump forwards bytes read from src to dst, applying the faults in effect to
+// responses if response is set, until either connection fails
+func (p *Proxy) pump(dst, src net.Conn, response bool) {
+	defer func() {
+		_ = dst.Close()
+		_ = src.Close()
+	}()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			latency, blackhole := p.faults()
+			switch {
+			case blackhole:
+				continue
+			case !response:
+				if _, werr := dst.Write(buf[:n]); werr != nil {
+					return
+				}
+				continue
+			}
+
+			time.Sleep(latency)
+
+			if limit, ok := p.truncated(); ok {
+				_, _ = dst.Write(buf[:min(n, limit)])
+				return
+			}
+
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
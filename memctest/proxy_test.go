@@ -0,0 +1,95 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memctest_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/test/must"
+)
+
+// listenFake serves a Fake over a loopback TCP listener
+func listenFake(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	fake := memctest.NewFake()
+	go func() {
+		for {
+			conn, aerr := listener.Accept()
+			if aerr != nil {
+				return
+			}
+			go fake.Serve(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	proxy := memctest.LaunchProxy(t, listenFake(t))
+
+	c := memc.New([]string{proxy.Address()})
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.NoError(t, memc.Set(c, "key", "value"))
+
+	get := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(t.Context(), timeout)
+		defer cancel()
+		_, err := memc.Get[string](c, "key", memc.Context(ctx))
+		return err
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		defer proxy.Reset()
+
+		proxy.SetLatency(time.Second)
+		must.ErrorIs(t, get(100*time.Millisecond), context.DeadlineExceeded)
+
+		proxy.Reset()
+		must.NoError(t, get(time.Second))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		must.NoError(t, get(time.Second))
+		proxy.Drop()
+
+		// the pooled connection fails, and is replaced by a new connection
+		_ = get(time.Second)
+		must.NoError(t, get(time.Second))
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		defer proxy.Reset()
+
+		proxy.Truncate(5)
+		err := get(time.Second)
+		must.Error(t, err)
+		must.False(t, errors.Is(err, memc.ErrCacheMiss))
+
+		proxy.Reset()
+		must.NoError(t, get(time.Second))
+	})
+
+	t.Run("blackhole", func(t *testing.T) {
+		defer proxy.Reset()
+
+		proxy.Blackhole(true)
+		must.ErrorIs(t, get(100*time.Millisecond), context.DeadlineExceeded)
+
+		proxy.Reset()
+		must.NoError(t, get(time.Second))
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Package server provides a memcached server implemented in Go, listening on
+// a real TCP or Unix socket, so the full client stack including dialing and
+// connection pooling can be tested on machines without memcached installed.
+//
+// The server implements the text protocol as memctest.Fake does, and shares
+// its limitations.
+package server
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc/memctest"
+	"github.com/shoenig/test/must"
+)
+
+// Server is a memcached server listening on a TCP or Unix socket, storing
+// items in memory.
+type Server struct {
+	listener net.Listener
+	fake     *memctest.Fake
+	wg       sync.WaitGroup
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+// Listen creates a Server listening on address, where network is "tcp" or
+// "unix" as for net.Listen. The Server serves connections until closed.
+func Listen(network, address string) (*Server, error) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		fake:     memctest.NewFake(),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// LaunchTCP starts a Server listening on a loopback TCP address, and returns
+// the address. The Server is closed once the test completes.
+func LaunchTCP(t *testing.T) string {
+	return launch(t, "tcp", "127.0.0.1:0").Address()
+}
+
+// LaunchUDS starts a Server listening on a Unix socket in a temporary
+// directory, and returns the path of the socket. The Server is closed once the
+// test completes.
+func LaunchUDS(t *testing.T) string {
+	return launch(t, "unix", filepath.Join(t.TempDir(), "test.sock")).Address()
+}
+
+func launch(t *testing.T, network, address string) *Server {
+	s, err := Listen(network, address)
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// Address returns the address the server is listening on.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Advance moves the clock of the server forward by d, expiring any items whose
+// expiration is reached, without waiting for d to pass.
+func (s *Server) Advance(d time.Duration) {
+	s.fake.Advance(d)
+}
+
+// Close stops the server from accepting connections, closes every connection
+// currently established, and waits for them to finish being served.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		if !s.track(conn) {
+			_ = conn.Close()
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.fake.Serve(conn)
+		}()
+	}
+}
+
+// track records conn as established, unless the server is closed
+func (s *Server) track(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.conns, conn)
+}
@@ -0,0 +1,84 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package server_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc"
+	"cattlecloud.net/go/memc/memctest/server"
+	"github.com/shoenig/test/must"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	launchers := map[string]func(*testing.T) string{
+		"tcp": server.LaunchTCP,
+		"uds": server.LaunchUDS,
+	}
+
+	for name, launch := range launchers {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := memc.New([]string{launch(t)}, memc.SetIdleConnections(2))
+			t.Cleanup(func() { _ = c.Close() })
+
+			must.NoError(t, memc.Set(c, "greeting", "hello"))
+			v, err := memc.Get[string](c, "greeting")
+			must.NoError(t, err)
+			must.Eq(t, "hello", v)
+
+			must.NoError(t, memc.Set(c, "counter", "41"))
+			n, err := memc.Increment[uint64](c, "counter", 1)
+			must.NoError(t, err)
+			must.Eq(t, 42, n)
+
+			must.NoError(t, memc.Delete(c, "greeting"))
+			_, err = memc.Get[string](c, "greeting")
+			must.ErrorIs(t, err, memc.ErrCacheMiss)
+		})
+	}
+}
+
+func TestServer_Advance(t *testing.T) {
+	t.Parallel()
+
+	s, err := server.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	c := memc.New([]string{s.Address()})
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.NoError(t, memc.Set(c, "k", "v", memc.TTL(time.Minute)))
+	s.Advance(2 * time.Minute)
+
+	_, err = memc.Get[string](c, "k")
+	must.ErrorIs(t, err, memc.ErrCacheMiss)
+}
+
+func TestServer_Close(t *testing.T) {
+	t.Parallel()
+
+	s, err := server.Listen("tcp", "127.0.0.1:0")
+	must.NoError(t, err)
+
+	conn, err := net.Dial("tcp", s.Address())
+	must.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	// the established connection is closed along with the server
+	must.NoError(t, s.Close())
+	_, err = conn.Read(make([]byte, 1))
+	must.Error(t, err)
+
+	_, err = net.Dial("tcp", s.Address())
+	must.Error(t, err)
+
+	must.NoError(t, s.Close())
+}
@@ -4,8 +4,18 @@
 package memctest
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
@@ -47,6 +57,12 @@ func waitUntilReady(t *testing.T, ctx scope.C, mode, address string) {
 }
 
 func LaunchTCP(t *testing.T, args []string) (string, func()) {
+	return launchTCP(t, args, nil)
+}
+
+// launchTCP launches memcached on a loopback TCP address, with the environment
+// variables of env in addition to those of the test
+func launchTCP(t *testing.T, args, env []string) (string, func()) {
 	// requires memcached executable on $PATH
 	skip.CommandUnavailable(t, executable)
 
@@ -58,6 +74,9 @@ func LaunchTCP(t *testing.T, args []string) (string, func()) {
 	// start the memcached process
 	ctx, cancel := scope.Cancelable()
 	cmd := exec.CommandContext(ctx, executable, args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	err := cmd.Start()
 	must.NoError(t, err)
 
@@ -92,3 +111,129 @@ func LaunchUDS(t *testing.T, args []string) (string, func()) {
 	// good to go!
 	return socket, cancel
 }
+
+// LaunchTLS launches a memcached instance listening on a loopback TCP address
+// with TLS enabled, using a throwaway self-signed certificate for localhost.
+// The returned tls.Config trusts the certificate, for use by a client.
+//
+// Requires the memcached executable to be built with TLS support, otherwise
+// the test is skipped.
+func LaunchTLS(t *testing.T, args []string) (string, *tls.Config, func()) {
+	// requires memcached executable on $PATH, built with TLS support
+	skip.CommandUnavailable(t, executable)
+	skipWithout(t, "ssl_", "TLS")
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	pool := generateCertificate(t, certFile, keyFile)
+
+	args = append(args, "-Z", "-o", "ssl_chain_cert="+certFile+",ssl_key="+keyFile)
+	address, cancel := LaunchTCP(t, args)
+
+	config := &tls.Config{
+		RootCAs:    pool,
+		ServerName: "localhost",
+		MinVersion: tls.VersionTLS12,
+	}
+	return address, config, cancel
+}
+
+// skipWithout skips the test unless the memcached executable lists option in
+// its help output, which is only listed when built with support for feature
+func skipWithout(t *testing.T, option, feature string) {
+	t.Helper()
+	output, _ := exec.Command(executable, "-h").CombinedOutput()
+	if !bytes.Contains(output, []byte(option)) {
+		t.Skipf("memcached is built without %s support", feature)
+	}
+}
+
+// LaunchAuth launches a memcached instance listening on a loopback TCP address
+// requiring clients to authenticate with the given username and password,
+// using a throwaway authentication file.
+//
+// Authentication uses the ASCII protocol authentication of memcached (the -Y
+// option), where a client authenticates by setting any key to the value
+// "username password" before issuing other commands.
+//
+// Requires the memcached executable to support ASCII authentication, otherwise
+// the test is skipped.
+func LaunchAuth(t *testing.T, args []string, username, password string) (string, func()) {
+	// requires memcached executable on $PATH, supporting an auth file
+	skip.CommandUnavailable(t, executable)
+	skipWithout(t, "auth-file", "ASCII authentication")
+
+	file := filepath.Join(t.TempDir(), "auth")
+	err := os.WriteFile(file, []byte(username+":"+password+"\n"), 0o600)
+	must.NoError(t, err)
+
+	args = append(args, "-Y", file)
+	return LaunchTCP(t, args)
+}
+
+// LaunchSASL launches a memcached instance listening on a loopback TCP address
+// with SASL authentication enabled (the -S option), accepting the PLAIN
+// mechanism for the given username and password, using a throwaway SASL
+// configuration and password database.
+//
+// SASL authentication is only available over the binary protocol of memcached,
+// and the text protocol is refused once enabled.
+//
+// Requires the memcached executable to be built with SASL support, otherwise
+// the test is skipped.
+func LaunchSASL(t *testing.T, args []string, username, password string) (string, func()) {
+	// requires memcached executable on $PATH, built with SASL support
+	skip.CommandUnavailable(t, executable)
+	skipWithout(t, "enable-sasl", "SASL")
+
+	dir := t.TempDir()
+	pwdb := filepath.Join(dir, "sasl-pwdb")
+	err := os.WriteFile(pwdb, []byte(username+":"+password+"\n"), 0o600)
+	must.NoError(t, err)
+
+	conf := filepath.Join(dir, "memcached.conf")
+	err = os.WriteFile(conf, []byte("mech_list: plain\n"), 0o600)
+	must.NoError(t, err)
+
+	args = append(args, "-S")
+	return launchTCP(t, args, []string{
+		"SASL_CONF_PATH=" + dir,
+		"MEMCACHED_SASL_PWDB=" + pwdb,
+	})
+}
+
+// generateCertificate writes a self-signed certificate for localhost and its
+// private key into PEM files, and returns a pool containing the certificate
+func generateCertificate(t *testing.T, certFile, keyFile string) *x509.CertPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	must.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	must.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	must.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	must.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	must.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	pool := x509.NewCertPool()
+	must.True(t, pool.AppendCertsFromPEM(certPEM))
+	return pool
+}
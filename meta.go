@@ -0,0 +1,321 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// Protocol indicates which memcached wire protocol a Client uses for sending
+// commands to memcached instances.
+type Protocol int
+
+const (
+	// Text is the classic memcached text protocol (get, set, delete, etc.).
+	Text Protocol = iota
+
+	// Meta is the memcached meta text protocol (mg, ms, md, ma), which is
+	// supported by memcached 1.6 and later.
+	Meta
+)
+
+// SetProtocol sets the wire protocol used for the Get, Gets, Set, Add,
+// Replace, Append, Prepend, CompareAndSwap, Delete, Touch, Increment, and
+// Decrement commands. Other commands always use the classic text protocol.
+//
+// If unset the default is to use the Text protocol.
+func SetProtocol(p Protocol) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.protocol = p
+	}
+}
+
+// modes maps storage commands onto the mode flag of the meta set command
+var modes = map[string]string{
+	"set":     "S",
+	"add":     "A",
+	"replace": "R",
+	"append":  "E",
+	"prepend": "P",
+	"cas":     "S",
+}
+
+func metaFetch(conn *iopool.Buffer, key string, cas bool, p payloads) (*[]byte, header, error) {
+	// request the value and client flags, and the cas unique if necessary
+	request := "v f"
+	if cas {
+		request = "v f c"
+	}
+
+	// write the header components
+	if _, err := fmt.Fprintf(conn, "mg %s %s\r\n", key, request); err != nil {
+		return nil, header{}, err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return nil, header{}, err
+	}
+
+	line, err := conn.ReadSlice('\n')
+	if err != nil {
+		return nil, header{}, err
+	}
+
+	// key was not found, is a cache miss
+	if string(line) == "EN\r\n" {
+		return nil, header{}, ErrCacheMiss
+	}
+
+	h, herr := parseMetaHeader(key, line)
+	if herr != nil {
+		return nil, header{}, herr
+	}
+
+	// read the data into our payload
+	payload := p.get(h.size + 2) // including \r\n
+	if _, err = io.ReadFull(conn, *payload); err != nil {
+		p.put(payload)
+		return nil, header{}, err
+	}
+	*payload = (*payload)[0:h.size] // chop \r\n
+
+	return payload, h, nil
+}
+
+// parseMetaHeader parses the line preceding a meta get value payload, in the
+// form "VA <bytes> <flags>*\r\n"
+func parseMetaHeader(key string, b []byte) (header, error) {
+	name, rest := field(trimLine(b))
+	n, rest := field(rest)
+	if string(name) != "VA" {
+		return header{}, unexpected(b)
+	}
+
+	size, ok := parseInt(n)
+	if !ok {
+		return header{}, unexpected(b)
+	}
+
+	h := header{
+		key:  key,
+		size: size,
+	}
+
+	for {
+		var flag []byte
+		if flag, rest = field(rest); len(flag) == 0 {
+			break
+		}
+
+		ok = true
+		switch flag[0] {
+		case 'f':
+			h.flags, ok = parseInt(flag[1:])
+		case 'c':
+			h.cas, ok = parseUint(flag[1:])
+		case 'k':
+			h.key = keyOf(key, flag[1:])
+		}
+		if !ok {
+			return header{}, unexpected(b)
+		}
+	}
+
+	return h, nil
+}
+
+func metaFetchMulti(conn *iopool.Buffer, keys []string, p payloads, f func(header, []byte)) error {
+	// write a quiet meta get for each key, which only responds on a hit,
+	// followed by a meta no-op to mark the end of the responses
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(conn, "mg %s v f k q\r\n", key); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(conn, "mn\r\n"); err != nil {
+		return err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	// read each value until the no-op response ("MN\r\n")
+	for {
+		line, err := conn.ReadSlice('\n')
+		if err != nil {
+			return err
+		}
+
+		if string(line) == "MN\r\n" {
+			return nil
+		}
+
+		h, herr := parseMetaHeader("", line)
+		if herr != nil {
+			return herr
+		}
+
+		if err = readValue(conn, h, p, f); err != nil {
+			return err
+		}
+	}
+}
+
+func metaWriteStore(conn *iopool.Buffer, cmd, key string, flags, expiration int, cas CAS, encoding []byte) error {
+	// write the header components, with the CAS token if necessary
+	if _, err := fmt.Fprintf(
+		conn,
+		"ms %s %d T%d F%d M%s",
+		key, len(encoding), expiration, flags, modes[cmd],
+	); err != nil {
+		return err
+	}
+	if cmd == "cas" {
+		if _, err := fmt.Fprintf(conn, " C%d", cas); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(conn, "\r\n"); err != nil {
+		return err
+	}
+
+	// write the payload
+	if _, err := conn.Write(encoding); err != nil {
+		return err
+	}
+
+	// write clrf
+	_, err := io.WriteString(conn, "\r\n")
+	return err
+}
+
+func metaStoreResult(cmd string, line []byte) error {
+	switch string(line) {
+	case "HD\r\n":
+		return nil
+	case "NS\r\n":
+		return ErrNotStored
+	case "NF\r\n":
+		return ErrNotFound
+	case "EX\r\n":
+		return ErrConflict
+	default:
+		return fmt.Errorf("memc: unexpected response to %s: %s", cmd, summarize(line))
+	}
+}
+
+func metaDelete(conn *iopool.Buffer, key string) error {
+	return metaDeleteCAS(conn, key, 0)
+}
+
+// metaDeleteCAS deletes key only if its CAS token matches cas, unless cas is
+// zero in which case key is deleted unconditionally
+func metaDeleteCAS(conn *iopool.Buffer, key string, cas CAS) error {
+	var err error
+	if cas == 0 {
+		_, err = fmt.Fprintf(conn, "md %s\r\n", key)
+	} else {
+		_, err = fmt.Fprintf(conn, "md %s C%d\r\n", key, cas)
+	}
+	if err != nil {
+		return err
+	}
+
+	// flush the buffer
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	line, lerr := conn.ReadSlice('\n')
+	if lerr != nil {
+		return lerr
+	}
+
+	switch string(line) {
+	case "HD\r\n":
+		return nil
+	case "NF\r\n":
+		return ErrNotFound
+	case "EX\r\n":
+		return ErrConflict
+	default:
+		return unexpected(line)
+	}
+}
+
+func metaTouch(conn *iopool.Buffer, key string, expiration int) error {
+	if _, err := fmt.Fprintf(conn, "mg %s T%d\r\n", key, expiration); err != nil {
+		return err
+	}
+
+	// flush the buffer
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	line, lerr := conn.ReadSlice('\n')
+	if lerr != nil {
+		return lerr
+	}
+
+	switch string(line) {
+	case "HD\r\n":
+		return nil
+	case "EN\r\n":
+		return ErrNotFound
+	default:
+		return unexpected(line)
+	}
+}
+
+func metaWriteArithmetic(conn *iopool.Buffer, cmd, key string, delta uint64) error {
+	mode := "I"
+	if cmd == "decr" {
+		mode = "D"
+	}
+
+	_, err := fmt.Fprintf(conn, "ma %s M%s D%d v\r\n", key, mode, delta)
+	return err
+}
+
+func metaReadArithmetic(conn *iopool.Buffer) (uint64, error) {
+	line, lerr := conn.ReadSlice('\n')
+	if lerr != nil {
+		return 0, lerr
+	}
+
+	// check for error response
+	s := string(line)
+	switch {
+	case s == "NF\r\n":
+		return 0, ErrNotFound
+	case strings.Contains(s, "cannot increment or decrement non-numeric value"):
+		return 0, ErrNonNumeric
+	case !strings.HasPrefix(s, "VA "):
+		return 0, unexpected(line)
+	}
+
+	// read the line containing the resulting value
+	value, verr := conn.ReadSlice('\n')
+	if verr != nil {
+		return 0, verr
+	}
+
+	u, uerr := strconv.ParseUint(strings.TrimSpace(string(value)), 10, 64)
+	if uerr != nil {
+		return 0, unexpected(value)
+	}
+
+	return u, nil
+}
@@ -0,0 +1,36 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_parseMetaHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("value", func(t *testing.T) {
+		h, err := parseMetaHeader("mykey", []byte("VA 12\r\n"))
+		must.NoError(t, err)
+		must.Eq(t, header{key: "mykey", size: 12}, h)
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		h, err := parseMetaHeader("mykey", []byte("VA 5 f3 c9001\r\n"))
+		must.NoError(t, err)
+		must.Eq(t, header{key: "mykey", flags: 3, size: 5, cas: 9001}, h)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := parseMetaHeader("mykey", []byte("VA 5 fzero\r\n"))
+		must.Error(t, err)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		_, err := parseMetaHeader("mykey", []byte("SERVER_ERROR out of memory\r\n"))
+		must.Error(t, err)
+	})
+}
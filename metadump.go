@@ -0,0 +1,172 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// KeyMeta describes a key stored on a memcached instance, as reported by the
+// "lru_crawler metadump" command.
+type KeyMeta struct {
+	// Key is the key of the item.
+	Key string
+
+	// Address is the address of the memcached instance the item is stored on.
+	Address string
+
+	// Expiration is when the item expires, or the zero Time if the item never
+	// expires.
+	Expiration time.Time
+
+	// LastAccess is when the item was last accessed.
+	LastAccess time.Time
+
+	// CAS is the CAS token of the item.
+	CAS CAS
+
+	// Fetched reports whether the item has been fetched since it was stored.
+	Fetched bool
+
+	// Class is the slab class the item is stored in.
+	Class int
+
+	// Size is the total size of the item in bytes, including its metadata.
+	Size int
+}
+
+// MetaDump calls f with the metadata of every key stored on each memcached
+// instance, using the LRU crawler of memcached, until f returns false. This is
+// useful for auditing the contents of a cache, or selectively invalidating
+// keys, but is expensive and should not be used on the hot path.
+//
+// Keys are listed as the crawler visits them, and keys stored or removed while
+// the crawler is running may or may not be listed.
+//
+// Errors are accumulated using errors.Join, and keys of instances that did
+// respond are still listed.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) MetaDump(f func(*KeyMeta) bool, opts ...Option) error {
+	options := c.options(opts)
+
+	var errs []error
+	more := true
+	for _, address := range c.instances() {
+		if !more {
+			break
+		}
+
+		err := c.doInstance(options.ctx, "metadump", address, func(conn *iopool.Buffer) error {
+			if _, err := fmt.Fprint(conn, "lru_crawler metadump all\r\n"); err != nil {
+				return err
+			}
+
+			// flush the connection, forcing bytes over the wire
+			if err := conn.Flush(); err != nil {
+				return err
+			}
+
+			for {
+				line, lerr := conn.ReadSlice('\n')
+				if lerr != nil {
+					return lerr
+				}
+
+				switch {
+				case string(line) == "END\r\n":
+					return nil
+				case !strings.HasPrefix(string(line), "key="):
+					return unexpected(line)
+				case !more:
+					// drain the remaining keys, keeping conn usable
+					continue
+				}
+
+				meta, merr := parseKeyMeta(string(line))
+				if merr != nil {
+					return merr
+				}
+				meta.Address = address
+				more = f(meta)
+			}
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Keys returns every key stored on each memcached instance, using MetaDump.
+//
+// Errors are accumulated using errors.Join, and keys of instances that did
+// respond are still returned.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func (c *Client) Keys(opts ...Option) ([]string, error) {
+	var keys []string
+	err := c.MetaDump(func(meta *KeyMeta) bool {
+		keys = append(keys, meta.Key)
+		return true
+	}, opts...)
+	return keys, err
+}
+
+// parseKeyMeta parses a line of the response to "lru_crawler metadump", e.g.
+//
+// "key=user%3A42 exp=-1 la=1769190296 cas=7 fetch=no cls=1 size=63\r\n"
+func parseKeyMeta(line string) (*KeyMeta, error) {
+	meta := new(KeyMeta)
+	for field := range strings.FieldsSeq(line) {
+		name, value, _ := strings.Cut(field, "=")
+
+		var err error
+		switch name {
+		case "key":
+			meta.Key, err = url.QueryUnescape(value)
+		case "exp":
+			meta.Expiration, err = unixTime(value)
+		case "la":
+			meta.LastAccess, err = unixTime(value)
+		case "cas":
+			var cas uint64
+			cas, err = strconv.ParseUint(value, 10, 64)
+			meta.CAS = CAS(cas)
+		case "fetch":
+			meta.Fetched = value == "yes"
+		case "cls":
+			meta.Class, err = strconv.Atoi(value)
+		case "size":
+			meta.Size, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("memc: unable to parse metadump field %q: %w", field, err)
+		}
+	}
+	return meta, nil
+}
+
+// unixTime parses the unix timestamp of value, where -1 indicates no time
+func unixTime(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	switch {
+	case err != nil:
+		return time.Time{}, err
+	case seconds < 0:
+		return time.Time{}, nil
+	default:
+		return time.Unix(seconds, 0), nil
+	}
+}
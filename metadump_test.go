@@ -0,0 +1,35 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_parseKeyMeta(t *testing.T) {
+	t.Parallel()
+
+	meta, err := parseKeyMeta("key=user%3A42%2Fprofile exp=1769193896 la=1769190296 cas=7 fetch=yes cls=3 size=163\r\n")
+	must.NoError(t, err)
+	must.Eq(t, &KeyMeta{
+		Key:        "user:42/profile",
+		Expiration: time.Unix(1769193896, 0),
+		LastAccess: time.Unix(1769190296, 0),
+		CAS:        7,
+		Fetched:    true,
+		Class:      3,
+		Size:       163,
+	}, meta)
+
+	meta, err = parseKeyMeta("key=forever exp=-1 la=1769190296 cas=8 fetch=no cls=1 size=63\r\n")
+	must.NoError(t, err)
+	must.True(t, meta.Expiration.IsZero())
+	must.False(t, meta.Fetched)
+
+	_, err = parseKeyMeta("key=bad exp=never\r\n")
+	must.ErrorContains(t, err, `"exp=never"`)
+}
@@ -0,0 +1,107 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Interface is the method based API of Client, for code that would rather
+// depend on an interface than on the generic package functions, such that a
+// fake or mock implementation can be injected in tests.
+//
+// Each method is equivalent to the package function of the same verb, given
+// the type of value in its name.
+type Interface interface {
+	GetBytes(key string, opts ...Option) ([]byte, error)
+	GetString(key string, opts ...Option) (string, error)
+	GetJSON(key string, v any, opts ...Option) error
+	SetBytes(key string, value []byte, opts ...Option) error
+	SetString(key string, value string, opts ...Option) error
+	SetJSON(key string, v any, opts ...Option) error
+	AddBytes(key string, value []byte, opts ...Option) error
+	Delete(key string, opts ...Option) error
+	Touch(key string, ttl time.Duration, opts ...Option) error
+	Increment(key string, delta uint64, opts ...Option) (uint64, error)
+	Decrement(key string, delta uint64, opts ...Option) (uint64, error)
+}
+
+var _ Interface = (*Client)(nil)
+
+// GetBytes gets the value associated with the given key as a []byte, as with
+// Get.
+func (c *Client) GetBytes(key string, opts ...Option) ([]byte, error) {
+	return Get[[]byte](c, key, opts...)
+}
+
+// GetString gets the value associated with the given key as a string, as with
+// Get.
+func (c *Client) GetString(key string, opts ...Option) (string, error) {
+	return Get[string](c, key, opts...)
+}
+
+// GetJSON gets the value associated with the given key, and unmarshals it as
+// JSON into v. A value that is not valid JSON fails with ErrEncoding.
+func (c *Client) GetJSON(key string, v any, opts ...Option) error {
+	b, err := Get[[]byte](c, key, opts...)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w: %w", ErrEncoding, err)
+	}
+	return nil
+}
+
+// SetBytes stores value using the given key, as with Set.
+func (c *Client) SetBytes(key string, value []byte, opts ...Option) error {
+	return Set(c, key, value, opts...)
+}
+
+// SetString stores value using the given key, as with Set.
+func (c *Client) SetString(key string, value string, opts ...Option) error {
+	return Set(c, key, value, opts...)
+}
+
+// SetJSON marshals v as JSON, and stores the JSON using the given key as with
+// Set. The value may be read by GetJSON, or as raw bytes by GetBytes.
+func (c *Client) SetJSON(key string, v any, opts ...Option) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return Set(c, key, b, opts...)
+}
+
+// AddBytes stores value using the given key only if no value is associated
+// with the key, as with Add.
+func (c *Client) AddBytes(key string, value []byte, opts ...Option) error {
+	return Add(c, key, value, opts...)
+}
+
+// Delete removes the value associated with the given key, as with the package
+// function Delete.
+func (c *Client) Delete(key string, opts ...Option) error {
+	return Delete(c, key, opts...)
+}
+
+// Touch updates the expiration time of the value associated with the given
+// key, as with the package function Touch.
+func (c *Client) Touch(key string, ttl time.Duration, opts ...Option) error {
+	return Touch(c, key, ttl, opts...)
+}
+
+// Increment adds delta to the counter associated with the given key, as with
+// the package function Increment.
+func (c *Client) Increment(key string, delta uint64, opts ...Option) (uint64, error) {
+	return Increment(c, key, delta, opts...)
+}
+
+// Decrement subtracts delta from the counter associated with the given key, as
+// with the package function Decrement.
+func (c *Client) Decrement(key string, delta uint64, opts ...Option) (uint64, error) {
+	return Decrement(c, key, delta, opts...)
+}
@@ -0,0 +1,73 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"time"
+)
+
+// A MetricsSink receives measurements of internal events from a Client, so
+// that the events may be forwarded into an arbitrary telemetry system.
+//
+// Metric names are of the form "memc.<command>.<event>", for example
+// "memc.get.calls" or "memc.set.errors".
+//
+// Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	// Count increments the counter of name by n.
+	Count(name string, n int64)
+
+	// Gauge records the current value of name.
+	Gauge(name string, value float64)
+
+	// Timing records the elapsed duration of an event of name.
+	Timing(name string, elapsed time.Duration)
+}
+
+type noopSink struct{}
+
+func (noopSink) Count(string, int64)          {}
+func (noopSink) Gauge(string, float64)        {}
+func (noopSink) Timing(string, time.Duration) {}
+
+// SetMetricsSink sets the MetricsSink the Client will report internal events
+// to, such as the number of calls, errors, and cache misses of each command,
+// the time taken for each command, and the number of idle and open connections.
+//
+// If unset the default is to discard all metrics.
+func SetMetricsSink(sink MetricsSink) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.metrics = sink
+	}
+}
+
+// reportPool reports the number of idle and open connections to the
+// MetricsSink, unless metrics are discarded, as counting them visits the pool
+// of every instance
+func (c *Client) reportPool() {
+	if _, discard := c.metrics.(noopSink); discard {
+		return
+	}
+	c.metrics.Gauge("memc.pool.idle", float64(c.pools.Idle()))
+	c.metrics.Gauge("memc.pool.open", float64(c.pools.Open()))
+}
+
+// record reports the outcome of executing command op to the MetricsSink
+func (c *Client) record(op string, start time.Time, err error) {
+	prefix := "memc." + op
+	c.metrics.Count(prefix+".calls", 1)
+	c.metrics.Timing(prefix+".duration", c.now().Sub(start))
+
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, ErrCacheMiss):
+		c.metrics.Count(prefix+".misses", 1)
+	default:
+		c.metrics.Count(prefix+".errors", 1)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+type testSink struct {
+	lock    sync.Mutex
+	counts  map[string]int64
+	gauges  map[string]float64
+	timings map[string]int
+}
+
+func newTestSink() *testSink {
+	return &testSink{
+		counts:  make(map[string]int64),
+		gauges:  make(map[string]float64),
+		timings: make(map[string]int),
+	}
+}
+
+func (s *testSink) Count(name string, n int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.counts[name] += n
+}
+
+func (s *testSink) Gauge(name string, value float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.gauges[name] = value
+}
+
+func (s *testSink) Timing(name string, _ time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timings[name]++
+}
+
+func TestClient_record(t *testing.T) {
+	t.Parallel()
+
+	sink := newTestSink()
+	c := New(nil, SetMetricsSink(sink))
+
+	start := c.now()
+	c.record("get", start, nil)
+	c.record("get", start, ErrCacheMiss)
+	c.record("set", start, errors.New("oops"))
+
+	must.Eq(t, 2, sink.counts["memc.get.calls"])
+	must.Eq(t, 1, sink.counts["memc.get.misses"])
+	must.Eq(t, 0, sink.counts["memc.get.errors"])
+	must.Eq(t, 1, sink.counts["memc.set.calls"])
+	must.Eq(t, 1, sink.counts["memc.set.errors"])
+	must.Eq(t, 2, sink.timings["memc.get.duration"])
+}
+
+func TestClient_reportPool(t *testing.T) {
+	t.Parallel()
+
+	sink := newTestSink()
+	c := New(nil, SetMetricsSink(sink))
+
+	c.reportPool()
+	must.MapContainsKey(t, sink.gauges, "memc.pool.idle")
+	must.MapContainsKey(t, sink.gauges, "memc.pool.open")
+}
@@ -3,7 +3,19 @@
 
 package memc
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"cattlecloud.net/go/memc/iopool"
+)
 
 // A Pair associates two elements.
 type Pair[T, U any] struct {
@@ -15,6 +27,10 @@ type Pair[T, U any] struct {
 // possibly overwritting any existing data. New items are at the top of the
 // LRU.
 //
+// Items are grouped by the memcached instance they are stored on, and the
+// commands for each group are pipelined, writing many commands before reading
+// their responses.
+//
 // Errors are accumulated using errors.Join.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
@@ -23,19 +39,17 @@ type Pair[T, U any] struct {
 // One or more Option(s) may be applied to configure things such as the
 // value expiration TTL or its associated flags.
 func SetMulti[T any](c *Client, items []*Pair[string, T], opts ...Option) error {
-	var errs []error
-	for _, item := range items {
-		if err := Set(c, item.A, item.B, opts...); err != nil {
-			errs = append(errs, err)
-		}
-	}
-	return errors.Join(errs...)
+	return storeMulti(c, "set", items, opts)
 }
 
 // AddMulti will store each item in items using the item's associated key,
 // but only if the item does not currently exist. New items are at the top of
 // the LRU.
 //
+// Items are grouped by the memcached instance they are stored on, and the
+// commands for each group are pipelined, writing many commands before reading
+// their responses.
+//
 // Errors are accumulated using errors.Join.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
@@ -44,29 +58,517 @@ func SetMulti[T any](c *Client, items []*Pair[string, T], opts ...Option) error
 // One or more Option(s) may be applied to configure things such as the
 // value expiration TTL or its associated flags.
 func AddMulti[T any](c *Client, items []*Pair[string, T], opts ...Option) error {
-	var errs []error
-	for _, item := range items {
-		if err := Add(c, item.A, item.B, opts...); err != nil {
-			errs = append(errs, err)
+	return storeMulti(c, "add", items, opts)
+}
+
+// pipelineWindow is the maximum number of commands written to a connection
+// before reading their responses, so that neither side of the connection
+// blocks on a full socket buffer
+const pipelineWindow = 128
+
+// storeMulti executes the storage command cmd for each item, pipelining the
+// commands sent to each memcached instance
+func storeMulti[T any](c *Client, cmd string, items []*Pair[string, T], opts []Option) error {
+	options := c.options(opts)
+
+	expiration, experr := c.expiry(options)
+	if experr != nil {
+		return experr
+	}
+
+	errs := make([]error, len(items))
+	keys := make([]string, len(items))
+	encodings := make([][]byte, len(items))
+	flags := make([]int, len(items))
+
+	var pending []int
+	for i, item := range items {
+		key, err := c.key(item.A)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		// the encoding is referenced until every command has been written
+		scratch := getScratch()
+		defer putScratch(scratch)
+
+		encoding, flag, encerr := c.packInto(scratch, key, item.B, options.flags)
+		if encerr == nil && c.oversized(cmd, len(encoding)) {
+			encoding, flag, encerr = c.chunk(options.ctx, encoding, flag, expiration)
+		}
+		if encerr != nil {
+			errs[i] = encerr
+			continue
 		}
+		keys[i] = key
+		encodings[i] = encoding
+		flags[i] = flag
+		pending = append(pending, i)
+	}
+
+	results := c.pipelineStore(options.ctx, options.server, cmd,
+		pick(keys, pending), pick(encodings, pending), pick(flags, pending),
+		slices.Repeat([]int{expiration}, len(pending)),
+	)
+	for _, i := range pending {
+		c.local.remove(keys[i])
+	}
+	for j, i := range pending {
+		errs[i] = results[j]
 	}
+
 	return errors.Join(errs...)
 }
 
+// pick returns the elements of s at the given positions
+func pick[E any](s []E, positions []int) []E {
+	result := make([]E, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, s[i])
+	}
+	return result
+}
+
+// pipelineStore executes the storage command cmd for each of the encoded values
+// of keys, with the flags and expiration of each, pipelining the commands sent
+// to each memcached instance, or only to server if not empty, and returns the
+// error of each command
+func (c *Client) pipelineStore(ctx context.Context, server, cmd string, keys []string, encodings [][]byte, flags, expirations []int) []error {
+	errs := make([]error, len(keys))
+	responded := make([]bool, len(keys))
+
+	// group the position of each key by the instance the key is stored on
+	groups := make(map[string][]int)
+	for i, key := range keys {
+		address := c.route(server, key)
+		groups[address] = append(groups[address], i)
+	}
+
+	fanOut(c, groups, func(address string, positions []int) {
+		err := c.doInstance(ctx, cmd+"_multi", address, func(conn *iopool.Buffer) error {
+			for window := range slices.Chunk(positions, pipelineWindow) {
+				// write each command of the window
+				for _, i := range window {
+					if err := c.writeStore(conn, cmd, keys[i], flags[i], expirations[i], 0, encodings[i]); err != nil {
+						return err
+					}
+				}
+
+				// flush the buffer
+				if err := conn.Flush(); err != nil {
+					return err
+				}
+
+				// read the response to each command of the window
+				for _, i := range window {
+					line, lerr := conn.ReadSlice('\n')
+					if lerr != nil {
+						return lerr
+					}
+					errs[i] = c.storeResult(cmd, line)
+					responded[i] = true
+				}
+			}
+			return nil
+		})
+
+		// any item without a response failed with err
+		if err != nil {
+			for _, i := range positions {
+				if !responded[i] {
+					errs[i] = err
+				}
+			}
+		}
+	})
+
+	return errs
+}
+
 // Get the values associated with the given keys. One Pair[T, error] return
 // value for each of the given keys, in the same order.
 //
+// Keys are grouped by the memcached instance they are stored on, and the values
+// of each group are requested in a single round trip to that instance. Groups
+// are requested concurrently, up to the limit set by SetFanOut.
+//
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
-func GetMulti[T any](c *Client, keys []string) []*Pair[T, error] {
-	results := make([]*Pair[T, error], 0, len(keys))
-	for _, key := range keys {
-		v, err := Get[T](c, key)
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func GetMulti[T any](c *Client, keys []string, opts ...Option) []*Pair[T, error] {
+	options := c.options(opts)
+	results := make([]*Pair[T, error], len(keys))
+
+	// group the position(s) of each key by the instance the key is stored on
+	groups := make(map[string]map[string][]int)
+	for i, key := range keys {
+		key, err := c.key(key)
 		if err != nil {
-			results = append(results, &Pair[T, error]{B: err})
-		} else {
-			results = append(results, &Pair[T, error]{A: v})
+			results[i] = &Pair[T, error]{B: err}
+			continue
+		}
+
+		address := c.route(options.server, key)
+		if groups[address] == nil {
+			groups[address] = make(map[string][]int)
+		}
+		groups[address][key] = append(groups[address][key], i)
+	}
+
+	fanOut(c, groups, func(address string, positions map[string][]int) {
+		batch := slices.Sorted(maps.Keys(positions))
+
+		manifests := make(map[string]*Pair[[]byte, int])
+
+		err := c.doInstance(options.ctx, "get_multi", address, func(conn *iopool.Buffer) error {
+			return c.fetchMulti(conn, batch, func(h header, payload []byte) {
+				if c.chunked(h.flags) {
+					manifests[h.key] = &Pair[[]byte, int]{A: slices.Clone(payload), B: h.flags}
+					return
+				}
+				v, derr := unpack[T](c, h.key, payload, h.flags)
+				for _, i := range positions[h.key] {
+					results[i] = &Pair[T, error]{A: v, B: derr}
+				}
+			})
+		})
+
+		// reassemble the values that were split into chunks
+		for key, m := range manifests {
+			v, derr := unchunk[T](options.ctx, c, key, m.A, m.B)
+			for _, i := range positions[key] {
+				results[i] = &Pair[T, error]{A: v, B: derr}
+			}
+		}
+
+		// any key without a value was either a miss or failed with err
+		if err == nil {
+			err = ErrCacheMiss
+		}
+		for _, key := range batch {
+			for _, i := range positions[key] {
+				if results[i] == nil {
+					results[i] = &Pair[T, error]{B: err}
+				}
+			}
+		}
+	})
+
+	return results
+}
+
+// fanOut calls f for the group of each memcached instance, concurrently
+// communicating with at most the configured number of instances at a time
+func fanOut[G any](c *Client, groups map[string]G, f func(string, G)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(1, c.fanout))
+
+	for address, positions := range groups {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			f(address, positions)
+		})
+	}
+
+	wg.Wait()
+}
+
+// GetMultiMap gets the values associated with the given keys, returning a map
+// containing only the keys that were found. Unlike GetMulti, a cache miss is
+// indicated by the absence of the key in the map, rather than by an error.
+//
+// Errors other than a cache miss are accumulated using errors.Join, and the
+// values of keys that were found are still returned.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func GetMultiMap[T any](c *Client, keys []string, opts ...Option) (map[string]T, error) {
+	results := GetMulti[T](c, keys, opts...)
+	values := make(map[string]T, len(results))
+
+	var errs []error
+	for i, result := range results {
+		switch {
+		case result.B == nil:
+			values[keys[i]] = result.A
+		case !errors.Is(result.B, ErrCacheMiss):
+			errs = append(errs, fmt.Errorf("%s: %w", keys[i], result.B))
 		}
 	}
+
+	return values, errors.Join(errs...)
+}
+
+// GetEach gets the values associated with the given keys, returning an iterator
+// over each key and a Pair[T, error] of its value. Values are yielded as soon
+// as each one is read from its memcached instance, rather than once all values
+// have been read, so the order of iteration is not the order of keys.
+//
+// Keys are grouped by the memcached instance they are stored on, and the values
+// of each group are requested in a single round trip to that instance. Groups
+// are requested concurrently, up to the limit set by SetFanOut.
+//
+//	for key, result := range memc.GetEach[string](client, keys) {
+//		if result.B != nil {
+//			// handle cache miss or other error
+//		}
+//	}
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func GetEach[T any](c *Client, keys []string, opts ...Option) iter.Seq2[string, *Pair[T, error]] {
+	options := c.options(opts)
+
+	type item struct {
+		key    string
+		result *Pair[T, error]
+	}
+
+	return func(yield func(string, *Pair[T, error]) bool) {
+		// group the occurrences of each key by the instance the key is stored on
+		groups := make(map[string]map[string]int)
+		given := make(map[string]string)
+		for _, key := range keys {
+			stored, err := c.key(key)
+			if err != nil {
+				if !yield(key, &Pair[T, error]{B: err}) {
+					return
+				}
+				continue
+			}
+			given[stored] = key
+			key = stored
+
+			address := c.route(options.server, key)
+			if groups[address] == nil {
+				groups[address] = make(map[string]int)
+			}
+			groups[address][key]++
+		}
+
+		items := make(chan item)
+		done := make(chan struct{})
+		defer close(done)
+
+		// keys are sent as given, rather than as stored
+		send := func(key string, result *Pair[T, error], n int) {
+			for range n {
+				select {
+				case items <- item{key: given[key], result: result}:
+				case <-done:
+					return
+				}
+			}
+		}
+
+		go func() {
+			defer close(items)
+
+			fanOut(c, groups, func(address string, occurrences map[string]int) {
+				batch := slices.Sorted(maps.Keys(occurrences))
+				found := make(map[string]bool, len(batch))
+
+				manifests := make(map[string]*Pair[[]byte, int])
+
+				err := c.doInstance(options.ctx, "get_multi", address, func(conn *iopool.Buffer) error {
+					return c.fetchMulti(conn, batch, func(h header, payload []byte) {
+						found[h.key] = true
+						if c.chunked(h.flags) {
+							manifests[h.key] = &Pair[[]byte, int]{A: slices.Clone(payload), B: h.flags}
+							return
+						}
+						v, derr := unpack[T](c, h.key, payload, h.flags)
+						send(h.key, &Pair[T, error]{A: v, B: derr}, occurrences[h.key])
+					})
+				})
+
+				// reassemble the values that were split into chunks
+				for key, m := range manifests {
+					v, derr := unchunk[T](options.ctx, c, key, m.A, m.B)
+					send(key, &Pair[T, error]{A: v, B: derr}, occurrences[key])
+				}
+
+				// any key without a value was either a miss or failed with err
+				if err == nil {
+					err = ErrCacheMiss
+				}
+				for _, key := range batch {
+					if !found[key] {
+						send(key, &Pair[T, error]{B: err}, occurrences[key])
+					}
+				}
+			})
+		}()
+
+		for it := range items {
+			if !yield(it.key, it.result) {
+				return
+			}
+		}
+	}
+}
+
+// fetchMulti requests the values of keys over conn in a single round trip
+// using the configured protocol, calling f with each value that is found
+func (c *Client) fetchMulti(conn *iopool.Buffer, keys []string, f func(header, []byte)) error {
+	switch c.protocol {
+	case Meta:
+		return metaFetchMulti(conn, keys, c.payloads(conn), f)
+	default:
+		return textFetchMulti(conn, keys, c.payloads(conn), f)
+	}
+}
+
+func textFetchMulti(conn *iopool.Buffer, keys []string, p payloads, f func(header, []byte)) error {
+	// write the header components
+	if _, err := fmt.Fprintf(conn, "get %s\r\n", strings.Join(keys, " ")); err != nil {
+		return err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	// read each value until the terminating line ("END\r\n")
+	for {
+		line, err := conn.ReadSlice('\n')
+		if err != nil {
+			return err
+		}
+
+		if string(line) == "END\r\n" {
+			return nil
+		}
+
+		h, herr := parseHeader("", line)
+		if herr != nil {
+			return herr
+		}
+
+		if err = readValue(conn, h, p, f); err != nil {
+			return err
+		}
+	}
+}
+
+// readValue reads the payload described by h from conn into a buffer of p,
+// which is only valid for the duration of the call to f
+func readValue(conn *iopool.Buffer, h header, p payloads, f func(header, []byte)) error {
+	payload := p.get(h.size + 2) // including \r\n
+	defer p.put(payload)
+
+	if _, err := io.ReadFull(conn, *payload); err != nil {
+		return err
+	}
+
+	f(h, (*payload)[0:h.size])
+	return nil
+}
+
+// IncrementMulti will increment the value associated with each key by its
+// associated delta. One Pair[uint64, error] return value for each of the given
+// items, containing the resulting value, in the same order.
+//
+// Items are grouped by the memcached instance they are stored on, and the
+// commands for each group are pipelined, writing many commands before reading
+// their responses.
+//
+// Note: each value must be an ASCII integer, as with Increment.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func IncrementMulti(c *Client, items []*Pair[string, uint64], opts ...Option) []*Pair[uint64, error] {
+	return arithmeticMulti(c, "incr", items, opts)
+}
+
+// DecrementMulti will decrement the value associated with each key by its
+// associated delta. One Pair[uint64, error] return value for each of the given
+// items, containing the resulting value, in the same order.
+//
+// Items are grouped by the memcached instance they are stored on, and the
+// commands for each group are pipelined, writing many commands before reading
+// their responses.
+//
+// Note: each value must be an ASCII integer, as with Decrement.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func DecrementMulti(c *Client, items []*Pair[string, uint64], opts ...Option) []*Pair[uint64, error] {
+	return arithmeticMulti(c, "decr", items, opts)
+}
+
+// arithmeticMulti executes the arithmetic command cmd for each item,
+// pipelining the commands sent to each memcached instance
+func arithmeticMulti(c *Client, cmd string, items []*Pair[string, uint64], opts []Option) []*Pair[uint64, error] {
+	options := c.options(opts)
+	results := make([]*Pair[uint64, error], len(items))
+	keys := make([]string, len(items))
+
+	// group the position of each item by the instance the item is stored on
+	groups := make(map[string][]int)
+	for i, item := range items {
+		key, err := c.key(item.A)
+		if err != nil {
+			results[i] = &Pair[uint64, error]{B: err}
+			continue
+		}
+
+		address := c.route(options.server, key)
+		groups[address] = append(groups[address], i)
+		keys[i] = key
+	}
+
+	defer func() {
+		for _, key := range keys {
+			c.local.remove(key)
+		}
+	}()
+
+	fanOut(c, groups, func(address string, positions []int) {
+		err := c.doInstance(options.ctx, cmd+"_multi", address, func(conn *iopool.Buffer) error {
+			for window := range slices.Chunk(positions, pipelineWindow) {
+				// write each command of the window
+				for _, i := range window {
+					if err := c.writeArithmetic(conn, cmd, keys[i], items[i].B); err != nil {
+						return err
+					}
+				}
+
+				// flush the buffer
+				if err := conn.Flush(); err != nil {
+					return err
+				}
+
+				// read the response to each command of the window
+				for _, i := range window {
+					u, err := c.readArithmetic(conn)
+					if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrNonNumeric) {
+						return err
+					}
+					results[i] = &Pair[uint64, error]{A: u, B: err}
+				}
+			}
+			return nil
+		})
+
+		// any item without a response failed with err
+		for _, i := range positions {
+			if results[i] == nil {
+				results[i] = &Pair[uint64, error]{B: err}
+			}
+		}
+	})
+
 	return results
 }
@@ -0,0 +1,134 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"strconv"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// Namespace is a group of keys that can be invalidated together in constant
+// time, without deleting each key or flushing every memcached instance.
+//
+// The current generation of the namespace is stored in memcached, and embedded
+// in each key derived from the namespace by Key. Invalidate increments the
+// generation, such that keys derived afterwards differ from the keys derived
+// before, whose values are no longer read and are eventually evicted.
+type Namespace struct {
+	client *Client
+	name   string
+}
+
+// NewNamespace returns the Namespace of the given name, using Client c to
+// store its generation.
+func NewNamespace(c *Client, name string) *Namespace {
+	return &Namespace{
+		client: c,
+		name:   name,
+	}
+}
+
+// key returns the key of the generation of the namespace
+func (n *Namespace) key() string {
+	return "memc:ns:" + n.name
+}
+
+// Key returns the key derived from key for the current generation of the
+// namespace, to be used with verbs such as Get and Set in place of key.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func (n *Namespace) Key(key string, opts ...Option) (string, error) {
+	generation, err := n.Generation(opts...)
+	if err != nil {
+		return "", err
+	}
+	return n.name + ":" + strconv.FormatUint(generation, 10) + ":" + key, nil
+}
+
+// Generation returns the current generation of the namespace.
+//
+// If the namespace has no generation stored in memcached, e.g. because it was
+// evicted, a new generation is stored based on the current time, such that a
+// generation is not reused and the values of keys derived before the eviction
+// cannot be read again.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func (n *Namespace) Generation(opts ...Option) (uint64, error) {
+	generation, err := Get[uint64](n.client, n.key(), opts...)
+	if errors.Is(err, ErrCacheMiss) {
+		return seedGeneration(n.client, n.key(), opts)
+	}
+	return generation, err
+}
+
+// Invalidate increments the generation of the namespace, such that the values
+// of every key derived from the namespace are no longer read.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func (n *Namespace) Invalidate(opts ...Option) error {
+	return bumpGeneration(n.client, n.key(), opts)
+}
+
+// seedGeneration stores a generation based on the current time under key,
+// unless a generation is stored concurrently, and returns the generation
+func seedGeneration(c *Client, key string, opts []Option) (uint64, error) {
+	generation := uint64(c.now().UnixNano())
+	err := addGeneration(c, key, generation, opts)
+	if errors.Is(err, ErrNotStored) {
+		return Get[uint64](c, key, opts...)
+	}
+	return generation, err
+}
+
+// bumpGeneration increments the generation stored under key
+func bumpGeneration(c *Client, key string, opts []Option) error {
+	_, err := Increment(c, key, uint64(1), opts...)
+	if errors.Is(err, ErrNotFound) {
+		// a new generation is seeded when a key is next derived
+		return nil
+	}
+	return err
+}
+
+// addGeneration stores generation under key unless a value is already stored.
+//
+// The generation is stored raw as a decimal integer, bypassing compression and
+// encryption, such that memcached can increment it in bumpGeneration.
+func addGeneration(c *Client, key string, generation uint64, opts []Option) error {
+	stored, err := c.key(key)
+	if err != nil {
+		return err
+	}
+
+	options := c.options(opts)
+
+	// the locally cached value is stale once the value is changed
+	defer c.local.remove(stored)
+
+	// the generation must not expire, regardless of the default ttl
+	encoding := strconv.AppendUint(nil, generation, 10)
+	return c.do(options, "add", stored, func(conn *iopool.Buffer) error {
+		if err := c.writeStore(conn, "add", stored, encodingRaw.flags(options.flags), 0, 0, encoding); err != nil {
+			return err
+		}
+
+		// flush the buffer
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+
+		// read response
+		line, lerr := conn.ReadSlice('\n')
+		if lerr != nil {
+			return lerr
+		}
+
+		return c.storeResult("add", line)
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// ErrKnownMiss is returned when reading a key that was stored by SetMiss,
+// indicating the value is known to be absent from the origin of the value.
+var ErrKnownMiss = errors.New("memc: known cache miss")
+
+// errMissCompat is returned by SetMiss when SetGomemcacheCompat is enabled
+var errMissCompat = errors.New("memc: known misses are not available with gomemcache compat")
+
+// missFlags are the flags of a known miss, which are those of a chunked value
+// without a recorded encoding, which are never the flags of a stored value
+const missFlags = chunkedFlag | int(encodingUnknown)<<encodingShift
+
+// missed reports whether flags records the value as a known miss
+func (c *Client) missed(flags int) bool {
+	return !c.compat && flags&^userFlagsMask == missFlags
+}
+
+// SetMiss records that the value of the given key is known to be absent from
+// the origin of the value, e.g. an entity that does not exist in a database,
+// such that reading key returns ErrKnownMiss instead of a value or
+// ErrCacheMiss, until the given ttl expires. Fetch returns ErrKnownMiss without
+// loading the value.
+//
+// The ttl is typically much shorter than the ttl of values, so that a value
+// created in the origin is soon read. The ttl must be greater than 1 second.
+//
+// Known misses are not available when SetGomemcacheCompat is enabled.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func SetMiss(c *Client, key string, ttl time.Duration, opts ...Option) error {
+	if c.compat {
+		return errMissCompat
+	}
+
+	key, err := c.key(key)
+	if err != nil {
+		return err
+	}
+
+	options := c.options(append(slices.Clip(opts), TTL(ttl)))
+
+	expiration, experr := c.expiry(options)
+	if experr != nil {
+		return experr
+	}
+
+	defer c.local.remove(key)
+
+	return c.do(options, "set", key, func(conn *iopool.Buffer) error {
+		if err := c.writeStore(conn, "set", key, missFlags, expiration, 0, nil); err != nil {
+			return err
+		}
+
+		// flush the buffer
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+
+		// read response
+		line, lerr := conn.ReadSlice('\n')
+		if lerr != nil {
+			return lerr
+		}
+
+		return c.storeResult("set", line)
+	})
+}
@@ -0,0 +1,31 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestClient_missed(t *testing.T) {
+	t.Parallel()
+
+	c := New(nil)
+	must.True(t, c.missed(missFlags))
+	must.True(t, c.missed(missFlags|42))
+	must.False(t, c.chunked(missFlags))
+
+	// chunked values always record their encoding
+	chunked := encodingRaw.flags(0) | chunkedFlag
+	must.False(t, c.missed(chunked))
+	must.True(t, c.chunked(chunked))
+	must.False(t, c.missed(encodingUnknown.flags(0)))
+
+	_, err := unpack[string](c, "key", nil, missFlags)
+	must.ErrorIs(t, err, ErrKnownMiss)
+
+	compat := New(nil, SetGomemcacheCompat(true))
+	must.False(t, compat.missed(missFlags))
+}
@@ -0,0 +1,76 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"math"
+)
+
+// The parsing of response lines is done by hand rather than with the strings
+// and strconv packages, as it is performed for every value read and must not
+// allocate.
+
+// trimLine returns b without the trailing "\r\n" of a response line
+func trimLine(b []byte) []byte {
+	if n := len(b); n > 0 && b[n-1] == '\n' {
+		b = b[:n-1]
+	}
+	if n := len(b); n > 0 && b[n-1] == '\r' {
+		b = b[:n-1]
+	}
+	return b
+}
+
+// field returns the first space separated field of b, along with the remainder
+// of b following the field, or an empty field if there are no more fields
+func field(b []byte) ([]byte, []byte) {
+	for len(b) > 0 && b[0] == ' ' {
+		b = b[1:]
+	}
+	i := 0
+	for i < len(b) && b[i] != ' ' {
+		i++
+	}
+	return b[:i], b[i:]
+}
+
+// parseUint parses b as an unsigned decimal integer, returning false if b is
+// not valid or overflows a uint64
+func parseUint(b []byte) (uint64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+
+	var n uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		d := uint64(c - '0')
+		if n > (math.MaxUint64-d)/10 {
+			return 0, false
+		}
+		n = n*10 + d
+	}
+	return n, true
+}
+
+// parseInt parses b as a non-negative decimal integer, returning false if b is
+// not valid or overflows an int
+func parseInt(b []byte) (int, bool) {
+	n, ok := parseUint(b)
+	if !ok || n > math.MaxInt {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// keyOf returns the key of a response line as a string, reusing key if it is
+// the same, such that reading the value of a known key does not allocate
+func keyOf(key string, b []byte) string {
+	if string(b) == key {
+		return key
+	}
+	return string(b)
+}
@@ -0,0 +1,173 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_field(t *testing.T) {
+	t.Parallel()
+
+	f, rest := field([]byte("VALUE  k 0"))
+	must.Eq(t, "VALUE", string(f))
+	must.Eq(t, "  k 0", string(rest))
+
+	f, rest = field(rest)
+	must.Eq(t, "k", string(f))
+
+	f, rest = field(rest)
+	must.Eq(t, "0", string(f))
+
+	f, _ = field(rest)
+	must.Eq(t, "", string(f))
+}
+
+func Test_parseUint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		input string
+		exp   uint64
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{"18446744073709551615", 18446744073709551615, true},
+		{"18446744073709551616", 0, false},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"+1", 0, false},
+		{"1x", 0, false},
+	}
+
+	for _, tc := range cases {
+		n, ok := parseUint([]byte(tc.input))
+		must.Eq(t, tc.ok, ok, must.Sprint(tc.input))
+		must.Eq(t, tc.exp, n, must.Sprint(tc.input))
+	}
+}
+
+func Test_parseInt(t *testing.T) {
+	t.Parallel()
+
+	n, ok := parseInt([]byte("16777216"))
+	must.True(t, ok)
+	must.Eq(t, 16777216, n)
+
+	_, ok = parseInt([]byte("18446744073709551615"))
+	must.False(t, ok)
+}
+
+func Test_parse_allocations(t *testing.T) { //nolint:paralleltest // AllocsPerRun counts allocations program-wide
+	// not parallel, as allocations are counted across the whole program
+	line := []byte("VALUE mykey 16777216 16384 9001\r\n")
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = parseHeader("mykey", line)
+	})
+	must.Zero(t, allocs)
+
+	meta := []byte("VA 16384 f16777216 c9001\r\n")
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _ = parseMetaHeader("mykey", meta)
+	})
+	must.Zero(t, allocs)
+}
+
+// fieldsHeader is the previous implementation of parseHeader, kept as a point
+// of comparison for the benchmarks
+func fieldsHeader(b []byte) (*header, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) < 4 || len(fields) > 5 || fields[0] != "VALUE" {
+		return nil, unexpected(b)
+	}
+
+	flags, ferr := strconv.Atoi(fields[2])
+	size, serr := strconv.Atoi(fields[3])
+	if ferr != nil || serr != nil {
+		return nil, unexpected(b)
+	}
+
+	h := &header{key: fields[1], flags: flags, size: size}
+	if len(fields) == 5 {
+		cas, cerr := strconv.ParseUint(fields[4], 10, 64)
+		if cerr != nil {
+			return nil, unexpected(b)
+		}
+		h.cas = cas
+	}
+	return h, nil
+}
+
+var sinkHeader header
+
+func Benchmark_parseHeader(b *testing.B) {
+	line := []byte("VALUE mykey 16777216 16384 9001\r\n")
+
+	b.Run("fields", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			h, _ := fieldsHeader(line)
+			sinkHeader = *h
+		}
+	})
+
+	b.Run("manual", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			sinkHeader, _ = parseHeader("mykey", line)
+		}
+	})
+}
+
+func Benchmark_parseMetaHeader(b *testing.B) {
+	line := []byte("VA 16384 f16777216 c9001\r\n")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		sinkHeader, _ = parseMetaHeader("mykey", line)
+	}
+}
+
+// repeat is an io.Reader which reads the same response over and over
+type repeat struct {
+	response string
+	offset   int
+}
+
+func (r *repeat) Read(b []byte) (int, error) {
+	n := copy(b, r.response[r.offset:])
+	r.offset = (r.offset + n) % len(r.response)
+	return n, nil
+}
+
+func Benchmark_getPayload(b *testing.B) {
+	value := strings.Repeat("x", 1024)
+	r := bufio.NewReader(&repeat{response: "VALUE mykey 16777216 1024\r\n" + value + "\r\nEND\r\n"})
+
+	b.ReportAllocs()
+	for b.Loop() {
+		payload, _, err := getPayload(r, "mykey", payloads{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		payloads{}.put(payload)
+	}
+}
+
+func Benchmark_textStoreResult(b *testing.B) {
+	line := []byte("STORED\r\n")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := textStoreResult("set", line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+	"github.com/shoenig/test/must"
+)
+
+// scripted returns a client whose only connection follows the script of mc,
+// which is verified once the test is complete
+func scripted(t *testing.T, mc *iopool.MockConn) *Client {
+	c := New([]string{"mock"}, SetConnOpener(iopool.MockConnections(mc)))
+	t.Cleanup(func() {
+		must.NoError(t, mc.Verify())
+		_ = c.Close()
+	})
+	return c
+}
+
+func TestProtocol_Set(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("set k 16777216 60 5\r\nvalue\r\n").
+		Respond("STORED\r\n")
+	c := scripted(t, mc)
+
+	err := Set(c, "k", "value", TTL(time.Minute))
+	must.NoError(t, err)
+}
+
+func TestProtocol_SetAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 23, 10, 24, 0, 0, time.UTC)
+	mc := iopool.NewMockConn().
+		Expect("set k 16777216 1769212800 5\r\nvalue\r\n").
+		Respond("STORED\r\n")
+	c := New([]string{"mock"}, SetConnOpener(iopool.MockConnections(mc)), SetClock(func() time.Time { return now }))
+	t.Cleanup(func() {
+		must.NoError(t, mc.Verify())
+		_ = c.Close()
+	})
+
+	err := Set(c, "k", "value", TTLAt(time.Date(2026, 1, 24, 0, 0, 0, 0, time.UTC)))
+	must.NoError(t, err)
+}
+
+func TestProtocol_SetLongTTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 23, 10, 24, 0, 0, time.UTC)
+	mc := iopool.NewMockConn().
+		Expect("set k 16777216 1773051840 5\r\nvalue\r\n").
+		Respond("STORED\r\n")
+	c := New([]string{"mock"}, SetConnOpener(iopool.MockConnections(mc)), SetClock(func() time.Time { return now }))
+	t.Cleanup(func() {
+		must.NoError(t, mc.Verify())
+		_ = c.Close()
+	})
+
+	// 45 days is sent as the unix timestamp of March 9th, 2026, 10:24:00 AM
+	err := Set(c, "k", "value", TTL(45*24*time.Hour))
+	must.NoError(t, err)
+}
+
+func TestProtocol_Add(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("add k 16777216 0 3\r\nabc\r\n").
+		Respond("NOT_", "STORED\r\n")
+	c := scripted(t, mc)
+
+	err := Add(c, "k", []byte("abc"), TTL(0))
+	must.ErrorIs(t, err, ErrNotStored)
+}
+
+func TestProtocol_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("split", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("get k\r\n").
+			Respond("VALUE k 16777216", " 5\r\nva", "lue\r\nEN", "D\r\n")
+		c := scripted(t, mc)
+
+		v, err := Get[string](c, "k")
+		must.NoError(t, err)
+		must.Eq(t, "value", v)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("get k\r\n").
+			Respond("END\r\n")
+		c := scripted(t, mc)
+
+		_, err := Get[string](c, "k")
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+}
+
+func TestProtocol_Gets(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("gets k\r\n").
+		Respond("VALUE k 16777216 5 42\r\nvalue\r\nEND\r\n")
+	c := scripted(t, mc)
+
+	v, cas, err := Gets[string](c, "k")
+	must.NoError(t, err)
+	must.Eq(t, "value", v)
+	must.Eq(t, 42, cas)
+}
+
+func TestProtocol_GetInto(t *testing.T) {
+	t.Parallel()
+
+	t.Run("text", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("get k\r\n").
+			Respond("VALUE k 16777219 5\r\nva", "lue\r\nEND\r\n")
+		c := scripted(t, mc)
+
+		dst := make([]byte, 16)
+		n, flags, err := GetInto(c, "k", dst)
+		must.NoError(t, err)
+		must.Eq(t, "value", string(dst[:n]))
+		must.Eq(t, 3, flags)
+	})
+
+	t.Run("meta", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("mg k v f\r\n").
+			Respond("VA 5 f16777216\r\nvalue\r\n")
+		c := New([]string{"mock"}, SetConnOpener(iopool.MockConnections(mc)), SetProtocol(Meta))
+		t.Cleanup(func() {
+			must.NoError(t, mc.Verify())
+			_ = c.Close()
+		})
+
+		dst := make([]byte, 5)
+		n, _, err := GetInto(c, "k", dst)
+		must.NoError(t, err)
+		must.Eq(t, "value", string(dst[:n]))
+	})
+
+	t.Run("short", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("get k\r\n").
+			Respond("VALUE k 16777216 5\r\nvalue\r\nEND\r\n")
+		c := scripted(t, mc)
+
+		n, _, err := GetInto(c, "k", make([]byte, 2))
+		must.ErrorIs(t, err, io.ErrShortBuffer)
+		must.Eq(t, 5, n)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		mc := iopool.NewMockConn().
+			Expect("get k\r\n").
+			Respond("END\r\n")
+		c := scripted(t, mc)
+
+		_, _, err := GetInto(c, "k", make([]byte, 16))
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+}
+
+func TestProtocol_PayloadReuse(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("get a\r\n").
+		Respond("VALUE a 16777216 5\r\nfirst\r\nEND\r\n").
+		Expect("get b\r\n").
+		Respond("VALUE b 16777216 6\r\nsecond\r\nEND\r\n")
+	c := New([]string{"mock"}, SetConnOpener(iopool.MockConnections(mc)), SetPayloadReuse(true))
+	t.Cleanup(func() {
+		must.NoError(t, mc.Verify())
+		_ = c.Close()
+	})
+
+	a, err := Get[[]byte](c, "a")
+	must.NoError(t, err)
+
+	b, err := Get[[]byte](c, "b")
+	must.NoError(t, err)
+
+	// both values were read into the same buffer, and copied out of it
+	must.Eq(t, "first", string(a))
+	must.Eq(t, "second", string(b))
+}
+
+func TestProtocol_Delete(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("delete k\r\n").
+		Respond("NOT_FOUND\r\n")
+	c := scripted(t, mc)
+
+	err := Delete(c, "k")
+	must.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestProtocol_Increment(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("incr k 2\r\n").
+		Respond("7\r\n")
+	c := scripted(t, mc)
+
+	v, err := Increment(c, "k", 2)
+	must.NoError(t, err)
+	must.Eq(t, 7, v)
+}
+
+func TestProtocol_Touch(t *testing.T) {
+	t.Parallel()
+
+	mc := iopool.NewMockConn().
+		Expect("touch k 30\r\n").
+		Respond("TOUCHED\r\n")
+	c := scripted(t, mc)
+
+	err := Touch(c, "k", 30*time.Second)
+	must.NoError(t, err)
+}
@@ -0,0 +1,71 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueryFunc executes a database query with the given arguments, producing a
+// result of type T. Typically this wraps a call to (*sql.DB).Query followed
+// by scanning the resulting rows.
+type QueryFunc[T any] func(query string, args ...any) (T, error)
+
+// QueryKey returns the key used to cache the result of executing query with
+// the given arguments.
+//
+// The key is derived from a SHA-256 hash of the query text and the type and
+// value of each argument, and so is always a valid memcached key.
+func QueryKey(query string, args ...any) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00", query)
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(h, "%T:%v\x00", arg, arg)
+	}
+	return "memc:sql:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Query returns the cached result of executing query with the given arguments,
+// if present. Otherwise f is called to execute the query, and its result is
+// stored in memcached with the given ttl before being returned.
+//
+//	users, err := memc.Query(client, 30*time.Second, listUsers,
+//		"SELECT id, name FROM users WHERE org = ?", []any{orgID},
+//	)
+//
+// Query has the semantics of Fetch: a failure to read or store the result in
+// memcached does not prevent f from being called and its result returned, and
+// concurrent calls for the same query and arguments share a single call of f.
+// An error returned by f is returned as is, and nothing is stored.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Query[T any](c *Client, ttl time.Duration, f QueryFunc[T], query string, args []any, opts ...Option) (T, error) {
+	return Fetch(c, QueryKey(query, args...), ttl, func(context.Context) (T, error) {
+		return f(query, args...)
+	}, opts...)
+}
+
+// InvalidateQuery removes the cached result of executing query with the given
+// arguments, such that the next call to Query will execute the query.
+//
+// It is not an error if there is no cached result.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func InvalidateQuery(c *Client, query string, args []any, opts ...Option) error {
+	key := QueryKey(query, args...)
+	if err := Delete(c, key, opts...); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestQueryKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		key := QueryKey("SELECT * FROM users WHERE id = ?", 42)
+		must.NoError(t, check(key))
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		a := QueryKey("SELECT * FROM users WHERE id = ?", 42)
+		b := QueryKey("SELECT * FROM users WHERE id = ?", 42)
+		must.Eq(t, a, b)
+	})
+
+	t.Run("args", func(t *testing.T) {
+		a := QueryKey("SELECT * FROM users WHERE id = ?", 42)
+		b := QueryKey("SELECT * FROM users WHERE id = ?", 43)
+		must.NotEq(t, a, b)
+	})
+
+	t.Run("types", func(t *testing.T) {
+		a := QueryKey("SELECT * FROM users WHERE id = ?", 42)
+		b := QueryKey("SELECT * FROM users WHERE id = ?", "42")
+		must.NotEq(t, a, b)
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NewFromFile creates a new Client using the memcached instances listed in the
+// file at path, which is watched for changes. Whenever the list of instances in
+// the file changes, connections are rebalanced across the new set of instances
+// without interrupting requests in flight.
+//
+// Instances are separated by commas or whitespace, and anything following a #
+// on a line is ignored as a comment.
+//
+//	# cache tier
+//	10.0.0.1:11211
+//	10.0.0.2:11211
+//
+// The file is checked for changes every 10 seconds, which can be adjusted by
+// SetReloadInterval.
+func NewFromFile(path string, opts ...ClientOption) (*Client, error) {
+	instances, err := readInstances(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := New(instances, opts...)
+	go c.watch(path, instances)
+	return c, nil
+}
+
+// NewFromEnv creates a new Client using the memcached instances listed in the
+// environment variable name, separated by commas or whitespace.
+func NewFromEnv(name string, opts ...ClientOption) (*Client, error) {
+	value, exists := os.LookupEnv(name)
+	if !exists {
+		return nil, fmt.Errorf("memc: environment variable %q is not set", name)
+	}
+	return New(parseInstances(value), opts...), nil
+}
+
+// SetReloadInterval adjusts how often the file of a Client created by
+// NewFromFile is checked for changes to the list of memcached instances.
+//
+// If unset the default reload interval is 10 seconds.
+func SetReloadInterval(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.reload = interval
+	}
+}
+
+// watch periodically reads the list of memcached instances from the file at
+// path, rebalancing whenever the list changes from previous, until the Client
+// is closed
+func (c *Client) watch(path string, previous []string) {
+	ticker := time.NewTicker(c.reload)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		instances, err := readInstances(path)
+		switch {
+		case err != nil:
+			// keep the existing instances, e.g. while the file is replaced
+			c.log.Debug("memc: failed to reload instances", "path", path, "error", err)
+			c.metrics.Count("memc.reload.errors", 1)
+			continue
+		case slices.Equal(instances, previous):
+			continue
+		}
+
+		c.core.lock.Lock()
+		c.addrs = instances
+		c.core.lock.Unlock()
+
+		c.rebalance()
+		previous = instances
+	}
+}
+
+func readInstances(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseInstances(string(b)), nil
+}
+
+// parseInstances returns the instances listed in s, separated by commas or
+// whitespace, ignoring comments beginning with #
+func parseInstances(s string) []string {
+	var instances []string
+	for line := range strings.Lines(s) {
+		line, _, _ = strings.Cut(line, "#")
+		instances = append(instances, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})...)
+	}
+	return instances
+}
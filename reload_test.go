@@ -0,0 +1,69 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_parseInstances(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		input string
+		exp   []string
+	}{
+		{name: "empty", input: "", exp: nil},
+		{name: "single", input: "10.0.0.1:11211", exp: []string{"10.0.0.1:11211"}},
+		{name: "commas", input: "10.0.0.1:11211, 10.0.0.2:11211", exp: []string{"10.0.0.1:11211", "10.0.0.2:11211"}},
+		{name: "lines", input: "# cache tier\n10.0.0.1:11211\n\n10.0.0.2:11211 # second\n", exp: []string{"10.0.0.1:11211", "10.0.0.2:11211"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			must.Eq(t, tc.exp, parseInstances(tc.input))
+		})
+	}
+}
+
+func TestNewFromFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "servers")
+	err := os.WriteFile(path, []byte("10.0.0.1:11211\n10.0.0.2:11211\n"), 0o644)
+	must.NoError(t, err)
+
+	c, err := NewFromFile(path, SetReloadInterval(10*time.Millisecond))
+	must.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	must.Eq(t, []string{"10.0.0.1:11211", "10.0.0.2:11211"}, c.instances())
+
+	err = os.WriteFile(path, []byte("10.0.0.2:11211\n10.0.0.3:11211\n"), 0o644)
+	must.NoError(t, err)
+
+	exp := []string{"10.0.0.2:11211", "10.0.0.3:11211"}
+	for !slices.Equal(exp, c.instances()) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := NewFromFile(filepath.Join(t.TempDir(), "missing"))
+		must.Error(t, err)
+	})
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFromEnv("MEMC_TEST_UNSET_SERVERS")
+	must.Error(t, err)
+}
@@ -0,0 +1,81 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// RetryPolicy configures retrying idempotent verbs, i.e. Get, Gets, and Touch,
+// that fail with a transient network error such as a connection reset by the
+// memcached instance.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int
+
+	// Delay is the delay before the first retry, which is doubled for each
+	// subsequent retry, up to a maximum of one minute.
+	Delay time.Duration
+
+	// Jitter is the fraction of each delay, between 0 and 1, that is random,
+	// so that many clients do not retry in lockstep.
+	Jitter float64
+}
+
+// SetRetryPolicy sets the RetryPolicy for retrying idempotent verbs that fail
+// with a temporary error, as reported by IsTemporary. Verbs are not retried
+// once their Context is done.
+//
+// If unset the default is to not retry.
+func SetRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.retry = policy
+	}
+}
+
+// maxBackoff is the longest delay before a retry
+const maxBackoff = time.Minute
+
+// backoff returns the delay before the given retry, where the first retry is 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.Delay <= 0 {
+		return 0
+	}
+
+	// double the delay without shifting it past maxBackoff, which would
+	// eventually overflow
+	delay := min(p.Delay, maxBackoff)
+	for i := 1; i < retry && delay < maxBackoff; i++ {
+		delay = min(delay*2, maxBackoff)
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	return delay - time.Duration(jitter*rand.Float64()*float64(delay))
+}
+
+// doRetry is like do, but executes f again according to the retry policy of
+// the Client whenever it fails with a temporary error
+func (c *Client) doRetry(options *Options, op, key string, f func(*iopool.Buffer) error) error {
+	ctx := options.ctx
+	err := c.do(options, op, key, f)
+	for retry := 1; retry < c.retry.Attempts && IsTemporary(err) && ctx.Err() == nil; retry++ {
+		timer := time.NewTimer(c.retry.backoff(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		c.log.Debug("memc: retrying operation", "op", op, "attempt", retry+1, "error", err)
+		c.metrics.Count("memc."+op+".retries", 1)
+		err = c.do(options, op, key, f)
+	}
+	return err
+}
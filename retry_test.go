@@ -0,0 +1,44 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shoenig/test/must"
+)
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exponential", func(t *testing.T) {
+		p := RetryPolicy{Delay: 10 * time.Millisecond}
+		must.Eq(t, 10*time.Millisecond, p.backoff(1))
+		must.Eq(t, 20*time.Millisecond, p.backoff(2))
+		must.Eq(t, 40*time.Millisecond, p.backoff(3))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := RetryPolicy{Delay: 100 * time.Millisecond, Jitter: 0.5}
+		for range 100 {
+			must.Between(t, 50*time.Millisecond, p.backoff(1), 100*time.Millisecond)
+		}
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		p := RetryPolicy{Delay: 10 * time.Millisecond}
+		must.Eq(t, maxBackoff, p.backoff(20))
+		must.Eq(t, maxBackoff, p.backoff(64))
+		must.Eq(t, maxBackoff, p.backoff(1000))
+
+		p = RetryPolicy{Delay: time.Hour}
+		must.Eq(t, maxBackoff, p.backoff(1))
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		p := RetryPolicy{}
+		must.Eq(t, 0, p.backoff(1))
+	})
+}
@@ -0,0 +1,37 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"time"
+)
+
+// SetSlowOperation sets a function called with the Info of each operation on
+// a memcached instance that takes at least threshold to complete, along with
+// the time it took, including any time spent waiting on a connection. This
+// enables slow keys and instances to be logged and alerted on.
+//
+// The function is called synchronously once the operation completes, and must
+// be safe for concurrent use.
+//
+// If unset the default is to not report slow operations.
+func SetSlowOperation(threshold time.Duration, f func(info Info, elapsed time.Duration)) ClientOption {
+	return func(c *Client) {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		c.slowThreshold = threshold
+		c.slowFunc = f
+	}
+}
+
+// slowOperation reports the operation of info that began at start if it took
+// at least the configured threshold to complete
+func (c *Client) slowOperation(info Info, start time.Time) {
+	if c.slowFunc == nil {
+		return
+	}
+	if elapsed := c.now().Sub(start); elapsed >= c.slowThreshold {
+		c.slowFunc(info, elapsed)
+	}
+}
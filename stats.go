@@ -70,9 +70,14 @@ type Statistics struct {
 	}
 
 	Items struct {
-		Bytes   int `json:"bytes"`
-		Current int `json:"curr_items"`
-		Total   int `json:"total_items"`
+		Bytes     int `json:"bytes"`
+		Current   int `json:"curr_items"`
+		Total     int `json:"total_items"`
+		Evictions int `json:"evictions"`
+	}
+
+	Memory struct {
+		Limit int `json:"limit_maxbytes"`
 	}
 }
 
@@ -162,6 +167,10 @@ SCAN:
 	s.Items.Bytes = toInt(m["bytes"])
 	s.Items.Current = toInt(m["curr_items"])
 	s.Items.Total = toInt(m["total_items"])
+	s.Items.Evictions = toInt(m["evictions"])
+
+	// map Memory
+	s.Memory.Limit = toInt(m["limit_maxbytes"])
 
 	return s, nil
 }
@@ -305,6 +314,8 @@ var (
 	statsItemsRe = regexp.MustCompile(`STAT items:(\d+):(\S+)\s+(\d+)`)
 )
 
+// ItemStatistics contains the item statistics of a single slab class, as
+// reported by the "stats items" command.
 type ItemStatistics struct {
 	Class               int `json:"slab_class"`
 	Number              int `json:"number"`
@@ -445,3 +456,77 @@ SCAN:
 
 	return results, nil
 }
+
+// ConnStatistics contains the state of a single connection to a memcached
+// instance, as reported by the "stats conns" command.
+type ConnStatistics struct {
+	FD                  int    `json:"fd"`
+	Address             string `json:"addr"`
+	ListenAddress       string `json:"listen_addr"`
+	State               string `json:"state"`
+	SecondsSinceLastCmd int    `json:"secs_since_last_cmd"`
+}
+
+var (
+	statsConnsRe = regexp.MustCompile(`STAT (\d+):(\S+)\s+(\S+)`)
+)
+
+func conns(r io.Reader) ([]*ConnStatistics, error) {
+	scanner := bufio.NewScanner(r)
+	m := make(map[int]*ConnStatistics, 4)
+
+SCAN:
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch line {
+		case "END":
+			break SCAN
+
+		case "ERROR":
+			return nil, ErrCommandIssue
+
+		default:
+			fields := statsConnsRe.FindStringSubmatch(line)
+			if len(fields) != 4 {
+				continue
+			}
+			fd := toInt(fields[1])
+			name := fields[2]
+			value := fields[3]
+
+			if _, exists := m[fd]; !exists {
+				m[fd] = &ConnStatistics{FD: fd}
+			}
+			conn := m[fd]
+
+			switch name {
+			case "addr":
+				conn.Address = value
+			case "listen_addr":
+				conn.ListenAddress = value
+			case "state":
+				conn.State = value
+			case "secs_since_last_cmd":
+				conn.SecondsSinceLastCmd = toInt(value)
+			}
+		}
+	}
+
+	// ensure the scan was a success
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*ConnStatistics, 0, len(m))
+	for _, v := range m {
+		results = append(results, v)
+	}
+
+	// order by file descriptor ascending
+	slices.SortFunc(results, func(a, b *ConnStatistics) int {
+		return cmp.Compare(a.FD, b.FD)
+	})
+
+	return results, nil
+}
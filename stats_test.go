@@ -20,6 +20,7 @@ func Test_stats(t *testing.T) {
 	// spot check a few values
 	must.Eq(t, 714, result.Runtime.PID)
 	must.Eq(t, 1024, result.Connections.Max)
+	must.Eq(t, 2147483648, result.Memory.Limit)
 }
 
 func Test_stats_slabs(t *testing.T) {
@@ -49,6 +50,38 @@ func Test_stats_items(t *testing.T) {
 	must.Eq(t, 3356, result[0].MemRequested)
 }
 
+func Test_stats_conns(t *testing.T) {
+	t.Parallel()
+
+	input := strings.NewReader(realStatsConns)
+	result, err := conns(input)
+	must.NoError(t, err)
+	must.SliceLen(t, 3, result)
+	must.Eq(t, &ConnStatistics{
+		FD:            26,
+		Address:       "/tmp/mc.sock",
+		ListenAddress: "/tmp/mc.sock",
+		State:         "conn_listening",
+	}, result[0])
+	must.Eq(t, &ConnStatistics{
+		FD:                  28,
+		Address:             "tcp:127.0.0.1:51122",
+		ListenAddress:       "tcp:0.0.0.0:11211",
+		State:               "conn_waiting",
+		SecondsSinceLastCmd: 17,
+	}, result[2])
+}
+
+func TestClusterStatistics_HitRatio(t *testing.T) {
+	t.Parallel()
+
+	s := new(ClusterStatistics)
+	must.Eq(t, 0, s.HitRatio())
+
+	s.Hits, s.Misses = 3, 1
+	must.Eq(t, 0.75, s.HitRatio())
+}
+
 // echo "stats" | nc -U /tmp/mc.sock
 const realStats = `
 STAT pid 714
@@ -344,3 +377,19 @@ STAT items:14:hits_to_cold 0
 STAT items:14:hits_to_temp 0
 END
 `
+
+// echo "stats conns" | nc -U /tmp/mc.sock
+const realStatsConns = `
+STAT 26:addr /tmp/mc.sock
+STAT 26:listen_addr /tmp/mc.sock
+STAT 26:state conn_listening
+STAT 27:addr /tmp/mc.sock
+STAT 27:listen_addr /tmp/mc.sock
+STAT 27:state conn_parse_cmd
+STAT 27:secs_since_last_cmd 0
+STAT 28:addr tcp:127.0.0.1:51122
+STAT 28:listen_addr tcp:0.0.0.0:11211
+STAT 28:state conn_waiting
+STAT 28:secs_since_last_cmd 17
+END
+`
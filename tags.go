@@ -0,0 +1,57 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// tagKey returns the key of the version of tag
+func tagKey(tag string) string {
+	return "memc:tag:" + tag
+}
+
+// TaggedKey returns the key derived from key and the current version of each
+// of the given tags, to be used with verbs such as Get and Set in place of key.
+// Once any of the tags is invalidated by InvalidateTag, the derived key changes
+// and the value stored under the previous key is no longer read.
+//
+// This associates a value with tags such as "user:42", so that every value
+// related to the tag can be purged without tracking their keys. The versions of
+// the tags are read in a single round trip to each memcached instance.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instances.
+func TaggedKey(c *Client, key string, tags []string, opts ...Option) (string, error) {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag))
+	}
+
+	h := sha256.New()
+	for i, result := range GetMulti[uint64](c, keys, opts...) {
+		version, err := result.A, result.B
+		if errors.Is(err, ErrCacheMiss) {
+			version, err = seedGeneration(c, keys[i], opts)
+		}
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(h, "%s=%d\n", tags[i], version)
+	}
+
+	return key + "#" + hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
+
+// InvalidateTag increments the version of tag, such that the values of every
+// key derived from the tag by TaggedKey are no longer read.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// a connection and response from memcached.
+func InvalidateTag(c *Client, tag string, opts ...Option) error {
+	return bumpGeneration(c, tagKey(tag), opts)
+}
@@ -5,9 +5,11 @@ package memc
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,14 @@ var (
 	ErrNegativeInc  = errors.New("memc: increment delta must be non-negative")
 	ErrNonNumeric   = errors.New("memc: cannot increment non-numeric value")
 	ErrCommandIssue = errors.New("memc: got command error response")
+
+	// ErrPoolExhausted is returned when no connection to a memcached instance
+	// became available within the timeout set by SetPoolWaitTimeout.
+	ErrPoolExhausted = iopool.ErrPoolExhausted
+
+	// ErrNoInstances is returned when the Client has no memcached instances,
+	// e.g. because none have been discovered yet.
+	ErrNoInstances = iopool.ErrNoInstances
 )
 
 // CAS represents a Compare-And-Swap token used for optimistic locking.
@@ -35,8 +45,12 @@ type CAS uint64
 // Options contains configuration parameters that may be applied when executing
 // a verb like Get, Set, etc.
 type Options struct {
+	ctx        context.Context
 	expiration time.Duration
+	expiresAt  time.Time
 	flags      int
+	soft       time.Duration
+	server     string
 }
 
 // Option to apply when executing a verb like Get, Set, etc.
@@ -46,91 +60,91 @@ type Option func(o *Options)
 //
 // The expiration must be greater than 1 second, or 0, indicating the value will
 // not expire automatically.
+//
+// Memcached treats an expiration of more than 30 days as a unix timestamp
+// rather than a number of seconds, so a longer expiration is converted into the
+// absolute time it ends, according to the clock of SetClock. Such values expire
+// early or late by however far the clock of the memcached instance is skewed.
 func TTL(expiration time.Duration) Option {
 	return func(o *Options) {
 		o.expiration = expiration
+		o.expiresAt = time.Time{}
 	}
 }
 
-// Flags applies the given flags on the value being set.
+// TTLAt applies the given absolute expiration time to set on the value being
+// set, which is sent to memcached as a unix timestamp in seconds, such that the
+// value expires at a wall-clock boundary such as midnight whenever it is set.
+//
+// The expiration must be in the future, and replaces any TTL applied before it.
+func TTLAt(expiration time.Time) Option {
+	return func(o *Options) {
+		o.expiresAt = expiration
+	}
+}
+
+// Flags applies the given flags on the value being set, rather than the
+// default flags of SetDefaultFlags.
+//
+// Only the lower 24 bits of flags are available to applications, as the upper
+// bits record how the value is encoded, unless SetGomemcacheCompat is enabled.
 func Flags(flags int) Option {
 	return func(o *Options) {
 		o.flags = flags
 	}
 }
 
-// Set will store the item using the given key, possibly overwriting any
-// existing data. New items are at the top of the LRU.
+// Context applies the given context to the execution of a verb. Once ctx is
+// done, dialing a new connection or any network read or write in progress is
+// abandoned, and the error of ctx is returned.
 //
-// Uses Client c to connect to a memcached instance, and automatically handles
-// connection pooling and reuse.
+// If unset the default is to use context.Background.
+func Context(ctx context.Context) Option {
+	return func(o *Options) {
+		o.ctx = ctx
+	}
+}
+
+// Server pins the execution of a verb to the memcached instance of address,
+// bypassing the hashing of keys, e.g. to inspect the values held by a specific
+// instance while debugging. The address must be one of the instances of the
+// Client, as given to New or discovered, otherwise the verb fails.
 //
-// One or more Option(s) may be applied to configure things such as the
-// value expiration TTL or its associated flags.
-func Set[T any](c *Client, key string, item T, opts ...Option) error {
-	if err := check(key); err != nil {
-		return err
+// Values stored on an instance other than the one chosen for their key are not
+// found by verbs that are not pinned. Neither the fallback of SetReadFallback
+// nor the local cache of SetLocalCache apply to pinned verbs.
+func Server(address string) Option {
+	return func(o *Options) {
+		o.server = address
 	}
+}
 
+// options returns the Options for executing a verb, starting from the defaults
+// of Client c and applying each of opts
+func (c *Client) options(opts []Option) *Options {
 	options := &Options{
+		ctx:        context.Background(),
 		expiration: c.expiration,
-		flags:      0,
+		flags:      c.flags,
 	}
 
 	for _, opt := range opts {
 		opt(options)
 	}
 
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
-
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
-
-		// write the header components
-		if _, err := fmt.Fprintf(
-			conn,
-			"set %s %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding),
-		); err != nil {
-			return err
-		}
-
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
-		}
-
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
-			return err
-		}
-
-		// flush the buffer
-		if err := conn.Flush(); err != nil {
-			return err
-		}
-
-		// read response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
-		}
+	return options
+}
 
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_STORED\r\n":
-			return ErrNotStored
-		default:
-			return fmt.Errorf("memc: unexpected response to set: %q", string(line))
-		}
-	})
+// Set will store the item using the given key, possibly overwriting any
+// existing data. New items are at the top of the LRU.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// One or more Option(s) may be applied to configure things such as the
+// value expiration TTL or its associated flags.
+func Set[T any](c *Client, key string, item T, opts ...Option) error {
+	return store(c, "set", key, item, 0, opts)
 }
 
 // Replace will store the item using the given key, but only if the key
@@ -142,76 +156,18 @@ func Set[T any](c *Client, key string, item T, opts ...Option) error {
 // One or more Option(s) may be applied to configure things such as the
 // value expiration TTL or its associated flags.
 func Replace[T any](c *Client, key string, item T, opts ...Option) error {
-	if err := check(key); err != nil {
-		return err
-	}
-
-	options := &Options{
-		expiration: c.expiration,
-		flags:      0,
-	}
-
-	for _, opt := range opts {
-		opt(options)
-	}
-
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
-
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
-
-		// write the header components
-		if _, err := fmt.Fprintf(
-			conn,
-			"replace %s %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding),
-		); err != nil {
-			return err
-		}
-
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
-		}
-
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
-			return err
-		}
-
-		// flush the buffer
-		if err := conn.Flush(); err != nil {
-			return err
-		}
-
-		// read response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
-		}
-
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_STORED\r\n":
-			return ErrNotStored
-		default:
-			return fmt.Errorf("memc: unexpected response to replace: %q", string(line))
-		}
-	})
+	return store(c, "replace", key, item, 0, opts)
 }
 
 // Prepend will prepend the given value to the value associated with the given key.
 //
 // Prepend differs from Set in that it is meant to add additional data to an
 // existing key, rather than replace the existing value entirely. The key
-// must already exist.
+// must already exist, otherwise ErrNotStored is returned.
+//
+// Prepend is intended for raw values such as []byte or string, where the bytes
+// of item precede the bytes of the existing value. Prepending to a value of
+// any other encoding produces a value that cannot be decoded.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
@@ -219,78 +175,18 @@ func Replace[T any](c *Client, key string, item T, opts ...Option) error {
 // One or more Option(s) may be applied to configure things such as the
 // value expiration TTL or its associated flags.
 func Prepend[T any](c *Client, key string, item T, opts ...Option) error {
-	if err := check(key); err != nil {
-		return err
-	}
-
-	options := &Options{
-		expiration: c.expiration,
-		flags:      0,
-	}
-
-	for _, opt := range opts {
-		opt(options)
-	}
-
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
-
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
-
-		// write the header components
-		if _, err := fmt.Fprintf(
-			conn,
-			"prepend %s %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding),
-		); err != nil {
-			return err
-		}
-
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
-		}
-
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
-			return err
-		}
-
-		// flush the buffer
-		if err := conn.Flush(); err != nil {
-			return err
-		}
-
-		// read response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
-		}
-
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_STORED\r\n":
-			return ErrNotStored
-		case "NOT_FOUND\r\n":
-			return ErrNotFound
-		default:
-			return fmt.Errorf("memc: unexpected response to prepend: %q", string(line))
-		}
-	})
+	return store(c, "prepend", key, item, 0, opts)
 }
 
 // Append will append the given value to the value associated with the given key.
 //
 // Append differs from Set in that it is meant to add additional data to an
 // existing key, rather than replace the existing value entirely. The key
-// must already exist.
+// must already exist, otherwise ErrNotStored is returned.
+//
+// Append is intended for raw values such as []byte or string, where the bytes
+// of item extend the bytes of the existing value. Appending to a value of any
+// other encoding produces a value that cannot be decoded.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
@@ -298,46 +194,75 @@ func Prepend[T any](c *Client, key string, item T, opts ...Option) error {
 // One or more Option(s) may be applied to configure things such as the
 // value expiration TTL or its associated flags.
 func Append[T any](c *Client, key string, item T, opts ...Option) error {
-	if err := check(key); err != nil {
+	return store(c, "append", key, item, 0, opts)
+}
+
+// Add will store the item using the given key, but only if no item currently
+// exists. New items are at the top of the LRU.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// One or more Option(s) may be applied to configure things such as the
+// value expiration TTL or its associated flags.
+func Add[T any](c *Client, key string, item T, opts ...Option) error {
+	return store(c, "add", key, item, 0, opts)
+}
+
+// CompareAndSwap will store the item using the given key, but only if the CAS
+// token matches the current value's CAS token. This provides atomic
+// compare-and-swap functionality for optimistic locking.
+//
+// If the key does not exist, ErrNotFound is returned.
+//
+// If the CAS token does not match (meaning the value was modified since it was
+// retrieved with Gets), ErrConflict is returned.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// One or more Option(s) may be applied to configure things such as the value
+// expiration TTL or its associated flags.
+func CompareAndSwap[T any](c *Client, key string, cas CAS, item T, opts ...Option) error {
+	return store(c, "cas", key, item, cas, opts)
+}
+
+// store executes the storage command cmd (one of set, add, replace, append,
+// prepend, or cas) for item using the given key
+func store[T any](c *Client, cmd, key string, item T, cas CAS, opts []Option) error {
+	key, err := c.key(key)
+	if err != nil {
 		return err
 	}
 
-	options := &Options{
-		expiration: c.expiration,
-		flags:      0,
-	}
+	options := c.options(opts)
 
-	for _, opt := range opts {
-		opt(options)
-	}
+	scratch := getScratch()
+	defer putScratch(scratch)
 
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
+	encoding, flags, encerr := c.packInto(scratch, key, item, options.flags)
+	if encerr != nil {
+		return encerr
+	}
 
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
+	expiration, experr := c.expiry(options)
+	if experr != nil {
+		return experr
+	}
 
-		// write the header components
-		if _, err := fmt.Fprintf(
-			conn,
-			"append %s %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding),
-		); err != nil {
-			return err
-		}
+	// the locally cached value is stale once the value is changed
+	defer c.local.remove(key)
 
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
+	// values too large for memcached are stored as chunks, with the manifest
+	// of the chunks stored in place of the value
+	if c.oversized(cmd, len(encoding)) {
+		if encoding, flags, encerr = c.chunk(options.ctx, encoding, flags, expiration); encerr != nil {
+			return encerr
 		}
+	}
 
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
+	return c.do(options, cmd, key, func(conn *iopool.Buffer) error {
+		if err := c.writeStore(conn, cmd, key, flags, expiration, cas, encoding); err != nil {
 			return err
 		}
 
@@ -352,231 +277,298 @@ func Append[T any](c *Client, key string, item T, opts ...Option) error {
 			return lerr
 		}
 
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_STORED\r\n":
-			return ErrNotStored
-		case "NOT_FOUND\r\n":
-			return ErrNotFound
-		default:
-			return fmt.Errorf("memc: unexpected response to append: %q", string(line))
-		}
+		return c.storeResult(cmd, line)
 	})
 }
 
-// Add will store the item using the given key, but only if no item currently
-// exists. New items are at the top of the LRU.
-//
-// Uses Client c to connect to a memcached instance, and automatically handles
-// connection pooling and reuse.
-//
-// One or more Option(s) may be applied to configure things such as the
-// value expiration TTL or its associated flags.
-func Add[T any](c *Client, key string, item T, opts ...Option) error {
-	if err := check(key); err != nil {
-		return err
-	}
-
-	options := &Options{
-		expiration: c.expiration,
-		flags:      0,
+// writeStore writes the storage command cmd for the encoded value of key into
+// conn using the configured protocol, without flushing conn
+func (c *Client) writeStore(conn *iopool.Buffer, cmd, key string, flags, expiration int, cas CAS, encoding []byte) error {
+	switch c.protocol {
+	case Meta:
+		return metaWriteStore(conn, cmd, key, flags, expiration, cas, encoding)
+	default:
+		return textWriteStore(conn, cmd, key, flags, expiration, cas, encoding)
 	}
+}
 
-	for _, opt := range opts {
-		opt(options)
+// storeResult converts the response line to storage command cmd into the
+// corresponding error, if any, using the configured protocol
+func (c *Client) storeResult(cmd string, line []byte) error {
+	switch c.protocol {
+	case Meta:
+		return metaStoreResult(cmd, line)
+	default:
+		return textStoreResult(cmd, line)
 	}
+}
 
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
-
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
-
-		// write the header components
+func textWriteStore(conn *iopool.Buffer, cmd, key string, flags, expiration int, cas CAS, encoding []byte) error {
+	// write the header components, with the CAS token if necessary
+	if cmd == "cas" {
 		if _, err := fmt.Fprintf(
 			conn,
-			"add %s %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding),
+			"cas %s %d %d %d %d\r\n",
+			key, flags, expiration, len(encoding), cas,
 		); err != nil {
 			return err
 		}
-
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
-		}
-
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
+	} else {
+		if _, err := fmt.Fprintf(
+			conn,
+			"%s %s %d %d %d\r\n",
+			cmd, key, flags, expiration, len(encoding),
+		); err != nil {
 			return err
 		}
+	}
 
-		// flush the buffer
-		if err := conn.Flush(); err != nil {
-			return err
-		}
+	// write the payload
+	if _, err := conn.Write(encoding); err != nil {
+		return err
+	}
 
-		// read response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
-		}
+	// write clrf
+	_, err := io.WriteString(conn, "\r\n")
+	return err
+}
 
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_STORED\r\n":
-			return ErrNotStored
-		case "EXISTS\r\n":
-			return ErrConflict
-		default:
-			return fmt.Errorf("memc: unexpected response to set: %q", string(line))
-		}
-	})
+func textStoreResult(cmd string, line []byte) error {
+	switch string(line) {
+	case "STORED\r\n":
+		return nil
+	case "NOT_STORED\r\n":
+		return ErrNotStored
+	case "NOT_FOUND\r\n":
+		return ErrNotFound
+	case "EXISTS\r\n":
+		return ErrConflict
+	default:
+		return fmt.Errorf("memc: unexpected response to %s: %s", cmd, summarize(line))
+	}
 }
 
-// CompareAndSwap will store the item using the given key, but only if the CAS
-// token matches the current value's CAS token. This provides atomic
-// compare-and-swap functionality for optimistic locking.
+// Get the value associated with the given key.
 //
-// If the key does not exist, ErrNotFound is returned.
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse. If enabled by SetReadFallback, a cache miss or
+// failure is retried on the next memcached instance for key.
 //
-// If the CAS token does not match (meaning the value was modified since it was
-// retrieved with Gets), ErrConflict is returned.
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Get[T any](c *Client, key string, opts ...Option) (T, error) {
+	result, _, err := get[T](c, key, opts)
+	return result, err
+}
+
+// GetWithFlags gets the value associated with the given key, along with the
+// flags the value was stored with using the Flags option.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
-// connection pooling and reuse.
+// connection pooling and reuse. If enabled by SetReadFallback, a cache miss or
+// failure is retried on the next memcached instance for key.
 //
-// One or more Option(s) may be applied to configure things such as the value
-// expiration TTL or its associated flags.
-func CompareAndSwap[T any](c *Client, key string, cas CAS, item T, opts ...Option) error {
-	if err := check(key); err != nil {
-		return err
-	}
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func GetWithFlags[T any](c *Client, key string, opts ...Option) (T, int, error) {
+	result, flags, err := get[T](c, key, opts)
+	return result, c.userFlags(flags), err
+}
 
-	options := &Options{
-		expiration: c.expiration,
-		flags:      0,
-	}
+// get the value associated with key, along with the flags of the value as
+// stored on the memcached instance
+func get[T any](c *Client, key string, opts []Option) (T, int, error) {
+	var result T
+	var flags int
 
-	for _, opt := range opts {
-		opt(options)
+	key, err := c.key(key)
+	if err != nil {
+		return result, 0, err
 	}
 
-	return c.do(key, func(conn *iopool.Buffer) error {
-		encoding, encerr := encode(item)
-		if encerr != nil {
-			return encerr
-		}
+	options := c.options(opts)
 
-		expiration, experr := c.seconds(options.expiration)
-		if experr != nil {
-			return experr
-		}
+	// a verb pinned to an instance reads what the instance holds
+	local := options.server == ""
 
-		// write the header components with CAS token
-		if _, err := fmt.Fprintf(
-			conn,
-			"cas %s %d %d %d %d\r\n",
-			key, options.flags, expiration, len(encoding), cas,
-		); err != nil {
-			return err
-		}
+	if payload, cached, ok := c.local.get(key, c.now()); local && ok {
+		result, err = unpack[T](c, key, payload, cached)
+		return result, cached, err
+	}
 
-		// write the payload
-		if _, err := conn.Write(encoding); err != nil {
-			return err
-		}
+	var manifest []byte
 
-		// write clrf
-		if _, err := io.WriteString(conn, "\r\n"); err != nil {
+	get := func(conn *iopool.Buffer) error {
+		payload, h, err := c.fetch(conn, key, false)
+		if err != nil {
 			return err
 		}
+		defer c.payloads(conn).put(payload)
 
-		// flush the buffer
-		if err := conn.Flush(); err != nil {
-			return err
+		flags = h.flags
+
+		if c.chunked(h.flags) {
+			manifest = slices.Clone(*payload)
+			return nil
 		}
 
-		// read response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
+		if local {
+			c.local.put(key, slices.Clone(*payload), h.flags, c.now())
 		}
 
-		switch string(line) {
-		case "STORED\r\n":
-			return nil
-		case "NOT_FOUND\r\n":
-			return ErrNotFound
-		case "EXISTS\r\n":
-			return ErrConflict
-		default:
-			return fmt.Errorf("memc: unexpected response to cas: %q", string(line))
+		result, err = unpack[T](c, key, *payload, h.flags)
+		return err
+	}
+
+	err = c.doRetry(options, "get", key, get)
+	if c.fallback && options.server == "" && retryable(err) {
+		if secondary := c.secondary(key); secondary != "" {
+			if ferr := c.doInstance(options.ctx, "get_fallback", secondary, get); ferr == nil {
+				err = nil
+			}
 		}
-	})
+	}
+	c.countRemote(err)
+
+	if err == nil && manifest != nil {
+		result, err = unchunk[T](options.ctx, c, key, manifest, flags)
+	}
+
+	return result, flags, err
 }
 
-// Get the value associated with the given key.
+// retryable reports whether a verb that failed with err may be attempted on
+// another memcached instance, which is the case unless err is caused by the
+// context of the verb, or is a value that was read, such as a known miss
+func retryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrKnownMiss), errors.Is(err, ErrEncoding):
+		return false
+	default:
+		return true
+	}
+}
+
+// Gets the value associated with the given key, along with its CAS token.
+//
+// The CAS token can be used with CompareAndSwap to atomically update the value,
+// providing optimistic locking. If the value has been modified since it was
+// retrieved, CompareAndSwap will return an ErrConflict error.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
-func Get[T any](c *Client, key string) (T, error) {
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Gets[T any](c *Client, key string, opts ...Option) (T, CAS, error) {
+	result, casToken, _, err := gets[T](c, key, opts)
+	return result, casToken, err
+}
+
+// gets the value associated with key, along with its CAS token and the flags
+// of the value as stored on the memcached instance
+func gets[T any](c *Client, key string, opts []Option) (T, CAS, int, error) {
 	var result T
+	var casToken CAS
+	var flags int
 
-	if err := check(key); err != nil {
-		return result, err
+	key, err := c.key(key)
+	if err != nil {
+		return result, 0, 0, err
 	}
 
-	err := c.do(key, func(conn *iopool.Buffer) error {
-		// write the header components
-		if _, err := fmt.Fprintf(conn, "get %s\r\n", key); err != nil {
-			return err
-		}
+	options := c.options(opts)
 
-		// flush the connection, forcing bytes over the wire
-		if err := conn.Flush(); err != nil {
-			return err
-		}
+	var manifest []byte
 
-		// read the response payload
-		payload, err := getPayload(conn.Reader)
+	err = c.doRetry(options, "gets", key, func(conn *iopool.Buffer) error {
+		payload, h, err := c.fetch(conn, key, true)
 		if err != nil {
 			return err
 		}
+		defer c.payloads(conn).put(payload)
+
+		casToken = CAS(h.cas)
+		flags = h.flags
+
+		if c.chunked(h.flags) {
+			manifest = slices.Clone(*payload)
+			return nil
+		}
 
-		result, err = decode[T](payload)
+		result, err = unpack[T](c, key, *payload, h.flags)
 		return err
 	})
 
-	return result, err
+	if err == nil && manifest != nil {
+		result, err = unchunk[T](options.ctx, c, key, manifest, flags)
+	}
+
+	return result, casToken, flags, err
 }
 
-// Gets the value associated with the given key, along with its CAS token.
+// fetch requests the value of key over conn, along with its CAS unique if
+// cas is set, using the configured protocol
+func (c *Client) fetch(conn *iopool.Buffer, key string, cas bool) (*[]byte, header, error) {
+	return c.fetchWith(conn, key, cas, c.payloads(conn))
+}
+
+// fetchWith is fetch, reading the payload into a buffer of p
+func (c *Client) fetchWith(conn *iopool.Buffer, key string, cas bool, p payloads) (*[]byte, header, error) {
+	switch c.protocol {
+	case Meta:
+		return metaFetch(conn, key, cas, p)
+	default:
+		return textFetch(conn, key, cas, p)
+	}
+}
+
+func textFetch(conn *iopool.Buffer, key string, cas bool, p payloads) (*[]byte, header, error) {
+	cmd := "get"
+	if cas {
+		cmd = "gets"
+	}
+
+	// write the header components
+	if _, err := fmt.Fprintf(conn, "%s %s\r\n", cmd, key); err != nil {
+		return nil, header{}, err
+	}
+
+	// flush the connection, forcing bytes over the wire
+	if err := conn.Flush(); err != nil {
+		return nil, header{}, err
+	}
+
+	// read the response payload
+	return getPayload(conn.Reader, key, p)
+}
+
+// Exists reports whether a value is associated with the given key.
 //
-// The CAS token can be used with CompareAndSwap to atomically update the value,
-// providing optimistic locking. If the value has been modified since it was
-// retrieved, CompareAndSwap will return an ErrConflict error.
+// Exists is implemented with the meta get command without requesting the
+// value, such that the value itself is never transferred over the network.
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
-func Gets[T any](c *Client, key string) (T, CAS, error) {
-	var result T
-	var casToken CAS
-
-	if err := check(key); err != nil {
-		return result, 0, err
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Exists(c *Client, key string, opts ...Option) (bool, error) {
+	key, err := c.key(key)
+	if err != nil {
+		return false, err
 	}
 
-	err := c.do(key, func(conn *iopool.Buffer) error {
-		// write the header components
-		if _, err := fmt.Fprintf(conn, "gets %s\r\n", key); err != nil {
+	var exists bool
+	options := c.options(opts)
+
+	err = c.do(options, "exists", key, func(conn *iopool.Buffer) error {
+		// write the header components, requesting no flags
+		if _, err := fmt.Fprintf(conn, "mg %s\r\n", key); err != nil {
 			return err
 		}
 
@@ -585,108 +577,114 @@ func Gets[T any](c *Client, key string) (T, CAS, error) {
 			return err
 		}
 
-		// read the response payload with CAS token
-		payload, cas, err := getPayloadWithCAS(conn.Reader)
-		if err != nil {
-			return err
+		line, lerr := conn.ReadSlice('\n')
+		if lerr != nil {
+			return lerr
 		}
 
-		result, err = decode[T](payload)
-		if err != nil {
-			return err
+		switch string(line) {
+		case "HD\r\n":
+			exists = true
+			return nil
+		case "EN\r\n":
+			exists = false
+			return nil
+		default:
+			return unexpected(line)
 		}
-
-		casToken = CAS(cas)
-		return nil
 	})
 
-	return result, casToken, err
+	return exists, err
 }
 
-func getPayload(r *bufio.Reader) ([]byte, error) {
-	b, err := r.ReadSlice('\n')
-	if err != nil {
-		return nil, err
-	}
+// header represents the line preceding a value payload, in the form
+// "VALUE <key> <flags> <bytes> [<cas unique>]\r\n"
+type header struct {
+	key   string
+	flags int
+	size  int
+	cas   uint64
+}
 
-	// key was not found, is a cache miss
-	if string(b) == "END\r\n" {
-		return nil, ErrCacheMiss
+// parseHeader parses the line preceding a value payload, the key of which is
+// expected to be key if not empty
+func parseHeader(key string, b []byte) (header, error) {
+	name, rest := field(trimLine(b))
+	if string(name) != "VALUE" {
+		return header{}, unexpected(b)
 	}
 
-	expect := "VALUE %s %d %d\r\n"
-	var (
-		key   string
-		flags int
-		size  int
-	)
-
-	// scan the header line, giving us a payload size
-	if _, err = fmt.Sscanf(string(b), expect, &key, &flags, &size); err != nil {
-		return nil, err
+	k, rest := field(rest)
+	f, rest := field(rest)
+	n, rest := field(rest)
+	u, rest := field(rest)
+	if extra, _ := field(rest); len(k) == 0 || len(extra) > 0 {
+		return header{}, unexpected(b)
 	}
 
-	// read the data into our payload
-	payload := make([]byte, size+2) // including \r\n
-	if _, err = io.ReadFull(r, payload); err != nil {
-		return nil, err
+	flags, fok := parseInt(f)
+	size, sok := parseInt(n)
+	if !fok || !sok {
+		return header{}, unexpected(b)
 	}
-	payload = payload[0:size] // chop \r\n
 
-	// read the trailing line ("END\r\n")
-	b, err = r.ReadSlice('\n')
-	if err != nil {
-		return nil, err
+	h := header{
+		key:   keyOf(key, k),
+		flags: flags,
+		size:  size,
 	}
-	if string(b) != "END\r\n" {
-		return nil, unexpected(b)
+
+	// the cas unique is only present in response to gets
+	if len(u) > 0 {
+		cas, cok := parseUint(u)
+		if !cok {
+			return header{}, unexpected(b)
+		}
+		h.cas = cas
 	}
 
-	return payload, err
+	return h, nil
 }
 
-func getPayloadWithCAS(r *bufio.Reader) ([]byte, uint64, error) {
+// getPayload reads a single value of key from r into a buffer of p, which
+// should be released with p.put once the payload has been decoded
+func getPayload(r *bufio.Reader, key string, p payloads) (*[]byte, header, error) {
 	b, err := r.ReadSlice('\n')
 	if err != nil {
-		return nil, 0, err
+		return nil, header{}, err
 	}
 
 	// key was not found, is a cache miss
 	if string(b) == "END\r\n" {
-		return nil, 0, ErrCacheMiss
+		return nil, header{}, ErrCacheMiss
 	}
 
-	// handle CAS value - format is "VALUE key flags bytes cas\r\n"
-	expect := "VALUE %s %d %d %d\r\n"
-	var (
-		key   string
-		flags int
-		size  int
-		cas   uint64
-	)
-
-	// scan the header line, giving us a payload size and CAS token
-	if _, err = fmt.Sscanf(string(b), expect, &key, &flags, &size, &cas); err != nil {
-		return nil, 0, err
+	// parse the header line, giving us a payload size
+	h, herr := parseHeader(key, b)
+	if herr != nil {
+		return nil, header{}, herr
 	}
 
 	// read the data into our payload
-	payload := make([]byte, size+2) // including \r\n
-	if _, err = io.ReadFull(r, payload); err != nil {
-		return nil, 0, err
+	payload := p.get(h.size + 2) // including \r\n
+	if _, err = io.ReadFull(r, *payload); err != nil {
+		p.put(payload)
+		return nil, header{}, err
 	}
-	payload = payload[0:size] // chop \r\n
+	*payload = (*payload)[0:h.size] // chop \r\n
 
 	// read the trailing line ("END\r\n")
 	b, err = r.ReadSlice('\n')
 	if err != nil {
-		return nil, 0, err
+		p.put(payload)
+		return nil, header{}, err
 	}
 	if string(b) != "END\r\n" {
-		return nil, 0, unexpected(b)
+		p.put(payload)
+		return nil, header{}, unexpected(b)
 	}
 
-	return payload, cas, nil
+	return payload, h, nil
 }
 
 // Flush will delete all items from memcached.
@@ -698,8 +696,13 @@ func getPayloadWithCAS(r *bufio.Reader) ([]byte, uint64, error) {
 // the Client is configured with multiple server addresses. This is intentional,
 // as flush is typically used by local administration tools that connect to a
 // single memcached instance.
-func Flush(c *Client, timeout time.Duration) error {
-	return c.do("", func(conn *iopool.Buffer) error {
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Flush(c *Client, timeout time.Duration, opts ...Option) error {
+	options := c.options(opts)
+
+	return c.do(options, "flush_all", "", func(conn *iopool.Buffer) error {
 		expiration, err := c.seconds(timeout)
 		if err != nil {
 			return err
@@ -735,12 +738,23 @@ func Flush(c *Client, timeout time.Duration) error {
 //
 // Uses Client c to connect to a memcached instance, and automatically handles
 // connection pooling and reuse.
-func Delete(c *Client, key string) error {
-	if err := check(key); err != nil {
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Delete(c *Client, key string, opts ...Option) error {
+	key, err := c.key(key)
+	if err != nil {
 		return err
 	}
 
-	return c.do(key, func(conn *iopool.Buffer) error {
+	options := c.options(opts)
+	defer c.local.remove(key)
+
+	return c.do(options, "delete", key, func(conn *iopool.Buffer) error {
+		if c.protocol == Meta {
+			return metaDelete(conn, key)
+		}
+
 		// write the header components
 		if _, err := fmt.Fprintf(
 			conn,
@@ -771,30 +785,41 @@ func Delete(c *Client, key string) error {
 	})
 }
 
-// Increment will increment the value associated with the given key by delta.
+// Touch will update the expiration time of the value associated with the
+// given key, without transferring the value itself.
 //
-// Note: the value must be an ASCII integer. It must have been initially stored
-// as a string value, e.g. by using Set. The delta value must be positive.
+// The ttl must be greater than 1 second, or 0, indicating the value will not
+// expire automatically. A ttl of more than 30 days is converted into an
+// absolute time as with TTL.
 //
-//	Set(client, "counter", "100")
-//	Increment(client, "counter", 1) // counter = 101
-func Increment[T Countable](c *Client, key string, delta T) (T, error) {
-	if err := check(key); err != nil {
-		return T(0), err
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Touch(c *Client, key string, ttl time.Duration, opts ...Option) error {
+	key, err := c.key(key)
+	if err != nil {
+		return err
 	}
 
-	if delta < 0 {
-		return T(0), ErrNegativeInc
+	options := c.options(opts)
+
+	expiration, experr := c.seconds(ttl)
+	if experr != nil {
+		return experr
 	}
 
-	var result T
+	return c.doRetry(options, "touch", key, func(conn *iopool.Buffer) error {
+		if c.protocol == Meta {
+			return metaTouch(conn, key, expiration)
+		}
 
-	err := c.do(key, func(conn *iopool.Buffer) error {
 		// write the header components
 		if _, err := fmt.Fprintf(
 			conn,
-			"incr %s %d\r\n",
-			key, delta,
+			"touch %s %d\r\n",
+			key, expiration,
 		); err != nil {
 			return err
 		}
@@ -804,46 +829,59 @@ func Increment[T Countable](c *Client, key string, delta T) (T, error) {
 			return err
 		}
 
-		// read the response
 		line, lerr := conn.ReadSlice('\n')
 		if lerr != nil {
 			return lerr
 		}
 
-		// check for error response
-		s := string(line)
-		switch {
-		case s == "NOT_FOUND\r\n":
+		switch string(line) {
+		case "TOUCHED\r\n":
+			return nil
+		case "NOT_FOUND\r\n":
 			return ErrNotFound
-		case strings.Contains(s, "cannot increment or decrement non-numeric value"):
-			return ErrNonNumeric
-		}
-
-		// parse response as the resulting value
-		s = strings.TrimSpace(s)
-		u, uerr := strconv.ParseUint(s, 10, 64)
-		if uerr != nil {
+		default:
 			return unexpected(line)
 		}
-
-		// recast to value type
-		result = T(u)
-
-		return nil
 	})
+}
 
-	return result, err
+// Increment will increment the value associated with the given key by delta.
+//
+// Note: the value must be an ASCII integer. It must have been initially stored
+// as a string value, e.g. by using Set. The delta value must be positive. The
+// value cannot be stored by a Client configured with SetEncryptionKey, as the
+// encrypted value is not an ASCII integer.
+//
+//	Set(client, "counter", "100")
+//	Increment(client, "counter", 1) // counter = 101
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Increment[T Countable](c *Client, key string, delta T, opts ...Option) (T, error) {
+	return arithmetic(c, "incr", key, delta, opts)
 }
 
 // Decrement will decrement the value associated with the given key by delta.
 //
 // Note: the value must be an ASCII integer. It must have been initially stored
-// as a string value, e.g. by using Set. The delta value must be positive.
+// as a string value, e.g. by using Set. The delta value must be positive. The
+// value cannot be stored by a Client configured with SetEncryptionKey, as the
+// encrypted value is not an ASCII integer.
 //
 //	Set(client, "counter", "100")
 //	Decrement(client, "counter", 1) // counter = 99
-func Decrement[T Countable](c *Client, key string, delta T) (T, error) {
-	if err := check(key); err != nil {
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Decrement[T Countable](c *Client, key string, delta T, opts ...Option) (T, error) {
+	return arithmetic(c, "decr", key, delta, opts)
+}
+
+// arithmetic executes the command cmd (one of incr or decr) to adjust the
+// value associated with the given key by delta
+func arithmetic[T Countable](c *Client, cmd, key string, delta T, opts []Option) (T, error) {
+	key, err := c.key(key)
+	if err != nil {
 		return T(0), err
 	}
 
@@ -852,14 +890,11 @@ func Decrement[T Countable](c *Client, key string, delta T) (T, error) {
 	}
 
 	var result T
+	options := c.options(opts)
+	defer c.local.remove(key)
 
-	err := c.do(key, func(conn *iopool.Buffer) error {
-		// write the header components
-		if _, err := fmt.Fprintf(
-			conn,
-			"decr %s %d\r\n",
-			key, delta,
-		); err != nil {
+	err = c.do(options, cmd, key, func(conn *iopool.Buffer) error {
+		if err := c.writeArithmetic(conn, cmd, key, uint64(delta)); err != nil {
 			return err
 		}
 
@@ -868,35 +903,64 @@ func Decrement[T Countable](c *Client, key string, delta T) (T, error) {
 			return err
 		}
 
-		// read the response
-		line, lerr := conn.ReadSlice('\n')
-		if lerr != nil {
-			return lerr
-		}
+		// read the response, recast to value type
+		u, err := c.readArithmetic(conn)
+		result = T(u)
+		return err
+	})
 
-		// check for error response
-		s := string(line)
-		switch {
-		case s == "NOT_FOUND\r\n":
-			return ErrNotFound
-		case strings.Contains(s, "cannot increment or decrement non-numeric value"):
-			return ErrNonNumeric
-		}
+	return result, err
+}
 
-		// parse response as the resulting value
-		s = strings.TrimSpace(s)
-		u, uerr := strconv.ParseUint(s, 10, 64)
-		if uerr != nil {
-			return unexpected(line)
-		}
+// writeArithmetic writes the arithmetic command cmd (one of incr or decr) for
+// key into conn using the configured protocol, without flushing conn
+func (c *Client) writeArithmetic(conn *iopool.Buffer, cmd, key string, delta uint64) error {
+	switch c.protocol {
+	case Meta:
+		return metaWriteArithmetic(conn, cmd, key, delta)
+	default:
+		_, err := fmt.Fprintf(conn, "%s %s %d\r\n", cmd, key, delta)
+		return err
+	}
+}
 
-		// recast to value type
-		result = T(u)
+// readArithmetic reads the response to an arithmetic command from conn using
+// the configured protocol, returning the resulting value
+//
+// Only an error of ErrNotFound or ErrNonNumeric leaves conn in a state where
+// further responses may be read.
+func (c *Client) readArithmetic(conn *iopool.Buffer) (uint64, error) {
+	switch c.protocol {
+	case Meta:
+		return metaReadArithmetic(conn)
+	default:
+		return textReadArithmetic(conn)
+	}
+}
 
-		return nil
-	})
+func textReadArithmetic(conn *iopool.Buffer) (uint64, error) {
+	line, lerr := conn.ReadSlice('\n')
+	if lerr != nil {
+		return 0, lerr
+	}
 
-	return result, err
+	// check for error response
+	s := string(line)
+	switch {
+	case s == "NOT_FOUND\r\n":
+		return 0, ErrNotFound
+	case strings.Contains(s, "cannot increment or decrement non-numeric value"):
+		return 0, ErrNonNumeric
+	}
+
+	// parse response as the resulting value
+	s = strings.TrimSpace(s)
+	u, uerr := strconv.ParseUint(s, 10, 64)
+	if uerr != nil {
+		return 0, unexpected(line)
+	}
+
+	return u, nil
 }
 
 // Stats returns runtime statistics for a single memcached server.
@@ -904,11 +968,16 @@ func Decrement[T Countable](c *Client, key string, delta T) (T, error) {
 // Note: this operation is performed on a single memcached server, even when
 // the Client is configured with multiple server addresses. This is intentional,
 // as stats is typically used by local monitoring tools that connect to a
-// single memcached instance.
-func Stats(c *Client) (*Statistics, error) {
+// single memcached instance. Use Client.Stats to query every instance, or
+// apply Server to choose the instance queried.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func Stats(c *Client, opts ...Option) (*Statistics, error) {
 	var statistics *Statistics
+	options := c.options(opts)
 
-	err := c.do("", func(conn *iopool.Buffer) error {
+	err := c.do(options, "stats", "", func(conn *iopool.Buffer) error {
 		// write the header component
 		if _, err := fmt.Fprintf(conn, "stats\r\n"); err != nil {
 			return err
@@ -937,11 +1006,15 @@ func Stats(c *Client) (*Statistics, error) {
 // Note: this operation is performed on a single memcached server, even when
 // the Client is configured with multiple server addresses. This is intentional,
 // as stats is typically used by local monitoring tools that connect to a
-// single memcached instance.
-func StatsSlabs(c *Client) (*SlabStatistics, error) {
+// single memcached instance. Use Client.StatsSlabs to query every instance.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func StatsSlabs(c *Client, opts ...Option) (*SlabStatistics, error) {
 	var statistics *SlabStatistics
+	options := c.options(opts)
 
-	err := c.do("", func(conn *iopool.Buffer) error {
+	err := c.do(options, "stats_slabs", "", func(conn *iopool.Buffer) error {
 		// write the header component
 		if _, err := fmt.Fprintf(conn, "stats slabs\r\n"); err != nil {
 			return err
@@ -970,11 +1043,15 @@ func StatsSlabs(c *Client) (*SlabStatistics, error) {
 // Note: this operation is performed on a single memcached server, even when
 // the Client is configured with multiple server addresses. This is intentional,
 // as stats is typically used by local monitoring tools that connect to a
-// single memcached instance.
-func StatsItems(c *Client) ([]*ItemStatistics, error) {
+// single memcached instance. Use Client.StatsItems to query every instance.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func StatsItems(c *Client, opts ...Option) ([]*ItemStatistics, error) {
 	var statistics []*ItemStatistics
+	options := c.options(opts)
 
-	err := c.do("", func(conn *iopool.Buffer) error {
+	err := c.do(options, "stats_items", "", func(conn *iopool.Buffer) error {
 		// write the header component
 		if _, err := fmt.Fprintf(conn, "stats items\r\n"); err != nil {
 			return err
@@ -998,9 +1075,77 @@ func StatsItems(c *Client) ([]*ItemStatistics, error) {
 	return statistics, err
 }
 
+// StatsConns returns the state of each connection to a single memcached
+// server, such as the address of its client and how long it has been idle.
+//
+// Note: this operation is performed on a single memcached server, even when
+// the Client is configured with multiple server addresses. This is intentional,
+// as stats is typically used by local monitoring tools that connect to a
+// single memcached instance. Use Client.StatsConns to query every instance.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func StatsConns(c *Client, opts ...Option) ([]*ConnStatistics, error) {
+	var statistics []*ConnStatistics
+	options := c.options(opts)
+
+	err := c.do(options, "stats_conns", "", func(conn *iopool.Buffer) error {
+		// write the header component
+		if _, err := fmt.Fprintf(conn, "stats conns\r\n"); err != nil {
+			return err
+		}
+
+		// flush the connection, forcing bytes over the wire
+		if err := conn.Flush(); err != nil {
+			return err
+		}
+
+		// extract the conns stats payload
+		payload, perr := conns(conn.Reader)
+		if perr != nil {
+			return perr
+		}
+		statistics = payload
+
+		return nil
+	})
+
+	return statistics, err
+}
+
 func unexpected(response []byte) error {
 	return fmt.Errorf(
-		"unexpected response from memcached %q",
-		string(response),
+		"unexpected response from memcached %s",
+		summarize(response),
 	)
 }
+
+// maxToken is the length of the longest leading token of a response included
+// in the error of an unexpected response
+const maxToken = 16
+
+// summarize describes a response by its leading token and its length, for the
+// error of an unexpected response. The response may be the bytes of a value,
+// e.g. after the connection is out of sync, so only a leading token that is a
+// keyword of the protocol, such as SERVER_ERROR, is included.
+func summarize(response []byte) string {
+	token, _ := field(trimLine(response))
+	if !keyword(token) {
+		return fmt.Sprintf("(%d bytes)", len(response))
+	}
+	return fmt.Sprintf("%q (%d bytes)", token, len(response))
+}
+
+// keyword returns whether token is made of only upper case letters and
+// underscores, as are the keywords of the memcached protocol
+func keyword(token []byte) bool {
+	if len(token) == 0 || len(token) > maxToken {
+		return false
+	}
+	for _, b := range token {
+		if (b < 'A' || b > 'Z') && b != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/shoenig/test/must"
+)
+
+func Test_parseHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get", func(t *testing.T) {
+		h, err := parseHeader("mykey", []byte("VALUE mykey 3 12\r\n"))
+		must.NoError(t, err)
+		must.Eq(t, header{key: "mykey", flags: 3, size: 12}, h)
+	})
+
+	t.Run("gets", func(t *testing.T) {
+		h, err := parseHeader("mykey", []byte("VALUE mykey 0 5 9001\r\n"))
+		must.NoError(t, err)
+		must.Eq(t, header{key: "mykey", size: 5, cas: 9001}, h)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := parseHeader("mykey", []byte("VALUE mykey zero 5\r\n"))
+		must.Error(t, err)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		_, err := parseHeader("mykey", []byte("SERVER_ERROR out of memory\r\n"))
+		must.Error(t, err)
+	})
+}
+
+func Test_getPayload(t *testing.T) {
+	t.Parallel()
+
+	t.Run("hit", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("VALUE k 0 5 42\r\nhello\r\nEND\r\n"))
+		payload, h, err := getPayload(r, "k", payloads{})
+		must.NoError(t, err)
+		must.Eq(t, "hello", string(*payload))
+		must.Eq(t, 42, h.cas)
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("END\r\n"))
+		_, _, err := getPayload(r, "k", payloads{})
+		must.ErrorIs(t, err, ErrCacheMiss)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		r := bufio.NewReader(strings.NewReader("VALUE k 0 5\r\nhel"))
+		_, _, err := getPayload(r, "k", payloads{})
+		must.Error(t, err)
+	})
+}
+
+func Test_unexpected(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keyword", func(t *testing.T) {
+		err := unexpected([]byte("SERVER_ERROR secret value\r\n"))
+		must.EqError(t, err, `unexpected response from memcached "SERVER_ERROR" (27 bytes)`)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		err := unexpected([]byte("secret value\r\n"))
+		must.EqError(t, err, "unexpected response from memcached (14 bytes)")
+	})
+
+	t.Run("store", func(t *testing.T) {
+		err := textStoreResult("set", []byte("password=hunter2\r\n"))
+		must.StrNotContains(t, err.Error(), "hunter2")
+
+		err = metaStoreResult("ms", []byte("password=hunter2\r\n"))
+		must.StrNotContains(t, err.Error(), "hunter2")
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright CattleCloud LLC 2025, 2026
+// SPDX-License-Identifier: BSD-3-Clause
+
+package memc
+
+import (
+	"slices"
+
+	"cattlecloud.net/go/memc/iopool"
+)
+
+// A View is a value read by GetView, which may borrow the pooled buffer the
+// value was read into rather than being a copy of it.
+//
+// The bytes of a View are valid until Release is called, after which they must
+// not be used, as the buffer may be reused by any other read.
+type View struct {
+	buffer *[]byte
+	value  []byte
+}
+
+// Bytes returns the value of v, which must not be used after Release.
+func (v *View) Bytes() []byte {
+	return v.value
+}
+
+// Release returns the buffer borrowed by v to the pool. Release may be called
+// more than once, and v must not be used afterwards.
+func (v *View) Release() {
+	if v.buffer != nil {
+		putBuffer(v.buffer)
+		v.buffer = nil
+	}
+	v.value = nil
+}
+
+// GetView reads the value associated with the given key as a View, for callers
+// that choose to borrow the buffer a value is read into rather than have Get
+// copy the value out of it, e.g. to write a large value into a response
+// without allocating a copy of it. The View must be released once the value is
+// no longer used.
+//
+// A value stored as is, without compression, encryption, or chunking, is
+// borrowed from the pooled buffer it is read into, even with SetPayloadReuse.
+// Other values are decoded into a View of a copy, as with Get[[]byte]. Values
+// read by GetView are not kept by the local cache of SetLocalCache, though
+// values already kept there are copied into the View.
+//
+// Uses Client c to connect to a memcached instance, and automatically handles
+// connection pooling and reuse. If enabled by SetReadFallback, a cache miss or
+// failure is retried on the next memcached instance for key.
+//
+// An Option such as Context may be applied to bound the time spent waiting on
+// the memcached instance.
+func GetView(c *Client, key string, opts ...Option) (*View, error) {
+	key, err := c.key(key)
+	if err != nil {
+		return nil, err
+	}
+
+	options := c.options(opts)
+
+	// a verb pinned to an instance reads what the instance holds
+	if payload, cached, ok := c.local.get(key, c.now()); options.server == "" && ok {
+		value, err := unpack[[]byte](c, key, payload, cached)
+		if err != nil {
+			return nil, err
+		}
+		return &View{value: value}, nil
+	}
+
+	var (
+		view     *View
+		flags    int
+		manifest []byte
+	)
+
+	get := func(conn *iopool.Buffer) error {
+		// the payload outlives the use of conn, so is never read into the
+		// scratch buffer of conn
+		p := payloads{conn: conn}
+		payload, h, err := c.fetchWith(conn, key, false, p)
+		if err != nil {
+			return err
+		}
+		flags = h.flags
+
+		switch {
+		case c.plain(h.flags):
+			view = &View{buffer: payload, value: *payload}
+			return nil
+		case c.chunked(h.flags):
+			manifest = slices.Clone(*payload)
+			p.put(payload)
+			return nil
+		default:
+			defer p.put(payload)
+			value, err := unpack[[]byte](c, key, *payload, h.flags)
+			if err != nil {
+				return err
+			}
+			view = &View{value: value}
+			return nil
+		}
+	}
+
+	err = c.doRetry(options, "get", key, get)
+	if c.fallback && options.server == "" && retryable(err) {
+		if secondary := c.secondary(key); secondary != "" {
+			if ferr := c.doInstance(options.ctx, "get_fallback", secondary, get); ferr == nil {
+				err = nil
+			}
+		}
+	}
+	c.countRemote(err)
+
+	if err == nil && manifest != nil {
+		var value []byte
+		if value, err = unchunk[[]byte](options.ctx, c, key, manifest, flags); err == nil {
+			view = &View{value: value}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return view, nil
+}